@@ -8,6 +8,7 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -21,6 +22,10 @@ type Config struct {
 	OAuth    OAuthConfig
 	JWT      JWTConfig
 	Redis    RedisConfig
+	MFA      MFAConfig
+	WebAuthn WebAuthnConfig
+	Lockout  LockoutConfig
+	OIDC     OIDCConfig
 }
 
 type BackendConfig struct {
@@ -46,14 +51,54 @@ type PostgresConfig struct {
 }
 
 type OAuthConfig struct {
-	ClientID          string
-	ClientSecret      string
-	ClientCallbackURL string
+	Providers []OAuthProviderConfig
+}
+
+// OAuthProviderConfig configures one sign in provider, Name is used in the /api/auth/:provider routes
+type OAuthProviderConfig struct {
+	Name         string
+	Type         string
+	DisplayName  string
+	ClientID     string
+	ClientSecret string
+	CallbackURL  string
+	DiscoveryURL string
+	Scopes       []string
 }
 
 type JWTConfig struct {
-	Token  string
-	Expiry string
+	Token         string
+	Expiry        string
+	RefreshExpiry string
+	SigningKeys   string
+	ActiveKeyID   string
+}
+
+type MFAConfig struct {
+	Issuer        string
+	EncryptionKey string
+}
+
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     string
+}
+
+type OIDCConfig struct {
+	Issuer            string
+	CodeExpiry        string
+	AccessTokenExpiry string
+	IDTokenExpiry     string
+}
+
+type LockoutConfig struct {
+	MaxAttempts    string
+	IPMaxAttempts  string
+	OTPMaxAttempts string
+	Window         string
+	Duration       string
+	BaseDelay      string
 }
 
 type SMTPConfig struct {
@@ -89,14 +134,13 @@ func LoadConfig() (*Config, error) {
 			Port:     os.Getenv("POSTGRES_PORT"),
 			SslMode:  os.Getenv("POSTGRES_SSL_MODE"),
 		},
-		OAuth: OAuthConfig{
-			ClientID:          os.Getenv("GOOGLE_CLIENT_ID"),
-			ClientSecret:      os.Getenv("GOOGLE_CLIENT_SECRET"),
-			ClientCallbackURL: os.Getenv("GOOGLE_CLIENT_CALLBACK_URL"),
-		},
+		OAuth: loadOAuthConfig(),
 		JWT: JWTConfig{
-			Token:  os.Getenv("JWT_TOKEN"),
-			Expiry: os.Getenv("JWT_EXPIRY"),
+			Token:         os.Getenv("JWT_TOKEN"),
+			Expiry:        os.Getenv("JWT_EXPIRY"),
+			RefreshExpiry: getEnvOrDefault("JWT_REFRESH_EXPIRY", "720h"),
+			SigningKeys:   os.Getenv("JWT_SIGNING_KEYS"),
+			ActiveKeyID:   os.Getenv("JWT_ACTIVE_KEY_ID"),
 		},
 		SMTP: SMTPConfig{
 			Host:     os.Getenv("EMAIL_HOST"),
@@ -109,7 +153,81 @@ func LoadConfig() (*Config, error) {
 			Database: os.Getenv("REDIS_DATABASE"),
 			Password: os.Getenv("REDIS_PASSWORD"),
 		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnvOrDefault("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnvOrDefault("WEBAUTHN_RP_NAME", "Go Auth Module"),
+			RPOrigins:     getEnvOrDefault("WEBAUTHN_RP_ORIGINS", "http://localhost:5173"),
+		},
+		Lockout: LockoutConfig{
+			MaxAttempts:    getEnvOrDefault("LOCKOUT_MAX_ATTEMPTS", "5"),
+			IPMaxAttempts:  getEnvOrDefault("LOCKOUT_IP_MAX_ATTEMPTS", "20"),
+			OTPMaxAttempts: getEnvOrDefault("LOCKOUT_OTP_MAX_ATTEMPTS", "5"),
+			Window:         getEnvOrDefault("LOCKOUT_WINDOW", "15m"),
+			Duration:       getEnvOrDefault("LOCKOUT_DURATION", "15m"),
+			BaseDelay:      getEnvOrDefault("LOCKOUT_BASE_DELAY", "1s"),
+		},
+		OIDC: OIDCConfig{
+			Issuer:            getEnvOrDefault("OIDC_ISSUER", "http://localhost:3000"),
+			CodeExpiry:        getEnvOrDefault("OIDC_CODE_EXPIRY", "1m"),
+			AccessTokenExpiry: getEnvOrDefault("OIDC_ACCESS_TOKEN_EXPIRY", "1h"),
+			IDTokenExpiry:     getEnvOrDefault("OIDC_ID_TOKEN_EXPIRY", "1h"),
+		},
+		MFA: MFAConfig{
+			Issuer:        getEnvOrDefault("MFA_ISSUER", "Go Auth Module"),
+			EncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+		},
 	}
 
 	return cfg, nil
 }
+
+// loadOAuthConfig reads the providers listed in OAUTH_PROVIDERS, each configured with OAUTH_<NAME>_* variables.
+// Deployments that only set the GOOGLE_* variables keep signing in with Google.
+func loadOAuthConfig() OAuthConfig {
+	names := os.Getenv("OAUTH_PROVIDERS")
+	if names == "" {
+		if os.Getenv("GOOGLE_CLIENT_ID") == "" {
+			return OAuthConfig{}
+		}
+		return OAuthConfig{Providers: []OAuthProviderConfig{{
+			Name:         "google",
+			Type:         "google",
+			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			CallbackURL:  os.Getenv("GOOGLE_CLIENT_CALLBACK_URL"),
+		}}}
+	}
+
+	var cfg OAuthConfig
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OAuthProviderConfig{
+			Name:         name,
+			Type:         getEnvOrDefault(prefix+"TYPE", name),
+			DisplayName:  os.Getenv(prefix + "DISPLAY_NAME"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			CallbackURL:  os.Getenv(prefix + "CALLBACK_URL"),
+			DiscoveryURL: os.Getenv(prefix + "DISCOVERY_URL"),
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Split(scopes, ",")
+		}
+		cfg.Providers = append(cfg.Providers, provider)
+	}
+
+	return cfg
+}
+
+// getEnvOrDefault returns the value of the environment variable or the fallback if it is unset
+func getEnvOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
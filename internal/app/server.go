@@ -7,8 +7,6 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
-	"github.com/markbates/goth"
-	"github.com/markbates/goth/providers/google"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/jalil32/go-auth-module/config"
@@ -17,11 +15,6 @@ import (
 
 func StartServer(cfg *config.Config, db *sqlx.DB, rdb *redis.Client, logger *slog.Logger) error {
 
-	// Setup OAuth providers
-	goth.UseProviders(
-		google.New(cfg.OAuth.ClientID, cfg.OAuth.ClientSecret, cfg.OAuth.ClientCallbackURL, "email", "profile"),
-	)
-
 	// Set gin to release mode so we get clean logs
 	gin.SetMode(gin.ReleaseMode)
 
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"log/slog"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// Store is the subset of the user database needed to persist auth events
+type Store interface {
+	CreateAuthEvent(event *models.AuthEvent) error
+}
+
+// Logger records security events in the auth_events table and the application log
+type Logger struct {
+	Store  Store
+	Logger *slog.Logger
+}
+
+// NewLogger initializes a Logger that writes to the given store
+func NewLogger(store Store, logger *slog.Logger) *Logger {
+	return &Logger{
+		Store:  store,
+		Logger: logger,
+	}
+}
+
+// Record saves an event. Failures are logged rather than returned so an audit outage never blocks signing in
+func (l *Logger) Record(event models.AuthEvent) {
+	l.Logger.Info("Auth event",
+		"type", event.Type,
+		"outcome", event.Outcome,
+		"userID", derefID(event.UserID),
+		"targetUserID", derefID(event.TargetUserID),
+		"email", event.Email,
+		"ip", event.IP,
+		"detail", event.Detail,
+	)
+
+	if err := l.Store.CreateAuthEvent(&event); err != nil {
+		l.Logger.Error("Failed to record auth event", "type", event.Type, "outcome", event.Outcome, "error", err)
+	}
+}
+
+// derefID logs optional IDs as their value instead of a pointer
+func derefID(id *int) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
@@ -0,0 +1,52 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/audit"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// storeFunc adapts a function to the Store interface.
+type storeFunc func(event *models.AuthEvent) error
+
+func (f storeFunc) CreateAuthEvent(event *models.AuthEvent) error {
+	return f(event)
+}
+
+func TestLogger_Record(t *testing.T) {
+	var logs bytes.Buffer
+	var stored []models.AuthEvent
+	logger := audit.NewLogger(storeFunc(func(event *models.AuthEvent) error {
+		stored = append(stored, *event)
+		return nil
+	}), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	userID := 42
+	logger.Record(models.AuthEvent{UserID: &userID, Email: "test@example.com", Type: models.AuthEventLogin, Outcome: models.AuthEventSuccess, IP: "192.0.2.1"})
+
+	require.Len(t, stored, 1)
+	assert.Equal(t, models.AuthEventLogin, stored[0].Type)
+	assert.Equal(t, 42, *stored[0].UserID)
+	assert.Contains(t, logs.String(), "userID=42")
+	assert.Contains(t, logs.String(), "ip=192.0.2.1")
+}
+
+func TestLogger_RecordStoreFailureIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	logger := audit.NewLogger(storeFunc(func(event *models.AuthEvent) error {
+		return errors.New("database is down")
+	}), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	assert.NotPanics(t, func() {
+		logger.Record(models.AuthEvent{Email: "nobody@example.com", Type: models.AuthEventLogin, Outcome: models.AuthEventFailure})
+	})
+	assert.Contains(t, logs.String(), "Failed to record auth event")
+	assert.Contains(t, logs.String(), "database is down")
+}
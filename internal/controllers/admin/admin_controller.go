@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+type AdminRepository interface {
+	FindUserByID(id int) (*models.User, error)
+	ListUsers(filter models.UserFilter) ([]models.User, int, error)
+	UpdateUser(ext sqlx.Ext, user *models.User) error
+	UpdateUserStatus(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error
+	FindAccountStatusHistory(userID int) ([]models.AccountStatusChange, error)
+	FindAuthEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error)
+	DeleteUser(ext sqlx.Ext, id int) (bool, error)
+	FindRoles() ([]models.Role, error)
+	FindRoleByName(name string) (*models.Role, error)
+	FindRolesByUserID(userID int) ([]string, error)
+	GrantRole(ext sqlx.Ext, userID int, roleID int, grantedBy int) (bool, error)
+	RevokeRole(ext sqlx.Ext, userID int, roleID int) (bool, error)
+	LockUsersWithRole(ext sqlx.Ext, roleID int) (int, error)
+	ListOAuthClients() ([]models.OAuthClient, error)
+	CreateOAuthClient(ext sqlx.Ext, client *models.OAuthClient) error
+	DeleteOAuthClient(ext sqlx.Ext, clientID string) (bool, error)
+	ListAPIKeys() ([]models.APIKey, error)
+	CreateAPIKey(ext sqlx.Ext, key *models.APIKey) error
+	RevokeAPIKey(ext sqlx.Ext, id int) (bool, error)
+	FindOrganizationByID(id int) (*models.Organization, error)
+	FindMembership(organizationID int, userID int) (*models.Membership, error)
+	Beginx() (*sqlx.Tx, error)
+}
+
+// SessionRevoker signs a user out everywhere by rejecting tokens issued before a cut off
+type SessionRevoker interface {
+	RevokeUserTokens(ctx context.Context, userID int, before time.Time) error
+}
+
+// AuditLogger records security events, it must not fail the request when the event cannot be stored
+type AuditLogger interface {
+	Record(event models.AuthEvent)
+}
+
+// PasswordResetter emails a user a reset password link
+type PasswordResetter interface {
+	SendPasswordReset(email string) error
+}
+
+type AdminController struct {
+	Logger         *slog.Logger
+	DB             AdminRepository
+	Sessions       SessionRevoker
+	PasswordResets PasswordResetter
+	Audit          AuditLogger
+}
+
+func NewAdminController(logger *slog.Logger, db AdminRepository, sessions SessionRevoker, passwordResets PasswordResetter, auditLogger AuditLogger) *AdminController {
+	return &AdminController{
+		Logger:         logger,
+		DB:             db,
+		Sessions:       sessions,
+		PasswordResets: passwordResets,
+		Audit:          auditLogger,
+	}
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognise, e.g. by secret scanners
+const apiKeyPrefix = "ak_"
+
+type CreateAPIKeyRequest struct {
+	Name           string     `json:"name" binding:"required,max=100"`
+	Scopes         []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	OrganizationID *int       `json:"organizationId"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+// ListAPIKeysHandler returns every API key, revoked keys included, without the keys themselves.
+func (a *AdminController) ListAPIKeysHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	keys, err := a.DB.ListAPIKeys()
+	if err != nil {
+		a.Logger.Error("Failed to list API keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+
+	a.audit(c, admin, "api_key.list", nil)
+	c.JSON(http.StatusOK, gin.H{"apiKeys": keys})
+}
+
+// CreateAPIKeyHandler creates a key for a service. The key is only shown in this response.
+func (a *AdminController) CreateAPIKeyHandler(c *gin.Context) {
+	// 1) Get the admin and validate the request
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.Logger.Error("Invalid create API key request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and at least one scope are required"})
+		return
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	// 2) Scopes are permissions, admins can only hand out permissions they hold themselves
+	for _, scope := range request.Scopes {
+		if !slices.Contains(admin.Permissions, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot grant scope " + scope})
+			return
+		}
+	}
+
+	// 3) Keys bound to an organization act in it as a member with the admin's data, so the admin must be a member too
+	if request.OrganizationID != nil {
+		organization, err := a.DB.FindOrganizationByID(*request.OrganizationID)
+		if err != nil {
+			a.Logger.Error("Failed to find organization", "organizationID", *request.OrganizationID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+			return
+		}
+
+		if organization == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Organization not found"})
+			return
+		}
+
+		membership, err := a.DB.FindMembership(organization.ID, admin.ID)
+		if err != nil {
+			a.Logger.Error("Failed to find membership", "organizationID", organization.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+			return
+		}
+
+		if membership == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot bind a key to an organization you are not a member of"})
+			return
+		}
+	}
+
+	// 4) Generate the key, only its hash is stored
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		a.Logger.Error("Failed to generate API key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+
+	key := &models.APIKey{
+		Name:           request.Name,
+		Prefix:         secret[:len(apiKeyPrefix)+8],
+		KeyHash:        models.HashAPIKey(secret),
+		Scopes:         pq.StringArray(request.Scopes),
+		OrganizationID: request.OrganizationID,
+		ExpiresAt:      request.ExpiresAt,
+		CreatedBy:      &admin.ID,
+	}
+
+	// 5) Store the key
+	if !a.inTransaction(c, "Failed to create API key", func(tx *sqlx.Tx) error {
+		return a.DB.CreateAPIKey(tx, key)
+	}) {
+		return
+	}
+
+	a.audit(c, admin, "api_key.create "+strconv.Itoa(key.ID), nil)
+	c.JSON(http.StatusCreated, gin.H{"apiKey": key, "key": secret})
+}
+
+// RevokeAPIKeyHandler stops a key from authenticating. The key is kept so its history stays visible.
+func (a *AdminController) RevokeAPIKeyHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := strconv.Atoi(c.Param("keyId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		a.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	defer tx.Rollback()
+
+	revoked, err := a.DB.RevokeAPIKey(tx, keyID)
+	if err != nil {
+		a.Logger.Error("Failed to revoke API key", "apiKeyID", keyID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	a.audit(c, admin, "api_key.revoke "+strconv.Itoa(keyID), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
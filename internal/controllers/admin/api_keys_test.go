@@ -0,0 +1,151 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAdminController_APIKeys(t *testing.T) {
+	adminUser := &models.User{ID: 1, Email: "admin@example.com", Permissions: []string{"api_keys:manage", "bank:upload"}}
+
+	keys := map[int]*models.APIKey{}
+	repo := &MockAdminRepository{
+		ListAPIKeysFunc: func() ([]models.APIKey, error) {
+			list := []models.APIKey{}
+			for _, key := range keys {
+				list = append(list, *key)
+			}
+			return list, nil
+		},
+		CreateAPIKeyFunc: func(ext sqlx.Ext, key *models.APIKey) error {
+			key.ID = len(keys) + 1
+			keys[key.ID] = key
+			return nil
+		},
+		RevokeAPIKeyFunc: func(ext sqlx.Ext, id int) (bool, error) {
+			key, exists := keys[id]
+			if !exists || key.RevokedAt != nil {
+				return false, nil
+			}
+			now := time.Now()
+			key.RevokedAt = &now
+			return true, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, nil, auditLogger)
+
+	// 1) Scopes are required, must be held by the admin, and expiry must be in the future
+	w := executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":[]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["users:write"]}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["bank:upload"],"expiresAt":"2020-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, keys)
+
+	// 2) The key is shown once and only its hash is stored
+	w = executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["bank:upload"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		APIKey models.APIKey `json:"apiKey"`
+		Key    string        `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.True(t, strings.HasPrefix(created.Key, "ak_"))
+
+	stored := keys[created.APIKey.ID]
+	require.NotNil(t, stored)
+	assert.Equal(t, models.HashAPIKey(created.Key), stored.KeyHash)
+	assert.True(t, strings.HasPrefix(created.Key, stored.Prefix))
+	assert.Equal(t, []string{"bank:upload"}, []string(stored.Scopes))
+	assert.Equal(t, adminUser.ID, *stored.CreatedBy)
+
+	// 3) Listing never shows keys or hashes
+	w = executeAdminHandler(controller.ListAPIKeysHandler, adminUser, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Import"`)
+	assert.NotContains(t, w.Body.String(), stored.KeyHash)
+	assert.NotContains(t, w.Body.String(), created.Key)
+
+	// 4) Revoking a key, it cannot be revoked twice
+	keyParam := gin.Params{{Key: "keyId", Value: "1"}}
+	w = executeAdminHandler(controller.RevokeAPIKeyHandler, adminUser, keyParam, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, stored.Usable(time.Now()))
+
+	w = executeAdminHandler(controller.RevokeAPIKeyHandler, adminUser, keyParam, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = executeAdminHandler(controller.RevokeAPIKeyHandler, adminUser, gin.Params{{Key: "keyId", Value: "abc"}}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 5) Both changes and the listing are audited
+	require.Len(t, auditLogger.Events, 3)
+	assert.Equal(t, "api_key.create 1", auditLogger.Events[0].Detail)
+	assert.Equal(t, "api_key.list", auditLogger.Events[1].Detail)
+	assert.Equal(t, "api_key.revoke 1", auditLogger.Events[2].Detail)
+}
+
+func TestAdminController_APIKeyOrganization(t *testing.T) {
+	adminUser := &models.User{ID: 1, Email: "admin@example.com", Permissions: []string{"api_keys:manage", "bank:upload"}}
+
+	var stored *models.APIKey
+	repo := &MockAdminRepository{
+		FindOrganizationByIDFunc: func(id int) (*models.Organization, error) {
+			if id != 7 && id != 9 {
+				return nil, nil
+			}
+			return &models.Organization{ID: id, Name: "Smith household"}, nil
+		},
+		FindMembershipFunc: func(organizationID int, userID int) (*models.Membership, error) {
+			if organizationID != 7 {
+				return nil, nil
+			}
+			return &models.Membership{OrganizationID: organizationID, UserID: userID, Role: models.OrganizationRoleMember}, nil
+		},
+		CreateAPIKeyFunc: func(ext sqlx.Ext, key *models.APIKey) error {
+			key.ID = 1
+			stored = key
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, nil, &MockAuditLogger{})
+
+	// 1) The organization must exist
+	w := executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["bank:upload"],"organizationId":8}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, stored)
+
+	// 2) The admin must be a member, the key works with their data
+	w = executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["bank:upload"],"organizationId":9}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Nil(t, stored)
+
+	// 3) The key is bound to the organization
+	w = executeAdminHandler(controller.CreateAPIKeyHandler, adminUser, nil, `{"name":"Import","scopes":["bank:upload"],"organizationId":7}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, stored)
+	require.NotNil(t, stored.OrganizationID)
+	assert.Equal(t, 7, *stored.OrganizationID)
+}
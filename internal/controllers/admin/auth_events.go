@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// ListAuthEventsHandler returns a page of the audit log, newest first, filtered by the userId, email, type, outcome,
+// ip, createdAfter and createdBefore query parameters.
+func (a *AdminController) ListAuthEventsHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1) Parse the filters and pagination
+	filter, page, pageSize, err := parseAuthEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2) Fetch the page
+	events, total, err := a.DB.FindAuthEvents(filter)
+	if err != nil {
+		a.Logger.Error("Failed to find auth events", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find auth events"})
+		return
+	}
+
+	a.audit(c, admin, viewAction(c, "audit_log.list"), nil)
+	c.JSON(http.StatusOK, gin.H{
+		"events":   events,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// parseAuthEventFilter reads the audit log filters and pagination from the query string
+func parseAuthEventFilter(c *gin.Context) (models.AuthEventFilter, int, int, error) {
+	var filter models.AuthEventFilter
+
+	if value := c.Query("userId"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, 0, 0, &filterError{"Invalid userId filter"}
+		}
+		filter.UserID = &userID
+	}
+
+	if value := c.Query("type"); value != "" {
+		eventType := models.AuthEventType(value)
+		filter.Type = &eventType
+	}
+
+	if value := c.Query("outcome"); value != "" {
+		outcome := models.AuthEventOutcome(value)
+		if outcome != models.AuthEventSuccess && outcome != models.AuthEventFailure {
+			return filter, 0, 0, &filterError{"Invalid outcome filter, use success or failure"}
+		}
+		filter.Outcome = &outcome
+	}
+
+	filter.Email = strings.TrimSpace(c.Query("email"))
+	filter.IP = strings.TrimSpace(c.Query("ip"))
+
+	if err := parseCreatedRange(c, &filter.CreatedAfter, &filter.CreatedBefore); err != nil {
+		return filter, 0, 0, err
+	}
+
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return filter, 0, 0, err
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+	return filter, page, pageSize, nil
+}
@@ -0,0 +1,67 @@
+package admin_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAdminController_ListAuthEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received models.AuthEventFilter
+	userID := 2
+	repo := &MockAdminRepository{
+		FindAuthEventsFunc: func(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+			received = filter
+			return []models.AuthEvent{{ID: 1, UserID: &userID, Type: models.AuthEventLogin, Outcome: models.AuthEventFailure}}, 1, nil
+		},
+	}
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, nil, auditLogger)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/auth-events?"+query, nil)
+		c.Set("user", &models.User{ID: 1, Email: "admin@example.com"})
+		controller.ListAuthEventsHandler(c)
+		return w
+	}
+
+	// 1) Filters and pagination are passed to the repository
+	w := list("userId=2&type=login&outcome=failure&ip=192.0.2.1&createdBefore=2026-10-17&page=2&pageSize=50")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	require.NotNil(t, received.UserID)
+	assert.Equal(t, 2, *received.UserID)
+	require.NotNil(t, received.Type)
+	assert.Equal(t, models.AuthEventLogin, *received.Type)
+	require.NotNil(t, received.Outcome)
+	assert.Equal(t, models.AuthEventFailure, *received.Outcome)
+	assert.Equal(t, "192.0.2.1", received.IP)
+	assert.NotNil(t, received.CreatedBefore)
+	assert.Equal(t, 50, received.Limit)
+	assert.Equal(t, 50, received.Offset)
+
+	// 2) Bad filters are rejected
+	for _, query := range []string{"userId=me", "outcome=maybe", "createdAfter=today", "pageSize=0"} {
+		w = list(query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// 3) Reading the audit log is itself audited
+	require.Len(t, auditLogger.Events, 1)
+	assert.Equal(t, models.AuthEventAdminAction, auditLogger.Events[0].Type)
+	assert.Equal(t, "audit_log.list ?userId=2&type=login&outcome=failure&ip=192.0.2.1&createdBefore=2026-10-17&page=2&pageSize=50", auditLogger.Events[0].Detail)
+}
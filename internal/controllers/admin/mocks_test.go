@@ -0,0 +1,151 @@
+package admin_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// MockAdminRepository is a mock implementation of the AdminRepository interface.
+type MockAdminRepository struct {
+	FindUserByIDFunc             func(id int) (*models.User, error)
+	ListUsersFunc                func(filter models.UserFilter) ([]models.User, int, error)
+	UpdateUserFunc               func(ext sqlx.Ext, user *models.User) error
+	DeleteUserFunc               func(ext sqlx.Ext, id int) (bool, error)
+	UpdateUserStatusFunc         func(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error
+	FindAccountStatusHistoryFunc func(userID int) ([]models.AccountStatusChange, error)
+	FindAuthEventsFunc           func(filter models.AuthEventFilter) ([]models.AuthEvent, int, error)
+	FindRolesFunc                func() ([]models.Role, error)
+	FindRoleByNameFunc           func(name string) (*models.Role, error)
+	FindRolesByUserIDFunc        func(userID int) ([]string, error)
+	GrantRoleFunc                func(ext sqlx.Ext, userID int, roleID int, grantedBy int) (bool, error)
+	RevokeRoleFunc               func(ext sqlx.Ext, userID int, roleID int) (bool, error)
+	LockUsersWithRoleFunc        func(ext sqlx.Ext, roleID int) (int, error)
+	ListOAuthClientsFunc         func() ([]models.OAuthClient, error)
+	CreateOAuthClientFunc        func(ext sqlx.Ext, client *models.OAuthClient) error
+	DeleteOAuthClientFunc        func(ext sqlx.Ext, clientID string) (bool, error)
+	ListAPIKeysFunc              func() ([]models.APIKey, error)
+	CreateAPIKeyFunc             func(ext sqlx.Ext, key *models.APIKey) error
+	RevokeAPIKeyFunc             func(ext sqlx.Ext, id int) (bool, error)
+	FindOrganizationByIDFunc     func(id int) (*models.Organization, error)
+	FindMembershipFunc           func(organizationID int, userID int) (*models.Membership, error)
+	BeginxFunc                   func() (*sqlx.Tx, error)
+}
+
+func (m *MockAdminRepository) FindUserByID(id int) (*models.User, error) {
+	return m.FindUserByIDFunc(id)
+}
+
+func (m *MockAdminRepository) ListUsers(filter models.UserFilter) ([]models.User, int, error) {
+	return m.ListUsersFunc(filter)
+}
+
+func (m *MockAdminRepository) UpdateUser(ext sqlx.Ext, user *models.User) error {
+	return m.UpdateUserFunc(ext, user)
+}
+
+func (m *MockAdminRepository) DeleteUser(ext sqlx.Ext, id int) (bool, error) {
+	return m.DeleteUserFunc(ext, id)
+}
+
+func (m *MockAdminRepository) UpdateUserStatus(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error {
+	return m.UpdateUserStatusFunc(ext, user, status, reason, changedBy)
+}
+
+func (m *MockAdminRepository) FindAccountStatusHistory(userID int) ([]models.AccountStatusChange, error) {
+	return m.FindAccountStatusHistoryFunc(userID)
+}
+
+func (m *MockAdminRepository) FindAuthEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+	return m.FindAuthEventsFunc(filter)
+}
+
+func (m *MockAdminRepository) FindRoles() ([]models.Role, error) {
+	return m.FindRolesFunc()
+}
+
+func (m *MockAdminRepository) FindRoleByName(name string) (*models.Role, error) {
+	return m.FindRoleByNameFunc(name)
+}
+
+func (m *MockAdminRepository) FindRolesByUserID(userID int) ([]string, error) {
+	return m.FindRolesByUserIDFunc(userID)
+}
+
+func (m *MockAdminRepository) GrantRole(ext sqlx.Ext, userID int, roleID int, grantedBy int) (bool, error) {
+	return m.GrantRoleFunc(ext, userID, roleID, grantedBy)
+}
+
+func (m *MockAdminRepository) RevokeRole(ext sqlx.Ext, userID int, roleID int) (bool, error) {
+	return m.RevokeRoleFunc(ext, userID, roleID)
+}
+
+func (m *MockAdminRepository) LockUsersWithRole(ext sqlx.Ext, roleID int) (int, error) {
+	return m.LockUsersWithRoleFunc(ext, roleID)
+}
+
+func (m *MockAdminRepository) ListOAuthClients() ([]models.OAuthClient, error) {
+	return m.ListOAuthClientsFunc()
+}
+
+func (m *MockAdminRepository) CreateOAuthClient(ext sqlx.Ext, client *models.OAuthClient) error {
+	return m.CreateOAuthClientFunc(ext, client)
+}
+
+func (m *MockAdminRepository) DeleteOAuthClient(ext sqlx.Ext, clientID string) (bool, error) {
+	return m.DeleteOAuthClientFunc(ext, clientID)
+}
+
+func (m *MockAdminRepository) ListAPIKeys() ([]models.APIKey, error) {
+	return m.ListAPIKeysFunc()
+}
+
+func (m *MockAdminRepository) CreateAPIKey(ext sqlx.Ext, key *models.APIKey) error {
+	return m.CreateAPIKeyFunc(ext, key)
+}
+
+func (m *MockAdminRepository) RevokeAPIKey(ext sqlx.Ext, id int) (bool, error) {
+	return m.RevokeAPIKeyFunc(ext, id)
+}
+
+func (m *MockAdminRepository) FindOrganizationByID(id int) (*models.Organization, error) {
+	return m.FindOrganizationByIDFunc(id)
+}
+
+func (m *MockAdminRepository) FindMembership(organizationID int, userID int) (*models.Membership, error) {
+	return m.FindMembershipFunc(organizationID, userID)
+}
+
+func (m *MockAdminRepository) Beginx() (*sqlx.Tx, error) {
+	return m.BeginxFunc()
+}
+
+// MockSessionRevoker is a mock implementation of the SessionRevoker interface.
+type MockSessionRevoker struct {
+	RevokeUserTokensFunc func(ctx context.Context, userID int, before time.Time) error
+}
+
+func (m *MockSessionRevoker) RevokeUserTokens(ctx context.Context, userID int, before time.Time) error {
+	return m.RevokeUserTokensFunc(ctx, userID, before)
+}
+
+// MockPasswordResetter is a mock implementation of the PasswordResetter interface.
+type MockPasswordResetter struct {
+	SendPasswordResetFunc func(email string) error
+}
+
+func (m *MockPasswordResetter) SendPasswordReset(email string) error {
+	return m.SendPasswordResetFunc(email)
+}
+
+// MockAuditLogger is a mock implementation of the AuditLogger interface that keeps every event.
+type MockAuditLogger struct {
+	Events []models.AuthEvent
+}
+
+func (m *MockAuditLogger) Record(event models.AuthEvent) {
+	m.Events = append(m.Events, event)
+}
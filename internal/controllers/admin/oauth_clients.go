@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,dive,required"`
+	Public       bool     `json:"public"`
+}
+
+// ListOAuthClientsHandler returns every application registered with the OpenID Connect provider.
+func (a *AdminController) ListOAuthClientsHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	clients, err := a.DB.ListOAuthClients()
+	if err != nil {
+		a.Logger.Error("Failed to list OAuth clients", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list clients"})
+		return
+	}
+
+	a.audit(c, admin, "client.list", nil)
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// CreateOAuthClientHandler registers an application. Confidential clients get a secret, it is only shown in this response.
+func (a *AdminController) CreateOAuthClientHandler(c *gin.Context) {
+	// 1) Get the admin and validate the request
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.Logger.Error("Invalid create client request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and at least one redirect URI are required"})
+		return
+	}
+
+	for _, redirectURI := range request.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redirect URI " + redirectURI})
+			return
+		}
+	}
+
+	// 2) Generate the credentials, only the hash of the secret is stored
+	client := &models.OAuthClient{
+		ClientID:     uuid.New().String(),
+		Name:         request.Name,
+		RedirectURIs: pq.StringArray(request.RedirectURIs),
+		CreatedBy:    &admin.ID,
+	}
+
+	var secret string
+	if !request.Public {
+		bytes := make([]byte, 32)
+		if _, err := rand.Read(bytes); err != nil {
+			a.Logger.Error("Failed to generate client secret", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create client"})
+			return
+		}
+		secret = base64.RawURLEncoding.EncodeToString(bytes)
+
+		hash := models.HashClientSecret(secret)
+		client.ClientSecretHash = &hash
+	}
+
+	// 3) Store the client
+	if !a.inTransaction(c, "Failed to create client", func(tx *sqlx.Tx) error {
+		return a.DB.CreateOAuthClient(tx, client)
+	}) {
+		return
+	}
+
+	a.audit(c, admin, "client.create "+client.ClientID, nil)
+
+	response := gin.H{"client": client}
+	if secret != "" {
+		response["clientSecret"] = secret
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteOAuthClientHandler removes an application. Codes and access tokens already issued to it stop working.
+func (a *AdminController) DeleteOAuthClientHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	clientID := c.Param("clientId")
+
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		a.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete client"})
+		return
+	}
+	defer tx.Rollback()
+
+	deleted, err := a.DB.DeleteOAuthClient(tx, clientID)
+	if err != nil {
+		a.Logger.Error("Failed to delete OAuth client", "clientID", clientID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete client"})
+		return
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Client not found"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete client"})
+		return
+	}
+
+	a.audit(c, admin, "client.delete "+clientID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Client deleted"})
+}
+
+// validRedirectURI accepts absolute URIs without a fragment, plain http is only allowed for local development
+func validRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAdminController_OAuthClients(t *testing.T) {
+	adminUser := &models.User{ID: 1, Email: "admin@example.com"}
+
+	clients := map[string]*models.OAuthClient{}
+	repo := &MockAdminRepository{
+		ListOAuthClientsFunc: func() ([]models.OAuthClient, error) {
+			list := []models.OAuthClient{}
+			for _, client := range clients {
+				list = append(list, *client)
+			}
+			return list, nil
+		},
+		CreateOAuthClientFunc: func(ext sqlx.Ext, client *models.OAuthClient) error {
+			client.ID = len(clients) + 1
+			clients[client.ClientID] = client
+			return nil
+		},
+		DeleteOAuthClientFunc: func(ext sqlx.Ext, clientID string) (bool, error) {
+			_, exists := clients[clientID]
+			delete(clients, clientID)
+			return exists, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, nil, auditLogger)
+
+	// 1) Redirect URIs must be absolute, and plain http is only allowed for localhost
+	w := executeAdminHandler(controller.CreateOAuthClientHandler, adminUser, nil, `{"name":"Budgeting","redirectUris":[]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeAdminHandler(controller.CreateOAuthClientHandler, adminUser, nil, `{"name":"Budgeting","redirectUris":["http://budget.example.com/callback"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeAdminHandler(controller.CreateOAuthClientHandler, adminUser, nil, `{"name":"Budgeting","redirectUris":["/callback"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 2) Confidential clients get a secret once, only its hash is stored
+	w = executeAdminHandler(controller.CreateOAuthClientHandler, adminUser, nil, `{"name":"Budgeting","redirectUris":["https://budget.example.com/callback"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		Client       models.OAuthClient `json:"client"`
+		ClientSecret string             `json:"clientSecret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ClientSecret)
+
+	stored := clients[created.Client.ClientID]
+	require.NotNil(t, stored)
+	assert.False(t, stored.Public())
+	assert.True(t, stored.VerifySecret(created.ClientSecret))
+	assert.NotEqual(t, created.ClientSecret, *stored.ClientSecretHash)
+	assert.Equal(t, adminUser.ID, *stored.CreatedBy)
+
+	// 3) Public clients have no secret
+	w = executeAdminHandler(controller.CreateOAuthClientHandler, adminUser, nil, `{"name":"Mobile","redirectUris":["http://localhost:5173/callback"],"public":true}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "clientSecret")
+
+	// 4) Listing never shows secrets
+	w = executeAdminHandler(controller.ListOAuthClientsHandler, adminUser, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Budgeting")
+	assert.NotContains(t, w.Body.String(), *stored.ClientSecretHash)
+
+	// 5) Deleting a client
+	clientParam := gin.Params{{Key: "clientId", Value: created.Client.ClientID}}
+	w = executeAdminHandler(controller.DeleteOAuthClientHandler, adminUser, clientParam, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = executeAdminHandler(controller.DeleteOAuthClientHandler, adminUser, clientParam, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 6) Changes and the listing are audited without a target user
+	var details []string
+	for _, event := range auditLogger.Events {
+		assert.Nil(t, event.TargetUserID)
+		details = append(details, event.Detail)
+	}
+	assert.Len(t, details, 4)
+	assert.Contains(t, details, "client.create "+created.Client.ClientID)
+	assert.Contains(t, details, "client.list")
+	assert.Contains(t, details, "client.delete "+created.Client.ClientID)
+}
@@ -0,0 +1,236 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// adminRole is the role that cannot lose its last holder, otherwise nobody could grant it again
+const adminRole = "admin"
+
+type GrantRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListRolesHandler returns every role with its permissions.
+func (a *AdminController) ListRolesHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	roles, err := a.DB.FindRoles()
+	if err != nil {
+		a.Logger.Error("Failed to find roles", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find roles"})
+		return
+	}
+
+	a.audit(c, admin, "role.list", nil)
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// ListUserRolesHandler returns the roles granted to a user.
+func (a *AdminController) ListUserRolesHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	roles, err := a.DB.FindRolesByUserID(user.ID)
+	if err != nil {
+		a.Logger.Error("Failed to find user roles", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user roles"})
+		return
+	}
+
+	a.audit(c, admin, "role.list_user", user)
+	c.JSON(http.StatusOK, gin.H{"userId": user.ID, "roles": roles})
+}
+
+// GrantRoleHandler gives a user a role. The user's next access token carries it.
+func (a *AdminController) GrantRoleHandler(c *gin.Context) {
+	// 1) Get the admin, the user and the role
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request GrantRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.Logger.Error("Invalid grant role request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role is required"})
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	role, ok := a.findRole(c, request.Role)
+	if !ok {
+		return
+	}
+
+	// 2) Grant the role
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		a.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant role"})
+		return
+	}
+	defer tx.Rollback()
+
+	granted, err := a.DB.GrantRole(tx, user.ID, role.ID, admin.ID)
+	if err != nil {
+		a.Logger.Error("Failed to grant role", "userID", user.ID, "role", role.Name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant role"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant role"})
+		return
+	}
+
+	if !granted {
+		c.JSON(http.StatusOK, gin.H{"message": "User already has this role"})
+		return
+	}
+
+	a.audit(c, admin, "role.grant "+role.Name, user)
+	c.JSON(http.StatusCreated, gin.H{"message": "Role granted"})
+}
+
+// RevokeRoleHandler removes a role from a user. Their tokens carry the roles they were issued with, so the user is
+// signed out everywhere and their next access token no longer carries it.
+func (a *AdminController) RevokeRoleHandler(c *gin.Context) {
+	// 1) Get the admin, the user and the role
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	role, ok := a.findRole(c, c.Param("role"))
+	if !ok {
+		return
+	}
+
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		a.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke role"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 2) Never remove the last admin. The admins' rows stay locked until the transaction ends, so two admins revoking
+	// each other at the same time cannot both pass this check.
+	if role.Name == adminRole {
+		admins, err := a.DB.LockUsersWithRole(tx, role.ID)
+		if err != nil {
+			a.Logger.Error("Failed to lock admins", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke role"})
+			return
+		}
+
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot revoke the role from the last admin"})
+			return
+		}
+	}
+
+	// 3) Revoke the role
+	revoked, err := a.DB.RevokeRole(tx, user.ID, role.ID)
+	if err != nil {
+		a.Logger.Error("Failed to revoke role", "userID", user.ID, "role", role.Name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke role"})
+		return
+	}
+
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User does not have this role"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke role"})
+		return
+	}
+
+	// 4) Sign the user out so tokens issued with the role stop working
+	if !a.revokeSessions(c, user.ID, "Failed to revoke role") {
+		return
+	}
+
+	a.audit(c, admin, "role.revoke "+role.Name, user)
+	c.JSON(http.StatusOK, gin.H{"message": "Role revoked"})
+}
+
+// findUserParam loads the user named by the :id path parameter and responds with an error if it is missing
+func (a *AdminController) findUserParam(c *gin.Context) (*models.User, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	user, err := a.DB.FindUserByID(userID)
+	if err != nil {
+		a.Logger.Error("Failed to find user", "userID", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+		return nil, false
+	}
+
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// findRole loads a role by name and responds with an error if it does not exist
+func (a *AdminController) findRole(c *gin.Context, name string) (*models.Role, bool) {
+	role, err := a.DB.FindRoleByName(name)
+	if err != nil {
+		a.Logger.Error("Failed to find role", "role", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find role"})
+		return nil, false
+	}
+
+	if role == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return nil, false
+	}
+
+	return role, true
+}
+
+// currentUser returns the admin set by the auth middleware
+func currentUser(c *gin.Context) (*models.User, bool) {
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+	return user, true
+}
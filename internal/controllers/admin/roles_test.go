@@ -0,0 +1,169 @@
+package admin_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// newSQLMockBeginx returns a Beginx func that starts every transaction on a fresh sqlmock connection,
+// so a transaction that is rolled back instead of committed does not affect the next one.
+func newSQLMockBeginx(t *testing.T) func() (*sqlx.Tx, error) {
+	return func() (*sqlx.Tx, error) {
+		mockSQL, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { mockSQL.Close() })
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		return sqlx.NewDb(mockSQL, "sqlmock").Beginx()
+	}
+}
+
+// executeAdminHandler runs a handler as the given admin with the path parameters set.
+func executeAdminHandler(handler gin.HandlerFunc, adminUser *models.User, params gin.Params, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("user", adminUser)
+
+	handler(c)
+	return w
+}
+
+func TestAdminController_GrantAndRevokeRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	adminUser := &models.User{ID: 1, Email: "admin@example.com"}
+	users := map[int]*models.User{1: adminUser, 2: {ID: 2, Email: "user@example.com"}}
+	roles := map[string]*models.Role{"admin": {ID: 1, Name: "admin"}, "member": {ID: 2, Name: "member"}}
+	userRoles := map[int]map[int]bool{1: {1: true}, 2: {2: true}}
+	var calls []string
+	var signedOut []int
+
+	repo := &MockAdminRepository{
+		FindUserByIDFunc:   func(id int) (*models.User, error) { return users[id], nil },
+		FindRoleByNameFunc: func(name string) (*models.Role, error) { return roles[name], nil },
+		FindRolesFunc: func() ([]models.Role, error) {
+			return []models.Role{*roles["admin"], *roles["member"]}, nil
+		},
+		FindRolesByUserIDFunc: func(userID int) ([]string, error) {
+			var names []string
+			for _, role := range roles {
+				if userRoles[userID][role.ID] {
+					names = append(names, role.Name)
+				}
+			}
+			return names, nil
+		},
+		GrantRoleFunc: func(ext sqlx.Ext, userID int, roleID int, grantedBy int) (bool, error) {
+			if userRoles[userID][roleID] {
+				return false, nil
+			}
+			userRoles[userID][roleID] = true
+			return true, nil
+		},
+		RevokeRoleFunc: func(ext sqlx.Ext, userID int, roleID int) (bool, error) {
+			calls = append(calls, "revoke")
+			had := userRoles[userID][roleID]
+			delete(userRoles[userID], roleID)
+			return had, nil
+		},
+		LockUsersWithRoleFunc: func(ext sqlx.Ext, roleID int) (int, error) {
+			// The admins' rows are locked in the transaction that revokes the role
+			_, inTransaction := ext.(*sqlx.Tx)
+			assert.True(t, inTransaction)
+			calls = append(calls, "lock")
+			count := 0
+			for _, held := range userRoles {
+				if held[roleID] {
+					count++
+				}
+			}
+			return count, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+	sessions := &MockSessionRevoker{
+		RevokeUserTokensFunc: func(ctx context.Context, userID int, before time.Time) error {
+			signedOut = append(signedOut, userID)
+			return nil
+		},
+	}
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, sessions, nil, auditLogger)
+
+	userParam := func(id string, role string) gin.Params {
+		return gin.Params{{Key: "id", Value: id}, {Key: "role", Value: role}}
+	}
+
+	// 1) The last admin cannot lose the admin role
+	w := executeAdminHandler(controller.RevokeRoleHandler, adminUser, userParam("1", "admin"), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.True(t, userRoles[1][1])
+	assert.Equal(t, []string{"lock"}, calls)
+	assert.Empty(t, signedOut)
+
+	// 2) Unknown users and roles are rejected
+	w = executeAdminHandler(controller.GrantRoleHandler, adminUser, userParam("99", ""), `{"role":"admin"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = executeAdminHandler(controller.GrantRoleHandler, adminUser, userParam("2", ""), `{"role":"owner"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = executeAdminHandler(controller.GrantRoleHandler, adminUser, userParam("2", ""), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 3) Granting a role, twice
+	w = executeAdminHandler(controller.GrantRoleHandler, adminUser, userParam("2", ""), `{"role":"admin"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, userRoles[2][1])
+
+	w = executeAdminHandler(controller.GrantRoleHandler, adminUser, userParam("2", ""), `{"role":"admin"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 4) With a second admin the first can be demoted, the admins are locked before the role is revoked and the
+	// demoted admin is signed out so tokens carrying the role stop working
+	calls = nil
+	w = executeAdminHandler(controller.RevokeRoleHandler, adminUser, userParam("1", "admin"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, userRoles[1][1])
+	assert.Equal(t, []string{"lock", "revoke"}, calls)
+	assert.Equal(t, []int{1}, signedOut)
+
+	// 5) Revoking a role the user does not have
+	w = executeAdminHandler(controller.RevokeRoleHandler, adminUser, userParam("1", "member"), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []int{1}, signedOut)
+
+	// 6) Listing roles is audited along with the changes
+	w = executeAdminHandler(controller.ListRolesHandler, adminUser, nil, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = executeAdminHandler(controller.ListUserRolesHandler, adminUser, userParam("2", ""), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"admin"`)
+
+	var details []string
+	for _, event := range auditLogger.Events {
+		details = append(details, event.Detail)
+	}
+	assert.Equal(t, []string{"role.grant admin", "role.revoke admin", "role.list", "role.list_user"}, details)
+	assert.Equal(t, 2, *auditLogger.Events[3].TargetUserID)
+}
@@ -0,0 +1,492 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListUsersHandler returns a page of users, filtered by the verified, status, provider, createdAfter, createdBefore and search query parameters.
+func (a *AdminController) ListUsersHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1) Parse the filters and pagination
+	filter, page, pageSize, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2) Fetch the page
+	users, total, err := a.DB.ListUsers(filter)
+	if err != nil {
+		a.Logger.Error("Failed to list users", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	a.audit(c, admin, viewAction(c, "user.list"), nil)
+	c.JSON(http.StatusOK, gin.H{
+		"users":    users,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetUserHandler returns a single user with their roles.
+func (a *AdminController) GetUserHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	roles, err := a.DB.FindRolesByUserID(user.ID)
+	if err != nil {
+		a.Logger.Error("Failed to find user roles", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+		return
+	}
+	user.Roles = roles
+
+	a.audit(c, admin, "user.view", user)
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// SuspendUserHandler suspends a user's account and signs them out everywhere. A reason is required.
+func (a *AdminController) SuspendUserHandler(c *gin.Context) {
+	// 1) Get the admin, the user and the reason
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	reason, ok := bindStatusReason(c, "")
+	if !ok {
+		return
+	}
+
+	// 2) Admins cannot lock themselves out
+	if user.ID == admin.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot suspend your own account"})
+		return
+	}
+
+	// 3) Suspend the user and cut off every session they already have
+	if !a.changeStatus(c, admin, user, models.AccountStatusSuspended, reason) {
+		return
+	}
+
+	if !a.revokeSessions(c, user.ID, "User suspended but their sessions could not be revoked") {
+		return
+	}
+
+	a.audit(c, admin, "user.suspend", user)
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended", "status": user.Status})
+}
+
+// ReactivateUserHandler lifts a suspension. Users who never verified their email go back to pending verification.
+func (a *AdminController) ReactivateUserHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	reason, ok := bindStatusReason(c, "Reactivated by an admin")
+	if !ok {
+		return
+	}
+
+	status := models.AccountStatusActive
+	if !user.Verified {
+		status = models.AccountStatusPendingVerification
+	}
+
+	if !a.changeStatus(c, admin, user, status, reason) {
+		return
+	}
+
+	a.audit(c, admin, "user.reactivate", user)
+	c.JSON(http.StatusOK, gin.H{"message": "User reactivated", "status": user.Status})
+}
+
+// VerifyUserHandler marks a user's email as verified without an OTP and activates the account if it was pending verification.
+func (a *AdminController) VerifyUserHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	if user.Verified {
+		c.JSON(http.StatusOK, gin.H{"message": "User is already verified"})
+		return
+	}
+
+	user.Verified = true
+	verified := a.inTransaction(c, "Failed to verify user", func(tx *sqlx.Tx) error {
+		if err := a.DB.UpdateUser(tx, user); err != nil {
+			return err
+		}
+		if user.Status != models.AccountStatusPendingVerification {
+			return nil
+		}
+		return a.DB.UpdateUserStatus(tx, user, models.AccountStatusActive, "Email verified by an admin", &admin.ID)
+	})
+	if !verified {
+		return
+	}
+
+	a.audit(c, admin, "user.verify", user)
+	c.JSON(http.StatusOK, gin.H{"message": "User verified", "status": user.Status})
+}
+
+// StatusHistoryHandler returns every status change of a user, newest first.
+func (a *AdminController) StatusHistoryHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	history, err := a.DB.FindAccountStatusHistory(user.ID)
+	if err != nil {
+		a.Logger.Error("Failed to find status history", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find status history"})
+		return
+	}
+
+	a.audit(c, admin, "user.status_history", user)
+	c.JSON(http.StatusOK, gin.H{"userId": user.ID, "status": user.Status, "history": history})
+}
+
+// ResetUserPasswordHandler emails a user a reset password link.
+func (a *AdminController) ResetUserPasswordHandler(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	// Users who sign in with a provider have no password to reset
+	if user.PasswordHash == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User signs in with a provider"})
+		return
+	}
+
+	if err := a.PasswordResets.SendPasswordReset(user.Email); err != nil {
+		a.Logger.Error("Failed to send password reset", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset"})
+		return
+	}
+
+	a.audit(c, admin, "user.password_reset", user)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset email sent"})
+}
+
+// DeleteUserHandler closes a user's account and signs them out everywhere. With ?permanent=true the user
+// and everything that belongs to them is removed from the database instead.
+func (a *AdminController) DeleteUserHandler(c *gin.Context) {
+	// 1) Get the admin and the user
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := a.findUserParam(c)
+	if !ok {
+		return
+	}
+
+	if user.ID == admin.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+
+	permanent, _ := strconv.ParseBool(c.Query("permanent"))
+
+	// 2) Mark the account deleted, or delete the user. Their roles, credentials and transactions cascade
+	if permanent {
+		var deleted bool
+		removed := a.inTransaction(c, "Failed to delete user", func(tx *sqlx.Tx) error {
+			var err error
+			deleted, err = a.DB.DeleteUser(tx, user.ID)
+			return err
+		})
+		if !removed {
+			return
+		}
+
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+	} else {
+		reason, ok := bindStatusReason(c, "Deleted by an admin")
+		if !ok {
+			return
+		}
+
+		if !a.changeStatus(c, admin, user, models.AccountStatusDeleted, reason) {
+			return
+		}
+	}
+
+	// 3) Tokens that were already issued must stop working too
+	if !a.revokeSessions(c, user.ID, "User deleted but their sessions could not be revoked") {
+		return
+	}
+
+	if permanent {
+		a.audit(c, admin, "user.purge", user)
+	} else {
+		a.audit(c, admin, "user.delete", user)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// StatusChangeRequest carries the reason recorded with a status change
+type StatusChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// maxStatusReasonLength keeps reasons to a short note
+const maxStatusReasonLength = 500
+
+// bindStatusReason reads the optional reason from the request body, falling back to the given default.
+// An empty fallback makes the reason required.
+func bindStatusReason(c *gin.Context, fallback string) (string, bool) {
+	var request StatusChangeRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return "", false
+	}
+
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		reason = fallback
+	}
+
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason is required"})
+		return "", false
+	}
+
+	if len(reason) > maxStatusReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason must be at most " + strconv.Itoa(maxStatusReasonLength) + " characters"})
+		return "", false
+	}
+
+	return reason, true
+}
+
+// parseUserFilter reads the listing filters and pagination from the query string
+func parseUserFilter(c *gin.Context) (models.UserFilter, int, int, error) {
+	var filter models.UserFilter
+
+	if value := c.Query("verified"); value != "" {
+		verified, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, 0, 0, &filterError{"Invalid verified filter"}
+		}
+		filter.Verified = &verified
+	}
+
+	if value := c.Query("status"); value != "" {
+		status := models.AccountStatus(value)
+		if !status.Valid() {
+			return filter, 0, 0, &filterError{"Invalid status filter"}
+		}
+		filter.Status = &status
+	}
+
+	if provider := c.Query("provider"); provider != "" {
+		filter.Provider = &provider
+	}
+
+	if err := parseCreatedRange(c, &filter.CreatedAfter, &filter.CreatedBefore); err != nil {
+		return filter, 0, 0, err
+	}
+
+	filter.Search = strings.TrimSpace(c.Query("search"))
+
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		return filter, 0, 0, err
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+	return filter, page, pageSize, nil
+}
+
+// parsePagination reads the page and pageSize query parameters
+func parsePagination(c *gin.Context) (int, int, error) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, &filterError{"Invalid page"}
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, 0, &filterError{"Invalid pageSize, it must be between 1 and " + strconv.Itoa(maxPageSize)}
+	}
+
+	return page, pageSize, nil
+}
+
+// parseCreatedRange reads the createdAfter and createdBefore query parameters
+func parseCreatedRange(c *gin.Context, after **time.Time, before **time.Time) error {
+	for name, target := range map[string]**time.Time{"createdAfter": after, "createdBefore": before} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseFilterTime(value)
+		if err != nil {
+			return &filterError{"Invalid " + name + " filter, use YYYY-MM-DD or RFC 3339"}
+		}
+		*target = &parsed
+	}
+	return nil
+}
+
+func parseFilterTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// filterError carries a message that is safe to show the caller
+type filterError struct {
+	message string
+}
+
+func (e *filterError) Error() string {
+	return e.message
+}
+
+// changeStatus moves the user to a new status if the lifecycle allows it and responds with an error if it does not
+func (a *AdminController) changeStatus(c *gin.Context, admin *models.User, user *models.User, status models.AccountStatus, reason string) bool {
+	if user.Status == status {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already " + strings.ReplaceAll(string(status), "_", " ")})
+		return false
+	}
+
+	if !user.Status.CanTransitionTo(status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot change status from " + string(user.Status) + " to " + string(status)})
+		return false
+	}
+
+	return a.inTransaction(c, "Failed to update user status", func(tx *sqlx.Tx) error {
+		return a.DB.UpdateUserStatus(tx, user, status, reason, &admin.ID)
+	})
+}
+
+// inTransaction runs fn in a transaction and responds with the failure message if any step fails
+func (a *AdminController) inTransaction(c *gin.Context, failure string, fn func(tx *sqlx.Tx) error) bool {
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		a.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		a.Logger.Error(failure, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	return true
+}
+
+// revokeSessions rejects every token issued to the user so far and responds with an error if it fails
+func (a *AdminController) revokeSessions(c *gin.Context, userID int, failure string) bool {
+	if err := a.Sessions.RevokeUserTokens(context.Background(), userID, time.Now()); err != nil {
+		a.Logger.Error("Failed to revoke user sessions", "userID", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+	return true
+}
+
+// viewAction names a read of the admin API in the audit log, with the filters of the query string
+func viewAction(c *gin.Context, action string) string {
+	if c.Request.URL.RawQuery == "" {
+		return action
+	}
+	return action + " ?" + c.Request.URL.RawQuery
+}
+
+// audit records an admin action against a user in the audit log
+// user is nil for actions that do not target a user
+func (a *AdminController) audit(c *gin.Context, admin *models.User, action string, user *models.User) {
+	var targetUserID *int
+	if user != nil {
+		targetUserID = &user.ID
+	}
+
+	a.Audit.Record(models.AuthEvent{
+		UserID:       &admin.ID,
+		TargetUserID: targetUserID,
+		Email:        admin.Email,
+		Type:         models.AuthEventAdminAction,
+		Outcome:      models.AuthEventSuccess,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Detail:       action,
+	})
+}
@@ -0,0 +1,248 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAdminController_ListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received models.UserFilter
+	repo := &MockAdminRepository{
+		ListUsersFunc: func(filter models.UserFilter) ([]models.User, int, error) {
+			received = filter
+			return []models.User{{ID: 2, Email: "user@example.com"}}, 41, nil
+		},
+	}
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, nil, auditLogger)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/users?"+query, nil)
+		c.Set("user", &models.User{ID: 1, Email: "admin@example.com"})
+		controller.ListUsersHandler(c)
+		return w
+	}
+
+	// 1) Filters and pagination are passed to the repository
+	w := list("verified=false&status=suspended&provider=google&createdAfter=2026-01-01&search=%20user%20&page=3&pageSize=10")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.NotNil(t, received.Verified)
+	assert.False(t, *received.Verified)
+	require.NotNil(t, received.Status)
+	assert.Equal(t, models.AccountStatusSuspended, *received.Status)
+	require.NotNil(t, received.Provider)
+	assert.Equal(t, "google", *received.Provider)
+	require.NotNil(t, received.CreatedAfter)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *received.CreatedAfter)
+	assert.Nil(t, received.CreatedBefore)
+	assert.Equal(t, "user", received.Search)
+	assert.Equal(t, 10, received.Limit)
+	assert.Equal(t, 20, received.Offset)
+
+	var response struct {
+		Users    []models.User `json:"users"`
+		Total    int           `json:"total"`
+		Page     int           `json:"page"`
+		PageSize int           `json:"pageSize"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Users, 1)
+	assert.Equal(t, 41, response.Total)
+	assert.Equal(t, 3, response.Page)
+	assert.Equal(t, 10, response.PageSize)
+
+	// 2) Defaults when nothing is given
+	w = list("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, received.Verified)
+	assert.Equal(t, 20, received.Limit)
+	assert.Equal(t, 0, received.Offset)
+
+	// 3) Bad filters are rejected
+	for _, query := range []string{"verified=maybe", "status=disabled", "createdBefore=yesterday", "page=0", "pageSize=1000"} {
+		w = list(query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// 4) Each successful listing is in the audit log with the filters used
+	require.Len(t, auditLogger.Events, 2)
+	assert.Equal(t, models.AuthEventAdminAction, auditLogger.Events[0].Type)
+	assert.Equal(t, 1, *auditLogger.Events[0].UserID)
+	assert.Nil(t, auditLogger.Events[0].TargetUserID)
+	assert.Equal(t, "user.list ?verified=false&status=suspended&provider=google&createdAfter=2026-01-01&search=%20user%20&page=3&pageSize=10", auditLogger.Events[0].Detail)
+	assert.Equal(t, "user.list", auditLogger.Events[1].Detail)
+}
+
+func TestAdminController_ManageUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	passwordHash := "hash"
+	provider := "google"
+	adminUser := &models.User{ID: 1, Email: "admin@example.com", Status: models.AccountStatusActive, Verified: true, PasswordHash: &passwordHash}
+	users := map[int]*models.User{
+		1: adminUser,
+		2: {ID: 2, Email: "user@example.com", Status: models.AccountStatusPendingVerification, PasswordHash: &passwordHash},
+		3: {ID: 3, Email: "oauth@example.com", Status: models.AccountStatusActive, Verified: true, Provider: &provider},
+		4: {ID: 4, Email: "former@example.com", Status: models.AccountStatusActive, Verified: true, PasswordHash: &passwordHash},
+	}
+	var history []models.AccountStatusChange
+
+	repo := &MockAdminRepository{
+		FindUserByIDFunc:      func(id int) (*models.User, error) { return users[id], nil },
+		FindRolesByUserIDFunc: func(userID int) ([]string, error) { return []string{"member"}, nil },
+		UpdateUserFunc: func(ext sqlx.Ext, user *models.User) error {
+			users[user.ID] = user
+			return nil
+		},
+		UpdateUserStatusFunc: func(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error {
+			from := user.Status
+			history = append(history, models.AccountStatusChange{UserID: user.ID, FromStatus: &from, ToStatus: status, Reason: reason, ChangedBy: changedBy})
+			user.Status = status
+			user.StatusReason = &reason
+			return nil
+		},
+		FindAccountStatusHistoryFunc: func(userID int) ([]models.AccountStatusChange, error) {
+			return history, nil
+		},
+		DeleteUserFunc: func(ext sqlx.Ext, id int) (bool, error) {
+			_, found := users[id]
+			delete(users, id)
+			return found, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	revoked := map[int]bool{}
+	sessions := &MockSessionRevoker{
+		RevokeUserTokensFunc: func(ctx context.Context, userID int, before time.Time) error {
+			revoked[userID] = true
+			return nil
+		},
+	}
+
+	var resets []string
+	passwordResets := &MockPasswordResetter{
+		SendPasswordResetFunc: func(email string) error {
+			resets = append(resets, email)
+			return nil
+		},
+	}
+
+	auditLogger := &MockAuditLogger{}
+	controller := admin.NewAdminController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, sessions, passwordResets, auditLogger)
+
+	userParam := func(id string) gin.Params {
+		return gin.Params{{Key: "id", Value: id}}
+	}
+
+	// 1) Viewing a user includes their roles but never the password hash
+	w := executeAdminHandler(controller.GetUserHandler, adminUser, userParam("2"), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"roles":["member"]`)
+	assert.NotContains(t, w.Body.String(), passwordHash)
+
+	w = executeAdminHandler(controller.GetUserHandler, adminUser, userParam("abc"), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 2) Suspending needs a reason and signs the user out, admins cannot suspend themselves
+	w = executeAdminHandler(controller.SuspendUserHandler, adminUser, userParam("1"), `{"reason":"Testing"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = executeAdminHandler(controller.SuspendUserHandler, adminUser, userParam("2"), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeAdminHandler(controller.SuspendUserHandler, adminUser, userParam("2"), `{"reason":"Chargeback fraud"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.AccountStatusSuspended, users[2].Status)
+	assert.True(t, revoked[2])
+
+	w = executeAdminHandler(controller.SuspendUserHandler, adminUser, userParam("2"), `{"reason":"Again"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 3) Reactivating an unverified user puts them back to pending verification
+	w = executeAdminHandler(controller.ReactivateUserHandler, adminUser, userParam("2"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.AccountStatusPendingVerification, users[2].Status)
+
+	// 4) Force verifying activates the account
+	w = executeAdminHandler(controller.VerifyUserHandler, adminUser, userParam("2"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, users[2].Verified)
+	assert.Equal(t, models.AccountStatusActive, users[2].Status)
+
+	// Every change is in the history with its reason and the admin who made it
+	require.Len(t, history, 3)
+	assert.Equal(t, "Chargeback fraud", history[0].Reason)
+	assert.Equal(t, "Reactivated by an admin", history[1].Reason)
+	assert.Equal(t, models.AccountStatusActive, history[2].ToStatus)
+	require.NotNil(t, history[2].ChangedBy)
+	assert.Equal(t, adminUser.ID, *history[2].ChangedBy)
+
+	w = executeAdminHandler(controller.StatusHistoryHandler, adminUser, userParam("2"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Chargeback fraud")
+
+	// 5) Password resets are only sent to users with a password
+	w = executeAdminHandler(controller.ResetUserPasswordHandler, adminUser, userParam("3"), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = executeAdminHandler(controller.ResetUserPasswordHandler, adminUser, userParam("2"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"user@example.com"}, resets)
+
+	// 6) Deleting closes the account, admins cannot delete themselves
+	w = executeAdminHandler(controller.DeleteUserHandler, adminUser, userParam("1"), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = executeAdminHandler(controller.DeleteUserHandler, adminUser, userParam("4"), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.AccountStatusDeleted, users[4].Status)
+	assert.True(t, revoked[4])
+
+	// Deleted accounts cannot come back
+	w = executeAdminHandler(controller.ReactivateUserHandler, adminUser, userParam("4"), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 7) Permanently deleting removes the user
+	permanent := gin.Params{{Key: "id", Value: "3"}}
+	w = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/api/admin/users/3?permanent=true", nil)
+	c.Params = permanent
+	c.Set("user", adminUser)
+	controller.DeleteUserHandler(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, users, 3)
+	assert.True(t, revoked[3])
+
+	w = executeAdminHandler(controller.DeleteUserHandler, adminUser, userParam("3"), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 8) Every successful action, reads included, is in the audit log against the admin and the target
+	var actions []string
+	for _, event := range auditLogger.Events {
+		assert.Equal(t, models.AuthEventAdminAction, event.Type)
+		assert.Equal(t, adminUser.ID, *event.UserID)
+		actions = append(actions, event.Detail)
+	}
+	assert.Equal(t, []string{"user.view", "user.suspend", "user.reactivate", "user.verify", "user.status_history", "user.password_reset", "user.delete", "user.purge"}, actions)
+}
@@ -0,0 +1,62 @@
+package auth_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_AccountStatusEnforced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 8, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, _ := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc:    func(id int) (*models.User, error) { return user, nil },
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	if err != nil {
+		t.Fatalf("failed to create AuthController: %v", err)
+	}
+
+	login := func() int {
+		req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+		return executeLoginHandler(authController, req).Code
+	}
+
+	// 1) An active user signs in and gets a refresh token
+	req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+	w := executeLoginHandler(authController, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	refreshToken := cookieValue(w, "refresh_token")
+
+	// 2) Once suspended, neither the password nor the refresh token works
+	user.Status = models.AccountStatusSuspended
+	assert.Equal(t, http.StatusForbidden, login())
+
+	w = executeRefreshHandler(authController, refreshToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, cookieValue(w, "auth_token"))
+
+	// 3) The refresh token family was revoked, so lifting the suspension does not bring it back
+	user.Status = models.AccountStatusActive
+	w = executeRefreshHandler(authController, refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 4) Deleted accounts look like a wrong password
+	user.Status = models.AccountStatusDeleted
+	assert.Equal(t, http.StatusUnauthorized, login())
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// rejectUnavailableAccount responds with an error and returns true if the account may not be issued tokens.
+// Rejections are audited as a failed eventType.
+func (a *AuthController) rejectUnavailableAccount(c *gin.Context, user *models.User, eventType models.AuthEventType) bool {
+	if user.Status == models.AccountStatusActive {
+		return false
+	}
+
+	a.recordAuthEvent(c, eventType, models.AuthEventFailure, user, "", "Account "+string(user.Status))
+
+	switch user.Status {
+	case models.AccountStatusSuspended:
+		a.HandleError(c, http.StatusForbidden, "Your account has been suspended", "Account suspended", errors.New("Account suspended"))
+	case models.AccountStatusPendingVerification:
+		a.HandleError(c, http.StatusForbidden, "Please verify your email before signing in", "Account pending verification", errors.New("Account pending verification"))
+	default:
+		a.HandleError(c, http.StatusUnauthorized, "Invalid email or password", "Account unavailable", fmt.Errorf("account status %q", user.Status))
+	}
+	return true
+}
+
+// activateVerifiedUser marks the user's email as verified and activates the account if it was waiting on verification
+func (a *AuthController) activateVerifiedUser(ext sqlx.Ext, user *models.User, reason string) error {
+	user.Verified = true
+	if err := a.UserDB.UpdateUser(ext, user); err != nil {
+		return err
+	}
+
+	if user.Status != models.AccountStatusPendingVerification {
+		return nil
+	}
+	return a.UserDB.UpdateUserStatus(ext, user, models.AccountStatusActive, reason, nil)
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+// ActivityHandler returns the authenticated user's most recent security events.
+func (a *AuthController) ActivityHandler(c *gin.Context) {
+	// 1) Get the user set by the auth middleware
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Read how many events to return
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultActivityLimit)))
+	if err != nil || limit < 1 || limit > maxActivityLimit {
+		a.HandleError(c, http.StatusBadRequest, "Limit must be between 1 and "+strconv.Itoa(maxActivityLimit), "Invalid activity limit", err)
+		return
+	}
+
+	// 3) Fetch the events
+	events, _, err := a.UserDB.FindAuthEvents(models.AuthEventFilter{UserID: &user.ID, Limit: limit})
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to find auth events", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
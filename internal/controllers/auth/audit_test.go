@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_LoginRecordsAuthEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 8, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, _ := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) {
+			if email == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	require.NoError(t, err)
+	auditLogger := &MockAuditLogger{}
+	authController.Audit = auditLogger
+
+	login := func(email, password string) int {
+		req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": email, "password": password})
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("User-Agent", "audit-test")
+		return executeLoginHandler(authController, req).Code
+	}
+
+	// 1) Unknown emails are recorded against the email only
+	assert.Equal(t, http.StatusUnauthorized, login("nobody@example.com", "password123"))
+
+	// 2) A wrong password is recorded against the user
+	assert.Equal(t, http.StatusUnauthorized, login(user.Email, "wrong-password"))
+
+	// 3) A successful sign in
+	assert.Equal(t, http.StatusOK, login(user.Email, "password123"))
+
+	require.Len(t, auditLogger.Events, 3)
+	for _, event := range auditLogger.Events {
+		assert.Equal(t, models.AuthEventLogin, event.Type)
+		assert.Equal(t, "192.0.2.1", event.IP)
+		assert.Equal(t, "audit-test", event.UserAgent)
+	}
+
+	assert.Nil(t, auditLogger.Events[0].UserID)
+	assert.Equal(t, "nobody@example.com", auditLogger.Events[0].Email)
+	assert.Equal(t, models.AuthEventFailure, auditLogger.Events[0].Outcome)
+
+	require.NotNil(t, auditLogger.Events[1].UserID)
+	assert.Equal(t, user.ID, *auditLogger.Events[1].UserID)
+	assert.Equal(t, models.AuthEventFailure, auditLogger.Events[1].Outcome)
+	assert.Equal(t, "Invalid password", auditLogger.Events[1].Detail)
+
+	assert.Equal(t, models.AuthEventSuccess, auditLogger.Events[2].Outcome)
+}
+
+func TestAuthController_Activity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: 8, Email: "test@example.com", Verified: true, Status: models.AccountStatusActive}
+
+	var received models.AuthEventFilter
+	mockDB := &MockDB{
+		FindAuthEventsFunc: func(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+			received = filter
+			return []models.AuthEvent{{ID: 1, UserID: &user.ID, Type: models.AuthEventLogin, Outcome: models.AuthEventSuccess}}, 1, nil
+		},
+	}
+
+	authController, err := createTestAuthController(mockDB, &MockRedisClient{}, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+
+	activity := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/auth/me/activity?"+query, nil)
+		c.Set("user", user)
+		authController.ActivityHandler(c)
+		return w
+	}
+
+	// 1) Only the signed in user's events are returned, 20 by default
+	w := activity("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"events"`)
+	require.NotNil(t, received.UserID)
+	assert.Equal(t, user.ID, *received.UserID)
+	assert.Equal(t, 20, received.Limit)
+
+	w = activity("limit=5")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, received.Limit)
+
+	// 2) Limits outside the allowed range are rejected
+	for _, query := range []string{"limit=0", "limit=101", "limit=all"} {
+		w = activity(query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// recordAuthEvent adds the client's IP and user agent to an event and writes it to the audit log.
+// user may be nil when the attempt did not match an account, email is what the client gave us.
+func (a *AuthController) recordAuthEvent(c *gin.Context, eventType models.AuthEventType, outcome models.AuthEventOutcome, user *models.User, email string, detail string) {
+	event := models.AuthEvent{
+		Email:     email,
+		Type:      eventType,
+		Outcome:   outcome,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Detail:    detail,
+	}
+
+	if user != nil {
+		event.UserID = &user.ID
+		event.Email = user.Email
+	}
+
+	a.Audit.Record(event)
+}
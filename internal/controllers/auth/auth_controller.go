@@ -2,23 +2,37 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/lockout"
 	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/oauth"
+	"github.com/jalil32/go-auth-module/internal/session"
 )
 
 type JWTGenerator interface {
 	GenerateJWT(user *models.User) (string, error)
+	ParseJWT(tokenString string) (jwt.MapClaims, error)
 }
 
 type RedisClient interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
 }
 
 type Logger interface {
@@ -26,10 +40,34 @@ type Logger interface {
 	Info(msg string, args ...any)
 }
 
+// AuditLogger records security events, it must not fail the request when the event cannot be stored
+type AuditLogger interface {
+	Record(event models.AuthEvent)
+}
+
 type UserRepository interface {
 	FindUserByEmail(email string) (*models.User, error)
+	FindUserByID(id int) (*models.User, error)
 	CreateUser(ext sqlx.Ext, user *models.User) error
 	UpdateUser(ext sqlx.Ext, user *models.User) error
+	UpdateUserStatus(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error
+	UpdateTOTP(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error
+	ReplaceRecoveryCodes(ext sqlx.Ext, userID int, codeHashes []string) error
+	ConsumeRecoveryCode(ext sqlx.Ext, userID int, codeHash string) (bool, error)
+	FindWebAuthnCredentialsByUserID(userID int) ([]models.WebAuthnCredential, error)
+	CreateWebAuthnCredential(ext sqlx.Ext, credential *models.WebAuthnCredential) error
+	UpdateWebAuthnCredentialUsage(ext sqlx.Ext, credential *models.WebAuthnCredential) error
+	DeleteWebAuthnCredential(ext sqlx.Ext, userID int, id int) (bool, error)
+	FindAuthEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error)
+	FindIdentity(provider string, providerUserID string) (*models.UserIdentity, error)
+	FindIdentitiesByUserID(userID int) ([]models.UserIdentity, error)
+	CreateIdentity(ext sqlx.Ext, identity *models.UserIdentity) error
+	TouchIdentity(ext sqlx.Ext, id int) error
+	UpdateIdentityEmail(ext sqlx.Ext, userID int, provider string, email string) error
+	DeleteIdentity(ext sqlx.Ext, userID int, provider string) (bool, error)
+	FindPersonalAccessTokensByUserID(userID int) ([]models.PersonalAccessToken, error)
+	CreatePersonalAccessToken(ext sqlx.Ext, token *models.PersonalAccessToken) error
+	DeletePersonalAccessToken(ext sqlx.Ext, userID int, id int) (bool, error)
 	Beginx() (*sqlx.Tx, error)
 }
 
@@ -37,30 +75,78 @@ type AuthController struct {
 	UserDB          UserRepository
 	RedisCache      RedisClient
 	Logger          Logger
+	Audit           AuditLogger
 	JwtToken        string
 	JwtExpiry       string
+	RefreshExpiry   string
 	Host            string
 	Port            string
 	Username        string
 	Password        string
 	FrontendAddress string
 	JWTGenerator    JWTGenerator
+	Sessions        *session.RevocationStore
+	MFAIssuer       string
+	MFAKey          []byte
+	WebAuthn        *webauthn.WebAuthn
+	Lockout         *lockout.Guard
+	OAuth           *oauth.Registry
 }
 
 // NewAuthController initializes a new AuthController
-func NewAuthController(userRepo UserRepository, rdb RedisClient, logger Logger, jwtGenerator JWTGenerator, cfg *config.Config) (*AuthController, error) {
+func NewAuthController(userRepo UserRepository, rdb RedisClient, logger Logger, jwtGenerator JWTGenerator, auditLogger AuditLogger, cfg *config.Config) (*AuthController, error) {
+	// MFA secrets are encrypted with a 256 bit key, enrollment is disabled if it is not configured
+	var mfaKey []byte
+	if cfg.MFA.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.MFA.EncryptionKey)
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("MFA_ENCRYPTION_KEY must be 32 base64 encoded bytes")
+		}
+		mfaKey = key
+	}
+
+	// Passkeys are bound to the relying party ID, which must be the frontend's domain
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     strings.Split(cfg.WebAuthn.RPOrigins, ","),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webauthn: %w", err)
+	}
+
+	// Failed login and OTP attempts are throttled per account and per client IP
+	lockoutGuard, err := lockout.NewGuard(rdb, cfg.Lockout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign in providers are built from config and registered with goth, which finds them by name
+	oauthProviders, err := oauth.NewRegistry(cfg.OAuth)
+	if err != nil {
+		return nil, err
+	}
+	oauthProviders.Use()
 
 	return &AuthController{
 		UserDB:          userRepo,
 		RedisCache:      rdb,
 		Logger:          logger,
+		Audit:           auditLogger,
 		JwtToken:        cfg.JWT.Token,
 		JwtExpiry:       cfg.JWT.Expiry,
+		RefreshExpiry:   cfg.JWT.RefreshExpiry,
 		Host:            cfg.SMTP.Host,
 		Port:            cfg.SMTP.Port,
 		Username:        cfg.SMTP.Username,
 		Password:        cfg.SMTP.Password,
 		FrontendAddress: cfg.Frontend.Addr,
 		JWTGenerator:    jwtGenerator,
+		Sessions:        session.NewRevocationStore(rdb, cfg.JWT),
+		MFAIssuer:       cfg.MFA.Issuer,
+		MFAKey:          mfaKey,
+		WebAuthn:        webAuthn,
+		Lockout:         lockoutGuard,
+		OAuth:           oauthProviders,
 	}, nil
 }
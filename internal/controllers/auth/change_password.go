@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// ChangePasswordHandler changes the authenticated user's password. Every other session is signed out,
+// the current one is issued new tokens so it stays signed in.
+func (a *AuthController) ChangePasswordHandler(c *gin.Context) {
+	// 1) Get the authenticated user, the stored record has the password hash
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.HandleError(c, http.StatusUnauthorized, "Unauthorized", "User not found", errors.New("User not found"))
+		return
+	}
+
+	// 2) Bind and validate the request, the new password must fulfil our requirements
+	var request ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	// 3) Accounts that sign in with a provider add a password instead
+	if user.PasswordHash == nil {
+		a.HandleError(c, http.StatusConflict, "Your account does not have a password yet, add one instead", "User has no password", errors.New("User has no password"))
+		return
+	}
+
+	// 4) Check the current password
+	if !a.reauthenticate(c, user, request.CurrentPassword, models.AuthEventPasswordChanged) {
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(request.NewPassword)) == nil {
+		a.HandleError(c, http.StatusBadRequest, "The new password must be different from the current one", "New password matches current password", errors.New("New password matches current password"))
+		return
+	}
+
+	// 5) Hash and store the new password
+	hashedPassword, err := a.hashPassword(request.NewPassword)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to hash password", err)
+		return
+	}
+	user.PasswordHash = &hashedPassword
+
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateUser(tx, user); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update password", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	// 6) Sign out every session, then sign this one back in with tokens issued after the cut off
+	if revokeErr := a.revokeAllSessions(user.ID); revokeErr != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to revoke sessions", revokeErr)
+		return
+	}
+
+	if currentRefreshToken, cookieErr := c.Cookie(refreshTokenCookie); cookieErr == nil && currentRefreshToken != "" {
+		a.revokeRefreshToken(currentRefreshToken)
+	}
+
+	token, tokenErr := a.JWTGenerator.GenerateJWT(user)
+	if tokenErr != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", tokenErr)
+		return
+	}
+
+	refreshToken, tokenErr := a.issueRefreshToken(user.ID, "")
+	if tokenErr != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", tokenErr)
+		return
+	}
+
+	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, refreshToken)
+
+	// 7) Tell the user, in the background so a slow mail server does not hold up the response
+	go func(email string, userID int) {
+		if sendErr := a.sendPasswordChangedEmail(email); sendErr != nil {
+			a.Logger.Error("Failed to send password changed email", "userID", userID, "error", sendErr)
+		}
+	}(user.Email, user.ID)
+
+	a.recordAuthEvent(c, models.AuthEventPasswordChanged, models.AuthEventSuccess, user, "", "")
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed. You have been signed out everywhere else."})
+}
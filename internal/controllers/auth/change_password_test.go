@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 7, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc: func(id int) (*models.User, error) {
+			copied := *user
+			return &copied, nil
+		},
+		UpdateUserFunc: func(ext sqlx.Ext, updated *models.User) error {
+			user.PasswordHash = updated.PasswordHash
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	require.NoError(t, err)
+
+	login := func() string {
+		req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+		w := executeLoginHandler(authController, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return cookieValue(w, "refresh_token")
+	}
+
+	changePassword := func(currentPassword, newPassword string, refreshToken string) *httptest.ResponseRecorder {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/me/password", map[string]string{"currentPassword": currentPassword, "newPassword": newPassword})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+		return executeAuthenticatedHandler(authController.ChangePasswordHandler, &models.User{ID: user.ID}, req)
+	}
+
+	// 1) Sign in on two devices, backdated so the cut off set below falls after both sign ins
+	currentSession := login()
+	otherSession := login()
+	for key, value := range store {
+		if strings.HasPrefix(key, "refresh_family:") {
+			var family map[string]any
+			require.NoError(t, json.Unmarshal([]byte(value), &family))
+			family["createdAtMs"] = time.Now().Add(-time.Minute).UnixMilli()
+			backdated, _ := json.Marshal(family)
+			store[key] = string(backdated)
+		}
+	}
+
+	// 2) The current password must be right and the new one strong and different
+	assert.Equal(t, http.StatusUnauthorized, changePassword("wrong-password", "NewPassword123!", currentSession).Code)
+	assert.Equal(t, http.StatusBadRequest, changePassword("password123", "weak", currentSession).Code)
+	assert.Equal(t, http.StatusBadRequest, changePassword("password123", "", currentSession).Code)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte("password123")))
+
+	// 3) Changing it stores the new hash and issues new tokens for the current session
+	w := changePassword("password123", "NewPassword123!", currentSession)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte("NewPassword123!")))
+	assert.Equal(t, "mock-token", cookieValue(w, "auth_token"))
+
+	newSession := cookieValue(w, "refresh_token")
+	require.NotEmpty(t, newSession)
+
+	// 4) Every other session is signed out, the current one stays signed in
+	assert.Equal(t, http.StatusUnauthorized, executeRefreshHandler(authController, otherSession).Code)
+	assert.Equal(t, http.StatusUnauthorized, executeRefreshHandler(authController, currentSession).Code)
+	assert.Equal(t, http.StatusOK, executeRefreshHandler(authController, newSession).Code)
+	assert.Contains(t, store, "tokens_valid_after:7")
+
+	// The new tokens are issued straight away, not after waiting for the cut off to pass
+	cutOff, err := strconv.ParseInt(store["tokens_valid_after:7"], 10, 64)
+	require.NoError(t, err)
+	assert.Less(t, time.Now().UnixMilli()-cutOff, int64(500))
+
+	// 5) Accounts without a password add one instead
+	user.PasswordHash = nil
+	assert.Equal(t, http.StatusConflict, changePassword("password123", "NewPassword123!", newSession).Code)
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// currentUser returns the user set by the auth middleware and responds with 401 if it is missing
+func (a *AuthController) currentUser(c *gin.Context) (*models.User, bool) {
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok || user == nil {
+		a.HandleError(c, http.StatusUnauthorized, "Unauthorized", "User missing from context", errors.New("User missing from context"))
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// emailChangeSentMessage is returned whether or not the new address is taken so the endpoint cannot be used to enumerate users
+const emailChangeSentMessage = "We have sent a confirmation link to your new email address. Your email will change once you confirm it."
+
+// ChangeEmailHandler starts changing the authenticated user's email. Nothing changes until the new address is confirmed.
+func (a *AuthController) ChangeEmailHandler(c *gin.Context) {
+	// 1) Get the authenticated user, the stored record has the password hash
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.HandleError(c, http.StatusUnauthorized, "Unauthorized", "User not found", errors.New("User not found"))
+		return
+	}
+
+	// 2) Bind and validate the request
+	var request ChangeEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	if strings.EqualFold(request.NewEmail, user.Email) {
+		a.HandleError(c, http.StatusBadRequest, "This is already your email address", "New email matches current email", errors.New("New email matches current email"))
+		return
+	}
+
+	// 3) Confirm it is the user asking and not someone at an unlocked computer
+	if !a.reauthenticate(c, user, request.Password, models.AuthEventEmailChangeRequested) {
+		return
+	}
+
+	// 4) Addresses that belong to another account get the same response but no link
+	existing, err := a.UserDB.FindUserByEmail(request.NewEmail)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if existing != nil {
+		a.recordAuthEvent(c, models.AuthEventEmailChangeRequested, models.AuthEventFailure, user, "", "New email already in use")
+		c.JSON(http.StatusOK, gin.H{"message": emailChangeSentMessage})
+		return
+	}
+
+	// 5) Store the change with a confirmation link for the new address and an undo link for the old one
+	change := emailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: request.NewEmail}
+	confirmLink, undoLink, err := a.createEmailChange(change)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create email change", err)
+		return
+	}
+
+	// 6) Send the emails in the background so response times do not reveal whether the new address is taken
+	go func() {
+		if sendErr := a.sendEmailChangeEmails(change, confirmLink, undoLink); sendErr != nil {
+			a.Logger.Error("Failed to send email change emails", "userID", change.UserID, "error", sendErr)
+		}
+	}()
+
+	a.recordAuthEvent(c, models.AuthEventEmailChangeRequested, models.AuthEventSuccess, user, "", "")
+	c.JSON(http.StatusOK, gin.H{"message": emailChangeSentMessage})
+}
+
+// ConfirmEmailChangeHandler changes the user's email with the token from the link sent to the new address
+// and signs out every session.
+func (a *AuthController) ConfirmEmailChangeHandler(c *gin.Context) {
+	// 1) Extract token from URL parameters
+	token := c.Query("token")
+	if token == "" {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Token is required", errors.New("Missing token"))
+		return
+	}
+
+	// 2) Consume the token, it can only be used once
+	change, err := a.consumeEmailChange(emailChangeKey(token))
+	if err != nil {
+		if errors.Is(err, errEmailChangeInvalid) {
+			a.recordAuthEvent(c, models.AuthEventEmailChanged, models.AuthEventFailure, nil, "", "Invalid or expired token")
+			a.HandleError(c, http.StatusBadRequest, "Invalid or expired confirmation link", "Email change validation failed", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to consume email change", err)
+		return
+	}
+
+	// 3) The account must still have the address the change was requested from
+	user, err := a.UserDB.FindUserByID(change.UserID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil || user.Email != change.OldEmail {
+		a.HandleError(c, http.StatusBadRequest, "Invalid or expired confirmation link", "Account email changed since the request", errors.New("Account email changed since the request"))
+		return
+	}
+
+	// 4) The new address may have been registered since the link was sent
+	existing, err := a.UserDB.FindUserByEmail(change.NewEmail)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if existing != nil {
+		a.recordAuthEvent(c, models.AuthEventEmailChanged, models.AuthEventFailure, user, "", "New email already in use")
+		a.HandleError(c, http.StatusConflict, "This email address is already in use", "New email already in use", errors.New("New email already in use"))
+		return
+	}
+
+	// 5) Swap the email, the new address is verified because the link sent to it was used
+	if err := a.updateUserEmail(user, change.NewEmail); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update email", err)
+		return
+	}
+
+	// 6) Sessions carry the old email, so every device has to sign in again
+	if err := a.revokeAllSessions(user.ID); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to revoke sessions", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventEmailChanged, models.AuthEventSuccess, user, "", "Changed from "+change.OldEmail)
+	c.JSON(http.StatusOK, gin.H{"message": "Your email address has been changed. Please sign in again."})
+}
+
+// UndoEmailChangeHandler handles the link sent to the old address. It cancels a pending change or reverts a
+// confirmed one, and signs out every session since the change was not made by the owner.
+func (a *AuthController) UndoEmailChangeHandler(c *gin.Context) {
+	// 1) Extract token from URL parameters
+	token := c.Query("token")
+	if token == "" {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Token is required", errors.New("Missing token"))
+		return
+	}
+
+	// 2) Consume the token, it can only be used once
+	change, err := a.consumeEmailChange(emailChangeUndoKey(token))
+	if err != nil {
+		if errors.Is(err, errEmailChangeInvalid) {
+			a.recordAuthEvent(c, models.AuthEventEmailChangeReverted, models.AuthEventFailure, nil, "", "Invalid or expired token")
+			a.HandleError(c, http.StatusBadRequest, "Invalid or expired link", "Email change undo validation failed", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to consume email change undo", err)
+		return
+	}
+
+	// 3) Cancel the change if it has not been confirmed yet
+	if err := a.RedisCache.Del(context.Background(), emailChangeKey(change.ConfirmToken)).Err(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to cancel email change", err)
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(change.UserID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.HandleError(c, http.StatusBadRequest, "Invalid or expired link", "User not found", errors.New("User not found"))
+		return
+	}
+
+	detail := "Cancelled before confirmation"
+	switch user.Email {
+	case change.OldEmail:
+		// Nothing to revert
+	case change.NewEmail:
+		// 4) Put the old address back unless another account has taken it since
+		existing, err := a.UserDB.FindUserByEmail(change.OldEmail)
+		if err != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+			return
+		}
+
+		if existing != nil {
+			a.recordAuthEvent(c, models.AuthEventEmailChangeReverted, models.AuthEventFailure, user, "", "Old email already in use")
+			a.HandleError(c, http.StatusConflict, "This email address is already in use", "Old email already in use", errors.New("Old email already in use"))
+			return
+		}
+
+		if err := a.updateUserEmail(user, change.OldEmail); err != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update email", err)
+			return
+		}
+		detail = "Reverted to " + change.OldEmail
+	default:
+		a.HandleError(c, http.StatusConflict, "Your email address has changed again since this link was sent", "Account email changed since the request", errors.New("Account email changed since the request"))
+		return
+	}
+
+	// 5) Whoever requested the change is signed out
+	if err := a.revokeAllSessions(user.ID); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to revoke sessions", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventEmailChangeReverted, models.AuthEventSuccess, user, "", detail)
+	c.JSON(http.StatusOK, gin.H{"message": "The email change has been undone and every session signed out. Please sign in again and change your password."})
+}
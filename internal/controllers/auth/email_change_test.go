@@ -0,0 +1,228 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// newEmailChangeController returns a controller whose users live in the given map keyed by ID. The email of each
+// user's password identity is kept in identityEmails.
+func newEmailChangeController(t *testing.T, users map[int]*models.User, identityEmails map[int]string) (*auth.AuthController, map[string]string, *MockAuditLogger) {
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByIDFunc: func(id int) (*models.User, error) {
+			if user, ok := users[id]; ok {
+				copied := *user
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		FindUserByEmailFunc: func(email string) (*models.User, error) {
+			for _, user := range users {
+				if user.Email == email {
+					copied := *user
+					return &copied, nil
+				}
+			}
+			return nil, nil
+		},
+		UpdateUserFunc: func(ext sqlx.Ext, updated *models.User) error {
+			copied := *updated
+			users[updated.ID] = &copied
+			return nil
+		},
+		UpdateIdentityEmailFunc: func(ext sqlx.Ext, userID int, provider string, email string) error {
+			if provider == models.IdentityProviderPassword {
+				identityEmails[userID] = email
+			}
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+
+	auditLogger := &MockAuditLogger{}
+	authController.Audit = auditLogger
+	return authController, store, auditLogger
+}
+
+// storedTokens returns the tokens stored in redis under the prefix.
+func storedTokens(store map[string]string, prefix string) []string {
+	var tokens []string
+	for key := range store {
+		if strings.HasPrefix(key, prefix) {
+			tokens = append(tokens, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return tokens
+}
+
+func executeEmailChangeLink(handler gin.HandlerFunc, path string, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, path+"?token="+token, nil)
+	return executeHandler(handler, req)
+}
+
+func TestAuthController_ChangeEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	users := map[int]*models.User{
+		8: {ID: 8, Email: "old@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive},
+		9: {ID: 9, Email: "taken@example.com", Verified: true, Status: models.AccountStatusActive},
+	}
+	identityEmails := map[int]string{8: "old@example.com"}
+	authController, store, auditLogger := newEmailChangeController(t, users, identityEmails)
+
+	changeEmail := func(newEmail, password string) *httptest.ResponseRecorder {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/me/email", map[string]string{"newEmail": newEmail, "password": password})
+		return executeAuthenticatedHandler(authController.ChangeEmailHandler, &models.User{ID: 8}, req)
+	}
+
+	// 1) The password is checked and invalid or unchanged addresses are rejected
+	assert.Equal(t, http.StatusUnauthorized, changeEmail("new@example.com", "wrong-password").Code)
+	assert.Equal(t, http.StatusBadRequest, changeEmail("not-an-email", "password123").Code)
+	assert.Equal(t, http.StatusBadRequest, changeEmail("old@example.com", "password123").Code)
+	assert.Empty(t, storedTokens(store, "email_change:"))
+
+	// 2) Addresses of other accounts get the same response but no link
+	taken := changeEmail("taken@example.com", "password123")
+	assert.Equal(t, http.StatusOK, taken.Code)
+	assert.Empty(t, storedTokens(store, "email_change:"))
+
+	// 3) Nothing changes until the new address is confirmed
+	w := changeEmail("new@example.com", "password123")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, taken.Body.String(), w.Body.String())
+	assert.Equal(t, "old@example.com", users[8].Email)
+
+	confirmTokens := storedTokens(store, "email_change:")
+	require.Len(t, confirmTokens, 1)
+	require.Len(t, storedTokens(store, "email_change_undo:"), 1)
+
+	// 4) Confirming swaps the email and revokes every session
+	w = executeEmailChangeLink(authController.ConfirmEmailChangeHandler, "/api/auth/email/confirm", confirmTokens[0])
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "new@example.com", users[8].Email)
+	assert.Equal(t, "new@example.com", identityEmails[8])
+	assert.True(t, users[8].Verified)
+	assert.Contains(t, store, "tokens_valid_after:8")
+
+	// 5) The confirmation link is single use
+	w = executeEmailChangeLink(authController.ConfirmEmailChangeHandler, "/api/auth/email/confirm", confirmTokens[0])
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var types []models.AuthEventType
+	for _, event := range auditLogger.Events {
+		if event.Outcome == models.AuthEventSuccess {
+			types = append(types, event.Type)
+		}
+	}
+	assert.Equal(t, []models.AuthEventType{models.AuthEventEmailChangeRequested, models.AuthEventEmailChanged}, types)
+}
+
+func TestAuthController_UndoEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	users := map[int]*models.User{
+		8: {ID: 8, Email: "old@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive},
+	}
+	identityEmails := map[int]string{8: "old@example.com"}
+	authController, store, _ := newEmailChangeController(t, users, identityEmails)
+
+	requestChange := func() (string, string) {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/me/email", map[string]string{"newEmail": "attacker@example.com", "password": "password123"})
+		w := executeAuthenticatedHandler(authController.ChangeEmailHandler, &models.User{ID: 8}, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		confirmTokens := storedTokens(store, "email_change:")
+		undoTokens := storedTokens(store, "email_change_undo:")
+		require.Len(t, confirmTokens, 1)
+		require.Len(t, undoTokens, 1)
+		return confirmTokens[0], undoTokens[0]
+	}
+
+	// 1) Undoing a pending change cancels it
+	confirmToken, undoToken := requestChange()
+	w := executeEmailChangeLink(authController.UndoEmailChangeHandler, "/api/auth/email/undo", undoToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, storedTokens(store, "email_change:"))
+
+	w = executeEmailChangeLink(authController.ConfirmEmailChangeHandler, "/api/auth/email/confirm", confirmToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "old@example.com", users[8].Email)
+
+	// 2) Undoing a confirmed change puts the old address back
+	confirmToken, undoToken = requestChange()
+	w = executeEmailChangeLink(authController.ConfirmEmailChangeHandler, "/api/auth/email/confirm", confirmToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attacker@example.com", users[8].Email)
+	assert.Equal(t, "attacker@example.com", identityEmails[8])
+
+	w = executeEmailChangeLink(authController.UndoEmailChangeHandler, "/api/auth/email/undo", undoToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "old@example.com", users[8].Email)
+	assert.Equal(t, "old@example.com", identityEmails[8])
+
+	// 3) The undo link is single use
+	w = executeEmailChangeLink(authController.UndoEmailChangeHandler, "/api/auth/email/undo", undoToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthController_ChangeEmailWithoutPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provider := "google"
+	users := map[int]*models.User{
+		8: {ID: 8, Email: "old@example.com", Provider: &provider, Verified: true, Status: models.AccountStatusActive},
+	}
+	authController, store, _ := newEmailChangeController(t, users, map[int]string{})
+
+	// signIn stores a refresh token whose family signed in at the given time
+	signIn := func(token string, at time.Time) {
+		hash := sha256.Sum256([]byte(token))
+		record, _ := json.Marshal(map[string]any{"userId": 8, "familyId": token})
+		family, _ := json.Marshal(map[string]any{"userId": 8, "createdAtMs": at.UnixMilli()})
+		store["refresh_token:"+hex.EncodeToString(hash[:])] = string(record)
+		store[fmt.Sprintf("refresh_family:%s", token)] = string(family)
+	}
+
+	changeEmail := func(refreshToken string) int {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/me/email", map[string]string{"newEmail": "new@example.com"})
+		if refreshToken != "" {
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+		}
+		return executeAuthenticatedHandler(authController.ChangeEmailHandler, &models.User{ID: 8}, req).Code
+	}
+
+	// 1) Users who sign in with a provider must have signed in recently
+	signIn("stale", time.Now().Add(-time.Hour))
+	signIn("fresh", time.Now().Add(-time.Minute))
+
+	assert.Equal(t, http.StatusForbidden, changeEmail(""))
+	assert.Equal(t, http.StatusForbidden, changeEmail("stale"))
+	assert.Empty(t, storedTokens(store, "email_change:"))
+
+	assert.Equal(t, http.StatusOK, changeEmail("fresh"))
+	assert.Len(t, storedTokens(store, "email_change:"), 1)
+}
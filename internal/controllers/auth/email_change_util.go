@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	// emailChangeExpiry is how long the confirmation link sent to the new address stays valid
+	emailChangeExpiry = 30 * time.Minute
+	// emailChangeUndoExpiry is how long the old address can undo the change
+	emailChangeUndoExpiry = 7 * 24 * time.Hour
+)
+
+var errEmailChangeInvalid = errors.New("email change link is invalid or expired")
+
+// emailChange is stored with the confirmation and undo tokens of a requested email change
+type emailChange struct {
+	UserID       int    `json:"userId"`
+	OldEmail     string `json:"oldEmail"`
+	NewEmail     string `json:"newEmail"`
+	ConfirmToken string `json:"confirmToken,omitempty"`
+}
+
+func emailChangeKey(token string) string {
+	return fmt.Sprintf("email_change:%s", token)
+}
+
+func emailChangeUndoKey(token string) string {
+	return fmt.Sprintf("email_change_undo:%s", token)
+}
+
+// createEmailChange stores the pending change and returns the link that confirms it and the link that undoes it
+func (a *AuthController) createEmailChange(change emailChange) (string, string, error) {
+	ctx := context.Background()
+
+	// 1) The confirmation token goes to the new address
+	confirmToken := uuid.New().String()
+	pending, err := json.Marshal(change)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal email change: %w", err)
+	}
+
+	if err := a.RedisCache.Set(ctx, emailChangeKey(confirmToken), pending, emailChangeExpiry).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store email change token: %w", err)
+	}
+
+	// 2) The undo token goes to the old address and can also cancel the change before it is confirmed
+	undoToken := uuid.New().String()
+	change.ConfirmToken = confirmToken
+	undo, err := json.Marshal(change)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal email change: %w", err)
+	}
+
+	if err := a.RedisCache.Set(ctx, emailChangeUndoKey(undoToken), undo, emailChangeUndoExpiry).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store email change undo token: %w", err)
+	}
+
+	confirmLink := fmt.Sprintf("%s/confirm-email?token=%s", a.FrontendAddress, url.QueryEscape(confirmToken))
+	undoLink := fmt.Sprintf("%s/undo-email-change?token=%s", a.FrontendAddress, url.QueryEscape(undoToken))
+	return confirmLink, undoLink, nil
+}
+
+// consumeEmailChange returns the change stored under the key and deletes it.
+// The token only counts as consumed by the request whose delete removed it, so it cannot be used twice concurrently.
+func (a *AuthController) consumeEmailChange(key string) (*emailChange, error) {
+	ctx := context.Background()
+
+	value, err := a.RedisCache.Get(ctx, key).Result()
+	if err != nil {
+		return nil, errEmailChangeInvalid
+	}
+
+	deleted, err := a.RedisCache.Del(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete email change token: %w", err)
+	}
+
+	if deleted == 0 {
+		return nil, errEmailChangeInvalid
+	}
+
+	var change emailChange
+	if err := json.Unmarshal([]byte(value), &change); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal email change: %w", err)
+	}
+
+	return &change, nil
+}
+
+// updateUserEmail saves the user's email address, which is verified because a link sent to it was used
+func (a *AuthController) updateUserEmail(user *models.User, email string) error {
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	updated := *user
+	updated.Email = email
+	updated.Verified = true
+
+	if err := a.UserDB.UpdateUser(tx, &updated); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	// The password identity signs in with the email address, so it moves with it
+	if err := a.UserDB.UpdateIdentityEmail(tx, user.ID, models.IdentityProviderPassword, email); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	*user = updated
+	return nil
+}
+
+// sendEmailChangeEmails sends the confirmation link to the new address and the undo link to the old one
+func (a *AuthController) sendEmailChangeEmails(change emailChange, confirmLink string, undoLink string) error {
+	confirmBody := fmt.Sprintf("Click the link to confirm %s as the email address of your account, it expires in %d minutes: %s\n\nIf you did not request this, you can ignore this email.", change.NewEmail, int(emailChangeExpiry.Minutes()), confirmLink)
+	if err := a.sendEmail(change.NewEmail, "Confirm your new email address", confirmBody); err != nil {
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	undoBody := fmt.Sprintf("A request was made to change the email address of your account to %s. If this was not you, click the link within %d days to keep %s and sign everyone out: %s", change.NewEmail, int(emailChangeUndoExpiry.Hours()/24), change.OldEmail, undoLink)
+	if err := a.sendEmail(change.OldEmail, "Your email address is being changed", undoBody); err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	return nil
+}
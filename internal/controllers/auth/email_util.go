@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-gomail/gomail"
+)
+
+// sendEmail sends a plain text email through the configured SMTP server, giving up after 10 seconds
+func (a *AuthController) sendEmail(to string, subject string, body string) error {
+	// 1) Create new message
+	message := gomail.NewMessage()
+
+	// 2) Set email headers
+	message.SetHeader("From", "team@demomailtrap.com")
+	message.SetHeader("To", to)
+	message.SetHeader("Subject", subject)
+	message.SetBody("text/plain", body)
+
+	// 3) Convert port to int
+	port, err := strconv.Atoi(a.Port)
+	if err != nil {
+		return fmt.Errorf("failed to convert port: %w", err)
+	}
+
+	// 4) Create a context with a timeout of 10 seconds
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 5) Create a channel to handle the result of the email sending
+	done := make(chan error, 1)
+
+	// 6) Run the email sending in a goroutine
+	go func() {
+		dialer := gomail.NewDialer(a.Host, port, a.Username, a.Password)
+		done <- dialer.DialAndSend(message)
+	}()
+
+	// 7) Wait for either the email to be sent or the context to timeout
+	select {
+	case <-ctx.Done():
+		// Context timed out
+		return fmt.Errorf("timeout reached")
+	case err := <-done:
+		// Email sending completed
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
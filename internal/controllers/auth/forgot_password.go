@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
 )
 
 // Endpoint to handle forgot password
@@ -43,29 +45,25 @@ func (a *AuthController) ForgotPasswordHandler(c *gin.Context) {
 	}
 
 	if user == nil {
+		a.recordAuthEvent(c, models.AuthEventPasswordResetRequested, models.AuthEventFailure, nil, request.Email, "Unknown email")
 		a.HandleError(c, http.StatusUnauthorized, "If your account exists, we have sent you an email to reset your password.", "User not found", errors.New("No User"))
 		return
 	}
 
-	// 4) Check if they use a provider and not traditional login/signup
-	if user.Provider != nil {
-		a.HandleError(c, http.StatusBadRequest, "Please sign in with Google.", "User not found", nil)
-		return
-	}
-
-	// 5) Generate forgot password link
+	// 4) Generate forgot password link, users who signed up with a provider use it to add a password
 	link, err := a.generateForgotPasswordLink(request.Email)
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate reset link", err)
 		return
 	}
 
-	// 6) Send the forgot password link to the user
+	// 5) Send the forgot password link to the user
 	if err := a.sendForgotPasswordToken(request.Email, link); err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to send reset email", err)
 		return
 	}
 
+	a.recordAuthEvent(c, models.AuthEventPasswordResetRequested, models.AuthEventSuccess, user, "", "")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "If your account exists, we have sent you an email to reset your password.",
 	})
@@ -107,6 +105,7 @@ func (a *AuthController) ResetPasswordHandler(c *gin.Context) {
 	// 4) Validate the forgot password token
 	email, err := a.validateForgotPasswordToken(token)
 	if err != nil {
+		a.recordAuthEvent(c, models.AuthEventPasswordResetCompleted, models.AuthEventFailure, nil, "", "Invalid or expired token")
 		a.HandleError(c, http.StatusBadRequest, "Invalid or expired token", "Token validation failed", err)
 		return
 	}
@@ -126,6 +125,7 @@ func (a *AuthController) ResetPasswordHandler(c *gin.Context) {
 	}
 
 	// 7) Set the users password to new password hash
+	addingPassword := user.PasswordHash == nil
 	user.PasswordHash = &hashedPassword
 
 	// 8) Update the user
@@ -150,13 +150,28 @@ func (a *AuthController) ResetPasswordHandler(c *gin.Context) {
 		return
 	}
 
+	// Users who signed up with a provider can now also sign in with their password
+	if addingPassword {
+		if identityErr := a.UserDB.CreateIdentity(tx, &models.UserIdentity{UserID: user.ID, Provider: models.IdentityProviderPassword, Email: &user.Email}); identityErr != nil {
+			err = identityErr
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to link password identity", identityErr)
+			return
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
 		return
 	}
 
-	// 9) Return success
+	// 9) Sign out every existing session so a stolen token stops working
+	if revokeErr := a.revokeAllSessions(user.ID); revokeErr != nil {
+		a.Logger.Error("Failed to revoke sessions after password reset", "userID", user.ID, "error", revokeErr)
+	}
+
+	// 10) Return success
+	a.recordAuthEvent(c, models.AuthEventPasswordResetCompleted, models.AuthEventSuccess, user, "", "")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password reset successfully.",
 	})
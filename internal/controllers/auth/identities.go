@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// ListIdentitiesHandler returns every way the authenticated user can sign in.
+func (a *AuthController) ListIdentitiesHandler(c *gin.Context) {
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	identities, err := a.UserDB.FindIdentitiesByUserID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load identities", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// AddPasswordHandler lets a user who signed up with a provider also sign in with a password.
+func (a *AuthController) AddPasswordHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Bind and validate the request
+	var request AddPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	// 3) Load the user, accounts that already have a password change it instead
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load user", err)
+		return
+	}
+
+	if user.PasswordHash != nil {
+		a.HandleError(c, http.StatusConflict, "Your account already has a password", "Password identity already linked", errors.New("Password identity already linked"))
+		return
+	}
+
+	// 4) Hash the password
+	hashedPassword, err := a.hashPassword(request.Password)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to hash password", err)
+		return
+	}
+	user.PasswordHash = &hashedPassword
+
+	// 5) Store the password and its identity together
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateUser(tx, user); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update password", err)
+		return
+	}
+
+	if err = a.UserDB.CreateIdentity(tx, &models.UserIdentity{UserID: user.ID, Provider: models.IdentityProviderPassword, Email: &user.Email}); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to link password identity", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventIdentityLinked, models.AuthEventSuccess, user, "", models.IdentityProviderPassword)
+	c.JSON(http.StatusOK, gin.H{"message": "Password added"})
+}
+
+// LinkProviderHandler starts an OAuth flow that links the provider account to the authenticated user.
+func (a *AuthController) LinkProviderHandler(c *gin.Context) {
+	// 1) Get the authenticated user and validate the provider
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	provider := c.Param("provider")
+	if !a.OAuth.Enabled(provider) {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid provider specified", errors.New("Invalid provider specified"))
+		return
+	}
+
+	// 2) Remember who is linking, the callback finds them by the OAuth state
+	state, err := a.createLinkState(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create link state", err)
+		return
+	}
+
+	// 3) Add provider and state to the request URL
+	q := c.Request.URL.Query()
+	q.Add("provider", provider)
+	q.Set("state", state)
+	c.Request.URL.RawQuery = q.Encode()
+
+	// 4) Begin OAuth flow
+	a.Logger.Info("Starting OAuth link flow", "provider", provider, "userID", contextUser.ID)
+	gothic.BeginAuthHandler(c.Writer, c.Request)
+}
+
+// linkProviderIdentity finishes a flow started by LinkProviderHandler, the user proved they own both accounts so the provider email does not need to match.
+func (a *AuthController) linkProviderIdentity(c *gin.Context, userID int, oauthUser goth.User) {
+	// 1) Load the user who started linking
+	user, err := a.UserDB.FindUserByID(userID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load linking user", err)
+		return
+	}
+
+	if a.rejectUnavailableAccount(c, user, models.AuthEventIdentityLinked) {
+		return
+	}
+
+	// 2) The provider account cannot sign in to anyone else
+	identity, err := a.UserDB.FindIdentity(oauthUser.Provider, oauthUser.UserID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database error during lookup", err)
+		return
+	}
+
+	if identity != nil && identity.UserID != user.ID {
+		a.recordAuthEvent(c, models.AuthEventIdentityLinked, models.AuthEventFailure, user, "", oauthUser.Provider+" account belongs to another user")
+		a.HandleError(c, http.StatusConflict, "This "+oauthUser.Provider+" account is linked to another user", "Identity linked to another user", errors.New("Identity linked to another user"))
+		return
+	}
+
+	// 3) Nothing to do if it is already linked to this user
+	if identity != nil {
+		c.Redirect(http.StatusFound, a.FrontendAddress+"/settings?linked="+url.QueryEscape(oauthUser.Provider))
+		return
+	}
+
+	// 4) Users hold one identity per provider
+	current, err := a.userIdentity(user.ID, oauthUser.Provider)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load identities", err)
+		return
+	}
+
+	if current != nil && current.ProviderUserID != nil {
+		a.recordAuthEvent(c, models.AuthEventIdentityLinked, models.AuthEventFailure, user, "", "Another "+oauthUser.Provider+" account is already linked")
+		a.HandleError(c, http.StatusConflict, "Unlink your current "+oauthUser.Provider+" account first", "Provider already linked", errors.New("Provider already linked"))
+		return
+	}
+
+	// 5) Link the identity
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.CreateIdentity(tx, &models.UserIdentity{UserID: user.ID, Provider: oauthUser.Provider, ProviderUserID: &oauthUser.UserID, Email: &oauthUser.Email}); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to link identity", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	// 6) Send the user back to their settings
+	a.recordAuthEvent(c, models.AuthEventIdentityLinked, models.AuthEventSuccess, user, "", oauthUser.Provider)
+	c.Redirect(http.StatusFound, a.FrontendAddress+"/settings?linked="+url.QueryEscape(oauthUser.Provider))
+}
+
+// UnlinkIdentityHandler removes one of the authenticated user's identities, the last one cannot be removed.
+func (a *AuthController) UnlinkIdentityHandler(c *gin.Context) {
+	// 1) Get the authenticated user and their identities
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	provider := c.Param("provider")
+	identities, err := a.UserDB.FindIdentitiesByUserID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load identities", err)
+		return
+	}
+
+	// 2) The identity must be linked and must not be the only way left to sign in
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+		}
+	}
+
+	if !linked {
+		a.HandleError(c, http.StatusNotFound, "Identity not found", "Identity not linked to user", errors.New("Identity not linked to user"))
+		return
+	}
+
+	if len(identities) == 1 {
+		a.HandleError(c, http.StatusConflict, "You cannot remove your only way to sign in", "Last identity", errors.New("Last identity"))
+		return
+	}
+
+	// 3) Unlink it, removing a password identity also removes the password
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if _, err = a.UserDB.DeleteIdentity(tx, contextUser.ID, provider); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to unlink identity", err)
+		return
+	}
+
+	if provider == models.IdentityProviderPassword {
+		user, findErr := a.UserDB.FindUserByID(contextUser.ID)
+		if findErr != nil || user == nil {
+			err = errors.New("failed to load user")
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load user", findErr)
+			return
+		}
+
+		user.PasswordHash = nil
+		if err = a.UserDB.UpdateUser(tx, user); err != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to remove password", err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventIdentityUnlinked, models.AuthEventSuccess, contextUser, "", provider)
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked"})
+}
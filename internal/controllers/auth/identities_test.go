@@ -0,0 +1,199 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/oauth"
+)
+
+func TestAuthController_Identities(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provider := "google"
+	subject := "google-subject"
+	user := &models.User{ID: 8, Email: "test@example.com", Provider: &provider, Verified: true, Status: models.AccountStatusActive}
+	identities := []models.UserIdentity{{ID: 1, UserID: user.ID, Provider: provider, ProviderUserID: &subject}}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByIDFunc:           func(id int) (*models.User, error) { return user, nil },
+		FindIdentitiesByUserIDFunc: func(userID int) ([]models.UserIdentity, error) { return identities, nil },
+		UpdateUserFunc: func(ext sqlx.Ext, updated *models.User) error {
+			user = updated
+			return nil
+		},
+		CreateIdentityFunc: func(ext sqlx.Ext, identity *models.UserIdentity) error {
+			identities = append(identities, *identity)
+			return nil
+		},
+		DeleteIdentityFunc: func(ext sqlx.Ext, userID int, provider string) (bool, error) {
+			for i, identity := range identities {
+				if identity.Provider == provider {
+					identities = append(identities[:i], identities[i+1:]...)
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+	authController.OAuth = newTestOAuthRegistry(t)
+
+	addPassword := func(password string) *httptest.ResponseRecorder {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/identities/password", map[string]string{"password": password})
+		return executeAuthenticatedHandler(authController.AddPasswordHandler, user, req)
+	}
+
+	unlink := func(provider string) *httptest.ResponseRecorder {
+		return executeUnlink(authController, user, provider)
+	}
+
+	// 1) A Google user cannot remove their only way to sign in
+	w := unlink("google")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Len(t, identities, 1)
+
+	// 2) They can add a password, which must be strong
+	w = addPassword("weak")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = addPassword("Password123!")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, user.PasswordHash)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte("Password123!")))
+	require.Len(t, identities, 2)
+	assert.Equal(t, models.IdentityProviderPassword, identities[1].Provider)
+
+	w = addPassword("Password123!")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 3) Both identities are listed
+	req, _ := http.NewRequest(http.MethodGet, "/api/auth/identities", nil)
+	w = executeAuthenticatedHandler(authController.ListIdentitiesHandler, user, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"provider":"google"`)
+	assert.Contains(t, w.Body.String(), `"provider":"password"`)
+	assert.NotContains(t, w.Body.String(), subject)
+
+	// 4) Now Google can be unlinked, but not twice
+	w = unlink("google")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, identities, 1)
+
+	w = unlink("google")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 5) Starting to link a provider remembers who is linking
+	w = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/auth/identities/gitlab/link", nil)
+	c.Params = gin.Params{{Key: "provider", Value: "gitlab"}}
+	c.Set("user", user)
+	authController.LinkProviderHandler(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/auth/identities/google/link", nil)
+	c.Params = gin.Params{{Key: "provider", Value: "google"}}
+	c.Set("user", user)
+	authController.LinkProviderHandler(c)
+
+	linking := 0
+	for key, value := range store {
+		if strings.HasPrefix(key, "oauth_link:") {
+			linking++
+			assert.Equal(t, "8", value)
+		}
+	}
+	assert.Equal(t, 1, linking)
+}
+
+// Unlinking the password removes the password hash so it can no longer be used to sign in.
+func TestAuthController_UnlinkPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hash := "hash"
+	subject := "google-subject"
+	user := &models.User{ID: 8, Email: "test@example.com", PasswordHash: &hash, Verified: true, Status: models.AccountStatusActive}
+	identities := []models.UserIdentity{
+		{ID: 1, UserID: user.ID, Provider: models.IdentityProviderPassword},
+		{ID: 2, UserID: user.ID, Provider: "google", ProviderUserID: &subject},
+	}
+
+	var updated *models.User
+	mockDB := &MockDB{
+		FindUserByIDFunc:           func(id int) (*models.User, error) { return user, nil },
+		FindIdentitiesByUserIDFunc: func(userID int) ([]models.UserIdentity, error) { return identities, nil },
+		DeleteIdentityFunc:         func(ext sqlx.Ext, userID int, provider string) (bool, error) { return true, nil },
+		UpdateUserFunc: func(ext sqlx.Ext, user *models.User) error {
+			updated = user
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	authController, err := createTestAuthController(mockDB, &MockRedisClient{}, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+
+	w := executeUnlink(authController, user, models.IdentityProviderPassword)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, updated)
+	assert.Nil(t, updated.PasswordHash)
+}
+
+// newTestOAuthRegistry returns a registry with Google and GitHub enabled.
+func newTestOAuthRegistry(t *testing.T) *oauth.Registry {
+	registry, err := oauth.NewRegistry(config.OAuthConfig{Providers: []config.OAuthProviderConfig{
+		{Name: "google", Type: oauth.TypeGoogle, ClientID: "id", ClientSecret: "secret", CallbackURL: "http://localhost:3000/api/auth/google/callback"},
+		{Name: "github", Type: oauth.TypeGitHub, ClientID: "id", ClientSecret: "secret", CallbackURL: "http://localhost:3000/api/auth/github/callback"},
+	}})
+	require.NoError(t, err)
+	return registry
+}
+
+func executeUnlink(authController *auth.AuthController, user *models.User, provider string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodDelete, "/api/auth/identities/"+provider, nil)
+	c.Params = gin.Params{{Key: "provider", Value: provider}}
+	c.Set("user", user)
+	authController.UnlinkIdentityHandler(c)
+	return w
+}
+
+func TestAuthController_Providers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authController, err := createTestAuthController(&MockDB{}, &MockRedisClient{}, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+	authController.OAuth = newTestOAuthRegistry(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/auth/providers", nil)
+	w := executeHandler(authController.ProvidersHandler, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"providers":[{"name":"google","displayName":"Google","type":"google"},{"name":"github","displayName":"GitHub","type":"github"}]}`, w.Body.String())
+
+	// Only enabled providers can be used to sign in
+	w = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/auth/gitlab", nil)
+	c.Params = gin.Params{{Key: "provider", Value: "gitlab"}}
+	authController.SignInWithProvider(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
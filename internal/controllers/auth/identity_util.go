@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// linkStateExpiry is how long a user has to finish linking a provider after starting
+const linkStateExpiry = 10 * time.Minute
+
+// createLinkState stores which user started linking a provider, the state is passed through the OAuth flow
+func (a *AuthController) createLinkState(userID int) (string, error) {
+	state := uuid.New().String()
+
+	key := fmt.Sprintf("oauth_link:%v", state)
+	if err := a.RedisCache.Set(context.Background(), key, userID, linkStateExpiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store link state: %w", err)
+	}
+
+	return state, nil
+}
+
+// consumeLinkState returns the user who started linking with this state, a state can only be used once
+func (a *AuthController) consumeLinkState(state string) (int, bool) {
+	if state == "" {
+		return 0, false
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("oauth_link:%v", state)
+	value, err := a.RedisCache.Get(ctx, key).Result()
+	if err != nil {
+		return 0, false
+	}
+	a.RedisCache.Del(ctx, key)
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+// userIdentity returns the user's identity for a provider, or nil if they have not linked one
+func (a *AuthController) userIdentity(userID int, provider string) (*models.UserIdentity, error) {
+	identities, err := a.UserDB.FindIdentitiesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range identities {
+		if identities[i].Provider == provider {
+			return &identities[i], nil
+		}
+	}
+	return nil, nil
+}
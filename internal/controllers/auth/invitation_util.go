@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// invitationExpiry is how long an emailed organization invitation stays valid
+const invitationExpiry = 7 * 24 * time.Hour
+
+func invitationKey(token string) string {
+	return fmt.Sprintf("org_invitation:%s", token)
+}
+
+// SendOrganizationInvitation stores a single use invitation token and emails the invitee the frontend link that accepts it
+func (a *AuthController) SendOrganizationInvitation(invitation models.OrganizationInvitation) error {
+	// 1) Store the invitation under a random token, like reset password links
+	token := uuid.New().String()
+
+	value, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to encode invitation: %w", err)
+	}
+
+	if err := a.RedisCache.Set(context.Background(), invitationKey(token), value, invitationExpiry).Err(); err != nil {
+		return fmt.Errorf("failed to store invitation token: %w", err)
+	}
+
+	// 2) Email the link
+	link := fmt.Sprintf("%s/accept-invitation?token=%s", a.FrontendAddress, url.QueryEscape(token))
+	body := fmt.Sprintf("You have been invited to join %s. Please click the link to accept the invitation: %s", invitation.OrganizationName, link)
+	if err := a.sendEmail(invitation.Email, "Organization Invitation", body); err != nil {
+		return fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return nil
+}
+
+// FindOrganizationInvitation returns the invitation for the token without using it up, or nil if it is unknown or expired
+func (a *AuthController) FindOrganizationInvitation(token string) (*models.OrganizationInvitation, error) {
+	value, err := a.RedisCache.Get(context.Background(), invitationKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find invitation token: %w", err)
+	}
+
+	var invitation models.OrganizationInvitation
+	if err := json.Unmarshal(value, &invitation); err != nil {
+		return nil, fmt.Errorf("failed to decode invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+// ConsumeOrganizationInvitation deletes the invitation token and reports whether this call removed it.
+// The token only counts as consumed by the request whose delete removed it, so it cannot be accepted twice concurrently.
+func (a *AuthController) ConsumeOrganizationInvitation(token string) (bool, error) {
+	deleted, err := a.RedisCache.Del(context.Background(), invitationKey(token)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete invitation token: %w", err)
+	}
+
+	return deleted == 1, nil
+}
+
+// IssueAccessToken sets a new access token cookie for the user, used when claims such as the active organization change
+func (a *AuthController) IssueAccessToken(c *gin.Context, user *models.User) error {
+	token, err := a.JWTGenerator.GenerateJWT(user)
+	if err != nil {
+		return fmt.Errorf("failed to generate JWT token: %w", err)
+	}
+
+	a.setAuthCookie(c, token)
+	return nil
+}
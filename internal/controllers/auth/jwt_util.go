@@ -7,14 +7,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 
 	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/signing"
 )
 
+// RoleLoader loads the roles and permissions that are embedded in access tokens
+type RoleLoader interface {
+	FindRolesByUserID(userID int) ([]string, error)
+	FindPermissionsByUserID(userID int) ([]string, error)
+}
+
 // JWTService implements JWTGenerator
 type JWTService struct {
-	SecretKey string
+	Keys      *signing.KeyManager
 	JwtExpiry string
+	Roles     RoleLoader
 }
 
 // GenerateJWT creates a JWT token for the authenticated user
@@ -24,22 +33,47 @@ func (j *JWTService) GenerateJWT(user *models.User) (string, error) {
 		return "", fmt.Errorf("failed to parse expiry time: %w", err)
 	}
 
-	expiryUnix := time.Now().Add(expiryTimeSeconds).Unix()
+	now := time.Now()
+	expiryUnix := now.Add(expiryTimeSeconds).Unix()
 
+	// iat_ms is the issue time in milliseconds, the per-user revocation cut off is compared with it
 	claims := jwt.MapClaims{
+		"jti":     uuid.New().String(),
 		"user_id": user.ID,
 		"email":   user.Email,
 		"exp":     expiryUnix,
-		"iat":     time.Now().Unix(),
+		"iat":     now.Unix(),
+		"iat_ms":  now.UnixMilli(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(j.SecretKey))
-	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+	// The organization the user works in, the middleware checks they are still a member on every request
+	if user.ActiveOrganizationID != nil {
+		claims["org_id"] = *user.ActiveOrganizationID
 	}
 
-	return signedToken, nil
+	// Roles are looked up on every issue so a refresh picks up grants and revokes
+	if j.Roles != nil {
+		roles, err := j.Roles.FindRolesByUserID(user.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to load roles: %w", err)
+		}
+
+		permissions, err := j.Roles.FindPermissionsByUserID(user.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to load permissions: %w", err)
+		}
+
+		claims["roles"] = roles
+		claims["permissions"] = permissions
+	}
+
+	// The key manager signs with the active key and sets the kid header
+	return j.Keys.Sign(claims)
+}
+
+// ParseJWT verifies the signature of a token issued by GenerateJWT and returns its claims
+func (j *JWTService) ParseJWT(tokenString string) (jwt.MapClaims, error) {
+	return j.Keys.Parse(tokenString)
 }
 
 // setAuthCookie sets the authentication token in a secure, HTTP-only cookie.
@@ -0,0 +1,100 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// executeVerifyOTPHandler calls the VerifyOTP handler with the given email and code.
+func executeVerifyOTPHandler(t *testing.T, handler gin.HandlerFunc, email string, otp string) *httptest.ResponseRecorder {
+	req, err := createTestRequest(http.MethodPost, "/api/auth/verify", map[string]string{"email": email, "otp": otp})
+	require.NoError(t, err)
+	return executeHandler(handler, req)
+}
+
+func TestAuthController_LoginLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+	os.Setenv("LOCKOUT_MAX_ATTEMPTS", "3")
+	os.Setenv("LOCKOUT_BASE_DELAY", "0s")
+	defer os.Unsetenv("LOCKOUT_MAX_ATTEMPTS")
+	defer os.Unsetenv("LOCKOUT_BASE_DELAY")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 4, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	var lockouts int
+	mockRedis, _ := newInMemoryRedis()
+	mockDB := &MockDB{FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil }}
+	mockLogger := &MockLogger{ErrorFunc: func(msg string, keysAndValues ...interface{}) {
+		if msg == "Locked out after repeated failed attempts" {
+			lockouts++
+		}
+	}}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, mockLogger, mockJWT)
+	require.NoError(t, err)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/login", map[string]string{"email": user.Email, "password": password})
+		return executeLoginHandler(authController, req)
+	}
+
+	// 1) A typo followed by the right password resets the count
+	assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	assert.Equal(t, http.StatusOK, login("password123").Code)
+
+	// 2) Reaching the threshold locks the account and logs the lockout
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	}
+	assert.Equal(t, 1, lockouts)
+
+	// 3) Even the right password is rejected until the lock expires
+	w := login("password123")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	assert.Empty(t, cookieValue(w, "auth_token"))
+}
+
+func TestAuthController_OTPInvalidatedAfterFailedAttempts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("LOCKOUT_OTP_MAX_ATTEMPTS", "3")
+	os.Setenv("LOCKOUT_BASE_DELAY", "0s")
+	defer os.Unsetenv("LOCKOUT_OTP_MAX_ATTEMPTS")
+	defer os.Unsetenv("LOCKOUT_BASE_DELAY")
+
+	email := "test@example.com"
+	mockRedis, store := newInMemoryRedis()
+	store[email] = "123456"
+
+	authController, err := createTestAuthController(&MockDB{}, mockRedis, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+
+	// 1) Wrong guesses are rejected until the limit is reached
+	for i := 0; i < 2; i++ {
+		w := executeVerifyOTPHandler(t, authController.VerifyOTPHandler, email, "000000")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// 2) The last allowed guess throws the code away
+	w := executeVerifyOTPHandler(t, authController.VerifyOTPHandler, email, "000000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.NotContains(t, store, email)
+
+	// 3) The correct code no longer works
+	w = executeVerifyOTPHandler(t, authController.VerifyOTPHandler, email, "123456")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
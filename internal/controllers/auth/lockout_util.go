@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/lockout"
+)
+
+var errTooManyAttempts = errors.New("too many failed attempts")
+
+// failedAttempt pairs a lockout key with the policy it is throttled by
+type failedAttempt struct {
+	key    string
+	policy lockout.Policy
+}
+
+// reservedAttempts are attempts counted against their keys before the credential is checked
+type reservedAttempts []lockout.Reservation
+
+// reserveAttempts counts an attempt against every key before the credential is checked, so concurrent guesses
+// cannot all get past the limit. It responds with 429 and reports false if any key is locked or out of attempts.
+// Redis errors fail open so an outage does not lock every user out.
+func (a *AuthController) reserveAttempts(c *gin.Context, attempts ...failedAttempt) (reservedAttempts, bool) {
+	var reserved reservedAttempts
+	var retryAfter time.Duration
+
+	for _, attempt := range attempts {
+		reservation, wait, err := a.Lockout.Reserve(context.Background(), attempt.key, attempt.policy)
+		if err != nil {
+			a.Logger.Error("Failed to check lockout, allowing attempt", "key", attempt.key, "error", err)
+			continue
+		}
+
+		if wait > 0 {
+			if wait > retryAfter {
+				retryAfter = wait
+			}
+			continue
+		}
+		reserved = append(reserved, reservation)
+	}
+
+	if retryAfter <= 0 {
+		return reserved, true
+	}
+
+	a.releaseAttempts(reserved)
+	a.respondTooManyAttempts(c, retryAfter)
+	return nil, false
+}
+
+// recordFailedAttempts counts the reserved attempts as failures. It reports whether any of their keys is now locked
+// out and the longest lockout started by this failure.
+func (a *AuthController) recordFailedAttempts(reserved reservedAttempts) (bool, time.Duration) {
+	lockedOut := false
+	var retryAfter time.Duration
+
+	for _, reservation := range reserved {
+		result, err := a.Lockout.RecordFailure(context.Background(), reservation)
+		if err != nil {
+			a.Logger.Error("Failed to record failed attempt", "key", reservation.Key, "error", err)
+			continue
+		}
+
+		if result.LockedOut {
+			lockedOut = true
+			if result.RetryAfter > retryAfter {
+				retryAfter = result.RetryAfter
+			}
+			a.Logger.Error("Locked out after repeated failed attempts", "key", reservation.Key, "failures", result.Failures, "retryAfter", result.RetryAfter.String())
+		}
+	}
+
+	return lockedOut, retryAfter
+}
+
+// releaseAttempts gives back reserved attempts that did not fail, such as ones that ended in a server error
+func (a *AuthController) releaseAttempts(reserved reservedAttempts) {
+	for _, reservation := range reserved {
+		if err := a.Lockout.Release(context.Background(), reservation); err != nil {
+			a.Logger.Error("Failed to release attempt", "key", reservation.Key, "error", err)
+		}
+	}
+}
+
+// resetFailedAttempts clears the key's failures after a successful attempt
+func (a *AuthController) resetFailedAttempts(key string) {
+	if err := a.Lockout.Reset(context.Background(), key); err != nil {
+		a.Logger.Error("Failed to reset failed attempts", "key", key, "error", err)
+	}
+}
+
+// respondTooManyAttempts sends a 429 telling the client how many seconds to wait
+func (a *AuthController) respondTooManyAttempts(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	a.HandleError(c, http.StatusTooManyRequests, "Too many failed attempts. Please try again later.", "Too many failed attempts", errTooManyAttempts)
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/lockout"
+	"github.com/jalil32/go-auth-module/internal/models"
 )
 
 func (a *AuthController) Login(c *gin.Context) {
@@ -35,39 +38,65 @@ func (a *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	// 3) Find the user by email
+	// 3) Count the attempt against the account and the client IP before checking it, rejecting it if either is locked out
+	accountKey := lockout.AccountKey("login", loginRequest.Email)
+	ipKey := lockout.IPKey("login", c.ClientIP())
+	reserved, ok := a.reserveAttempts(c, failedAttempt{key: accountKey, policy: a.Lockout.Account}, failedAttempt{key: ipKey, policy: a.Lockout.IP})
+	if !ok {
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, nil, loginRequest.Email, "Locked out")
+		return
+	}
+
+	// 4) Find the user by email
 	user, err := a.UserDB.FindUserByEmail(loginRequest.Email)
 	if err != nil {
+		a.releaseAttempts(reserved)
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
 		return
 	}
 
 	if user == nil {
-		// No user found with the given email
+		// No user found with the given email, counted like a wrong password so lockouts do not reveal which accounts exist
+		a.recordFailedAttempts(reserved)
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, nil, loginRequest.Email, "Unknown email")
 		a.HandleError(c, http.StatusUnauthorized, "Invalid email or password", "User not found", errors.New("User not found"))
 		return
 	}
 
-	// 4) Check if the user needs to authenticate via a provider
-	if user.PasswordHash == nil && user.Provider != nil {
+	// 5) Check if the user needs to authenticate via a provider, they can add a password from their settings
+	if user.PasswordHash == nil {
+		a.releaseAttempts(reserved)
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Account signs in with a provider")
 		a.HandleError(c, http.StatusUnauthorized, "Please sign in with a provider", "User needs to sign in with provider", errors.New("User needs to sign in with provider"))
 		return
 	}
 
-	// 5) Compare the provided password with the hashed password
+	// 6) Compare the provided password with the hashed password
 	if compareErr := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(loginRequest.Password)); compareErr != nil {
+		a.recordFailedAttempts(reserved)
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Invalid password")
 		a.HandleError(c, http.StatusUnauthorized, "Invalid email or password", "Invalid password", compareErr)
 		return
 	}
 
-	// 6) Handle unverified users
+	// 7) The password was right, so neither this attempt nor earlier typos count against the account
+	a.releaseAttempts(reserved)
+	a.resetFailedAttempts(accountKey)
+
+	// 8) Suspended and deleted accounts cannot sign in, accounts pending verification are sent an OTP below
+	if user.Status != models.AccountStatusPendingVerification && a.rejectUnavailableAccount(c, user, models.AuthEventLogin) {
+		return
+	}
+
+	// 9) Handle unverified users
 	if !user.Verified {
 		if otpErr := a.sendOTP(user.Email); otpErr != nil {
 			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to send OTP", otpErr)
 			return
 		}
 		// Not using normal error handling as this is a special case
-		a.Logger.Info("User not verified. OTP has been sent.", "email", user.Email, "userID", user.ID)
+		a.recordAuthEvent(c, models.AuthEventOTPSent, models.AuthEventSuccess, user, "", "Sign in before verification")
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Email not verified")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "User is not verified.",
 			"user": gin.H{
@@ -79,15 +108,39 @@ func (a *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	// 7) Generate and set JWT token
+	// 10) Users with an authenticator app must complete a second factor before a session is issued
+	if user.TOTPEnabled {
+		challengeToken, challengeErr := a.createMFAChallenge(user.ID)
+		if challengeErr != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create MFA challenge", challengeErr)
+			return
+		}
+
+		a.Logger.Info("Second factor required", "email", user.Email, "userID", user.ID)
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "Second factor required",
+			"mfaRequired":    true,
+			"challengeToken": challengeToken,
+		})
+		return
+	}
+
+	// 11) Generate and set JWT and refresh tokens
 	token, err := a.JWTGenerator.GenerateJWT(user)
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", err)
 		return
 	}
 
+	refreshToken, err := a.issueRefreshToken(user.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
 	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, refreshToken)
 
-	a.Logger.Info("User logged in successfully", "email", loginRequest.Email)
+	a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventSuccess, user, "", "Password")
 	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
 }
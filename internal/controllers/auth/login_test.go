@@ -33,7 +33,7 @@ func createTestAuthController(
 		return nil, err
 	}
 
-	return auth.NewAuthController(mockDB, mockRedis, mockLogger, mockJWTGenerator, cfg)
+	return auth.NewAuthController(mockDB, mockRedis, mockLogger, mockJWTGenerator, &MockAuditLogger{}, cfg)
 }
 
 // Helper function to create a test HTTP request.
@@ -178,6 +178,7 @@ func TestAuthController_Login(t *testing.T) {
 						Email:        email,
 						PasswordHash: &hashedPasswordStr,
 						Verified:     true,
+						Status:       models.AccountStatusActive,
 					}, nil
 				},
 			},
@@ -233,6 +234,7 @@ func TestAuthController_Login(t *testing.T) {
 						Email:        email,
 						PasswordHash: &hashedPasswordStr,
 						Verified:     true,
+						Status:       models.AccountStatusActive,
 					}, nil
 				},
 			},
@@ -4,10 +4,25 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
 )
 
 // Logout handles user logout.
 func (a *AuthController) Logout(c *gin.Context) {
+	// Revoke the access token so a copy of it stops working immediately
+	var user *models.User
+	if token, err := c.Cookie("auth_token"); err == nil && token != "" {
+		user = a.tokenUser(token)
+		a.revokeAccessToken(token)
+	}
+
+	// Revoke the refresh token family so the session cannot be renewed
+	if refreshToken, err := c.Cookie(refreshTokenCookie); err == nil && refreshToken != "" {
+		a.revokeRefreshToken(refreshToken)
+	}
+	a.clearRefreshCookie(c)
+
 	// Clear the authentication cookie
 	c.SetCookie(
 		"auth_token", // Name
@@ -19,6 +34,28 @@ func (a *AuthController) Logout(c *gin.Context) {
 		true,         // HttpOnly
 	)
 
-	a.Logger.Info("User logged out successfully")
+	a.recordAuthEvent(c, models.AuthEventLogout, models.AuthEventSuccess, user, "", "")
 	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
+
+// LogoutAllHandler revokes every session of the authenticated user, including the current one.
+func (a *AuthController) LogoutAllHandler(c *gin.Context) {
+	// 1) Get the user set by the auth middleware
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Reject every token issued before now
+	if err := a.revokeAllSessions(user.ID); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to revoke sessions", err)
+		return
+	}
+
+	// 3) Clear the cookies of the current session
+	a.clearRefreshCookie(c)
+	c.SetCookie("auth_token", "", -1, "/", "", true, true)
+
+	a.recordAuthEvent(c, models.AuthEventLogoutAll, models.AuthEventSuccess, user, "", "")
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// magicLinkSentMessage is returned whether or not the account exists so the endpoint cannot be used to enumerate users
+const magicLinkSentMessage = "If your account exists, we have sent you a sign in link."
+
+// MagicLinkHandler emails a single use sign in link to the user.
+func (a *AuthController) MagicLinkHandler(c *gin.Context) {
+	// 1) Unmarshal request
+	var request MagicLinkRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	// 2) Validate the request
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	// 3) Check if the user exists, unknown emails get the same response as known ones
+	user, err := a.UserDB.FindUserByEmail(request.Email)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.Logger.Info("Magic link requested for unknown email")
+		c.JSON(http.StatusOK, gin.H{"message": magicLinkSentMessage})
+		return
+	}
+
+	// 4) Generate the sign in link
+	link, err := a.generateMagicLink(user.Email)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate magic link", err)
+		return
+	}
+
+	// 5) Send the email in the background so response times do not reveal whether the account exists
+	go func(email string, userID int) {
+		if sendErr := a.sendMagicLink(email, link); sendErr != nil {
+			a.Logger.Error("Failed to send magic link", "userID", userID, "error", sendErr)
+		}
+	}(user.Email, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": magicLinkSentMessage})
+}
+
+// ConsumeMagicLinkHandler signs the user in with a token from a magic link email.
+func (a *AuthController) ConsumeMagicLinkHandler(c *gin.Context) {
+	// 1) Extract token from URL parameters
+	token := c.Query("token")
+	if token == "" {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Token is required", errors.New("Missing token"))
+		return
+	}
+
+	// 2) Consume the token, it can only be used once
+	email, err := a.consumeMagicLinkToken(token)
+	if err != nil {
+		if errors.Is(err, errMagicLinkInvalid) {
+			a.HandleError(c, http.StatusBadRequest, "Invalid or expired sign in link", "Magic link validation failed", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to consume magic link", err)
+		return
+	}
+
+	// 3) Get the user the link was issued for
+	user, err := a.UserDB.FindUserByEmail(email)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.HandleError(c, http.StatusBadRequest, "Invalid or expired sign in link", "User not found", errors.New("User not found"))
+		return
+	}
+
+	// 4) Suspended and deleted accounts cannot sign in, this is checked before the link changes anything about the
+	// account. Accounts waiting on verification are verified by the link below.
+	if user.Status != models.AccountStatusPendingVerification && a.rejectUnavailableAccount(c, user, models.AuthEventLogin) {
+		return
+	}
+
+	// 5) Opening the link proves the user owns the email, so unverified users become verified and active
+	if !user.Verified || user.Status == models.AccountStatusPendingVerification {
+		if err := a.markUserVerified(user); err != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update user", err)
+			return
+		}
+
+		a.Logger.Info("User email verified through magic link", "userID", user.ID)
+	}
+
+	// 6) The link replaces the password only, users with an authenticator app still need a second factor
+	if user.TOTPEnabled {
+		challengeToken, challengeErr := a.createMFAChallenge(user.ID)
+		if challengeErr != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create MFA challenge", challengeErr)
+			return
+		}
+
+		a.Logger.Info("Second factor required", "email", user.Email, "userID", user.ID)
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "Second factor required",
+			"mfaRequired":    true,
+			"challengeToken": challengeToken,
+		})
+		return
+	}
+
+	// 7) Generate and set JWT and refresh tokens
+	jwtToken, err := a.JWTGenerator.GenerateJWT(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", err)
+		return
+	}
+
+	refreshToken, err := a.issueRefreshToken(user.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	a.setAuthCookie(c, jwtToken)
+	a.setRefreshCookie(c, refreshToken)
+
+	a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventSuccess, user, "", "Magic link")
+	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+}
@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_MagicLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: 9, Email: "test@example.com", Verified: false, Status: models.AccountStatusPendingVerification}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) {
+			if email == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+		UpdateUserFunc: func(ext sqlx.Ext, updated *models.User) error {
+			user.Verified = updated.Verified
+			return nil
+		},
+		UpdateUserStatusFunc: func(ext sqlx.Ext, updated *models.User, status models.AccountStatus, reason string, changedBy *int) error {
+			updated.Status = status
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	require.NoError(t, err)
+
+	requestLink := func(body string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/magic-link", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := executeHandler(authController.MagicLinkHandler, req)
+		return w.Code, w.Body.String()
+	}
+
+	magicLinkTokens := func() []string {
+		var tokens []string
+		for key := range store {
+			if strings.HasPrefix(key, "magic_link:") {
+				tokens = append(tokens, strings.TrimPrefix(key, "magic_link:"))
+			}
+		}
+		return tokens
+	}
+
+	// 1) Invalid emails are rejected
+	code, _ := requestLink(`{"email": "not-an-email"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 2) Unknown and known emails get the same response, but only known ones get a link
+	unknownCode, unknownBody := requestLink(`{"email": "nobody@example.com"}`)
+	assert.Empty(t, magicLinkTokens())
+
+	knownCode, knownBody := requestLink(`{"email": "test@example.com"}`)
+	assert.Equal(t, unknownCode, knownCode)
+	assert.Equal(t, unknownBody, knownBody)
+
+	tokens := magicLinkTokens()
+	require.Len(t, tokens, 1)
+
+	// 3) Consuming the link verifies and activates the user and signs them in
+	req, _ := http.NewRequest(http.MethodGet, "/api/auth/magic-link/consume?token="+tokens[0], nil)
+	w := executeHandler(authController.ConsumeMagicLinkHandler, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "mock-token", cookieValue(w, "auth_token"))
+	assert.NotEmpty(t, cookieValue(w, "refresh_token"))
+	assert.True(t, user.Verified)
+	assert.Equal(t, models.AccountStatusActive, user.Status)
+
+	// 4) The link is single use
+	req, _ = http.NewRequest(http.MethodGet, "/api/auth/magic-link/consume?token="+tokens[0], nil)
+	w = executeHandler(authController.ConsumeMagicLinkHandler, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, cookieValue(w, "auth_token"))
+
+	// 5) A suspended account is not signed in, and its link click does not verify it
+	user.Verified, user.Status = false, models.AccountStatusSuspended
+	store["magic_link:suspended-token"] = user.Email
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/auth/magic-link/consume?token=suspended-token", nil)
+	w = executeHandler(authController.ConsumeMagicLinkHandler, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, cookieValue(w, "auth_token"))
+	assert.False(t, user.Verified)
+	assert.Equal(t, models.AccountStatusSuspended, user.Status)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// magicLinkExpiry is how long an emailed sign in link stays valid
+const magicLinkExpiry = 10 * time.Minute
+
+var errMagicLinkInvalid = errors.New("magic link is invalid or expired")
+
+func magicLinkKey(token string) string {
+	return fmt.Sprintf("magic_link:%s", token)
+}
+
+// generateMagicLink stores a single use sign in token for the email and returns the frontend link that consumes it
+func (a *AuthController) generateMagicLink(email string) (string, error) {
+	token := uuid.New().String()
+
+	if err := a.RedisCache.Set(context.Background(), magicLinkKey(token), email, magicLinkExpiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store magic link token: %w", err)
+	}
+
+	return fmt.Sprintf("%s/magic-link?token=%s", a.FrontendAddress, url.QueryEscape(token)), nil
+}
+
+// consumeMagicLinkToken returns the email the token was issued for and deletes it.
+// The token only counts as consumed by the request whose delete removed it, so it cannot be used twice concurrently.
+func (a *AuthController) consumeMagicLinkToken(token string) (string, error) {
+	ctx := context.Background()
+	key := magicLinkKey(token)
+
+	email, err := a.RedisCache.Get(ctx, key).Result()
+	if err != nil {
+		return "", errMagicLinkInvalid
+	}
+
+	deleted, err := a.RedisCache.Del(ctx, key).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to delete magic link token: %w", err)
+	}
+
+	if deleted == 0 {
+		return "", errMagicLinkInvalid
+	}
+
+	return email, nil
+}
+
+// markUserVerified sets the user's email as verified and activates the account if it was waiting on verification
+func (a *AuthController) markUserVerified(user *models.User) error {
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	if err := a.activateVerifiedUser(tx, user, "Email verified with a magic link"); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (a *AuthController) sendMagicLink(email string, link string) error {
+	body := fmt.Sprintf("Click the link to sign in, it expires in %d minutes: %s\n\nIf you did not request this, you can ignore this email.", int(magicLinkExpiry.Minutes()), link)
+	if err := a.sendEmail(email, "Your sign in link", body); err != nil {
+		return fmt.Errorf("failed to send magic link: %w", err)
+	}
+
+	return nil
+}
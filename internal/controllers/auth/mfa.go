@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/lockout"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/totp"
+)
+
+// EnrollTOTPHandler starts authenticator app enrollment by generating a secret for the user to scan.
+func (a *AuthController) EnrollTOTPHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	// 2) Users must disable their existing authenticator before enrolling a new one
+	if user.TOTPEnabled {
+		a.HandleError(c, http.StatusConflict, "Authenticator app is already enabled.", "TOTP already enabled", errors.New("TOTP already enabled"))
+		return
+	}
+
+	// 3) Generate and encrypt a new secret
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate TOTP secret", err)
+		return
+	}
+
+	encryptedSecret, err := a.encryptSecret(secret)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to encrypt TOTP secret", err)
+		return
+	}
+
+	// 4) Store the pending secret, it is not enforced until the user confirms a code
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateTOTP(tx, user.ID, &encryptedSecret, false); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to store TOTP secret", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.Logger.Info("TOTP enrollment started", "userID", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"otpauthUri": totp.URI(a.MFAIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTOTPHandler enables the authenticator app once the user proves they can generate codes.
+func (a *AuthController) ConfirmTOTPHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Bind and validate the request
+	var request TOTPCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user.TOTPEnabled {
+		a.HandleError(c, http.StatusConflict, "Authenticator app is already enabled.", "TOTP already enabled", errors.New("TOTP already enabled"))
+		return
+	}
+
+	if user.TOTPSecret == nil {
+		a.HandleError(c, http.StatusBadRequest, "Please start enrollment first.", "No pending TOTP secret", errors.New("No pending TOTP secret"))
+		return
+	}
+
+	// 3) Check the code against the pending secret
+	valid, err := a.verifyTOTPCode(user, request.Code)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to verify TOTP code", err)
+		return
+	}
+
+	if !valid {
+		a.HandleError(c, http.StatusUnauthorized, "Invalid authentication code", "Invalid TOTP code", nil)
+		return
+	}
+
+	// 4) Generate recovery codes
+	recoveryCodes, recoveryHashes, err := generateRecoveryCodes()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate recovery codes", err)
+		return
+	}
+
+	// 5) Enable TOTP and store the recovery codes together
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateTOTP(tx, user.ID, user.TOTPSecret, true); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to enable TOTP", err)
+		return
+	}
+
+	if err = a.UserDB.ReplaceRecoveryCodes(tx, user.ID, recoveryHashes); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to store recovery codes", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	// 6) Return the recovery codes, this is the only time they are shown
+	a.Logger.Info("TOTP enabled", "userID", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Authenticator app enabled",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// DisableTOTPHandler turns off the authenticator app after checking a current code or recovery code.
+func (a *AuthController) DisableTOTPHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Bind and validate the request
+	var request MFACodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if !user.TOTPEnabled {
+		a.HandleError(c, http.StatusBadRequest, "Authenticator app is not enabled.", "TOTP not enabled", errors.New("TOTP not enabled"))
+		return
+	}
+
+	// 3) Require a second factor so a hijacked session cannot silently remove it
+	valid, err := a.verifySecondFactor(user, request.Code, request.RecoveryCode)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to verify second factor", err)
+		return
+	}
+
+	if !valid {
+		a.HandleError(c, http.StatusUnauthorized, "Invalid authentication code", "Invalid second factor", nil)
+		return
+	}
+
+	// 4) Remove the secret and any remaining recovery codes
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateTOTP(tx, user.ID, nil, false); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to disable TOTP", err)
+		return
+	}
+
+	if err = a.UserDB.ReplaceRecoveryCodes(tx, user.ID, nil); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to delete recovery codes", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.Logger.Info("TOTP disabled", "userID", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Authenticator app disabled"})
+}
+
+// VerifyMFAHandler completes a sign in that was paused for a second factor and issues the session.
+func (a *AuthController) VerifyMFAHandler(c *gin.Context) {
+	// 1) Bind and validate the request
+	var request MFAVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	// 2) Look up the challenge issued by Login
+	challenge, err := a.getMFAChallenge(request.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, errMFAChallengeInvalid) {
+			a.HandleError(c, http.StatusUnauthorized, "Your sign in has expired. Please sign in again.", "Invalid MFA challenge", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to get MFA challenge", err)
+		return
+	}
+
+	// 3) Fetch the user
+	user, err := a.UserDB.FindUserByID(challenge.UserID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.HandleError(c, http.StatusUnauthorized, "Your sign in has expired. Please sign in again.", "User not found", errors.New("User not found"))
+		return
+	}
+
+	// The account may have been suspended since the challenge was issued
+	if a.rejectUnavailableAccount(c, user, models.AuthEventLogin) {
+		return
+	}
+
+	// 4) Wrong codes lock the account's second factor. A new challenge from a correct password does not reset it,
+	// so knowing the password does not give unlimited guesses
+	mfaKey := lockout.AccountKey("mfa", user.Email)
+	reserved, ok := a.reserveAttempts(c, failedAttempt{key: mfaKey, policy: a.Lockout.OTP})
+	if !ok {
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Second factor locked out")
+		return
+	}
+
+	// 5) Each challenge allows a few attempts, counted before the code is checked
+	allowed, err := a.claimMFAAttempt(request.ChallengeToken, challenge)
+	if err != nil {
+		a.releaseAttempts(reserved)
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to count MFA attempt", err)
+		return
+	}
+
+	if !allowed {
+		a.releaseAttempts(reserved)
+		a.HandleError(c, http.StatusUnauthorized, "Your sign in has expired. Please sign in again.", "MFA challenge attempts used up", errMFAChallengeInvalid)
+		return
+	}
+
+	// 6) Check the TOTP or recovery code
+	valid, err := a.verifySecondFactor(user, request.Code, request.RecoveryCode)
+	if err != nil {
+		a.releaseAttempts(reserved)
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to verify second factor", err)
+		return
+	}
+
+	if !valid {
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Invalid second factor")
+		if lockedOut, retryAfter := a.recordFailedAttempts(reserved); lockedOut {
+			a.RedisCache.Del(c.Request.Context(), mfaChallengeKey(request.ChallengeToken))
+			a.respondTooManyAttempts(c, retryAfter)
+			return
+		}
+		a.HandleError(c, http.StatusUnauthorized, "Invalid authentication code", "Invalid second factor", nil)
+		return
+	}
+
+	a.releaseAttempts(reserved)
+	a.resetFailedAttempts(mfaKey)
+
+	// 7) The challenge is single use
+	a.RedisCache.Del(c.Request.Context(), mfaChallengeKey(request.ChallengeToken), mfaChallengeAttemptsKey(request.ChallengeToken))
+
+	// 8) Generate and set JWT and refresh tokens
+	token, err := a.JWTGenerator.GenerateJWT(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", err)
+		return
+	}
+
+	refreshToken, err := a.issueRefreshToken(user.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, refreshToken)
+
+	a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventSuccess, user, "", "Second factor")
+	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+}
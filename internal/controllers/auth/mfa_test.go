@@ -0,0 +1,258 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/totp"
+)
+
+// executeAuthenticatedHandler runs a handler as if the auth middleware had set the user.
+func executeAuthenticatedHandler(handler gin.HandlerFunc, user *models.User, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	if user != nil {
+		c.Set("user", user)
+	}
+
+	handler(c)
+	return w
+}
+
+// executeHandler runs an unauthenticated handler.
+func executeHandler(handler gin.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	return executeAuthenticatedHandler(handler, nil, req)
+}
+
+// newSQLMockBeginx returns a Beginx func backed by sqlmock that expects every transaction to commit.
+func newSQLMockBeginx(t *testing.T) func() (*sqlx.Tx, error) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockSQL.Close() })
+
+	database := sqlx.NewDb(mockSQL, "sqlmock")
+	return func() (*sqlx.Tx, error) {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		return database.Beginx()
+	}
+}
+
+func TestAuthController_TOTPEnrollmentAndLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+	os.Setenv("MFA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	os.Setenv("LOCKOUT_BASE_DELAY", "0s")
+	defer os.Unsetenv("MFA_ENCRYPTION_KEY")
+	defer os.Unsetenv("LOCKOUT_BASE_DELAY")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 3, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+	storedRecoveryHashes := map[string]bool{}
+
+	mockRedis, _ := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc:    func(id int) (*models.User, error) { return user, nil },
+		UpdateTOTPFunc: func(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error {
+			user.TOTPSecret = encryptedSecret
+			user.TOTPEnabled = enabled
+			return nil
+		},
+		ReplaceRecoveryCodesFunc: func(ext sqlx.Ext, userID int, codeHashes []string) error {
+			for _, hash := range codeHashes {
+				storedRecoveryHashes[hash] = true
+			}
+			return nil
+		},
+		ConsumeRecoveryCodeFunc: func(ext sqlx.Ext, userID int, codeHash string) (bool, error) {
+			if storedRecoveryHashes[codeHash] {
+				delete(storedRecoveryHashes, codeHash)
+				return true, nil
+			}
+			return false, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	require.NoError(t, err)
+
+	// 1) Enrollment returns a secret and otpauth URI, the secret is stored encrypted
+	req, _ := createTestRequest(http.MethodPost, "/api/auth/mfa/totp/enroll", nil)
+	w := executeAuthenticatedHandler(authController.EnrollTOTPHandler, user, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var enrollResponse struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauthUri"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollResponse))
+	assert.Contains(t, enrollResponse.OtpauthURI, "otpauth://totp/")
+	require.NotNil(t, user.TOTPSecret)
+	assert.NotContains(t, *user.TOTPSecret, enrollResponse.Secret)
+	assert.False(t, user.TOTPEnabled)
+
+	// 2) Confirming with a wrong code fails
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/totp/confirm", map[string]string{"code": "000000"})
+	w = executeAuthenticatedHandler(authController.ConfirmTOTPHandler, user, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 3) Confirming with the current code enables TOTP and returns recovery codes
+	code, err := totp.Code(enrollResponse.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/totp/confirm", map[string]string{"code": code})
+	w = executeAuthenticatedHandler(authController.ConfirmTOTPHandler, user, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var confirmResponse struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirmResponse))
+	assert.Len(t, confirmResponse.RecoveryCodes, 10)
+	assert.True(t, user.TOTPEnabled)
+
+	// 4) Login now answers with a challenge instead of a session
+	req, _ = createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+	w = executeLoginHandler(authController, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Empty(t, cookieValue(w, "auth_token"))
+
+	var loginResponse struct {
+		MFARequired    bool   `json:"mfaRequired"`
+		ChallengeToken string `json:"challengeToken"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResponse))
+	assert.True(t, loginResponse.MFARequired)
+	require.NotEmpty(t, loginResponse.ChallengeToken)
+
+	// 5) The TOTP code used to confirm enrollment cannot be replayed
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/verify", map[string]string{"challengeToken": loginResponse.ChallengeToken, "code": code})
+	w = executeHandler(authController.VerifyMFAHandler, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// An older code that is still within the allowed clock drift cannot be used after a newer one either
+	previous, err := totp.Code(enrollResponse.Secret, totp.Step(time.Now())-1)
+	require.NoError(t, err)
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/verify", map[string]string{"challengeToken": loginResponse.ChallengeToken, "code": previous})
+	w = executeHandler(authController.VerifyMFAHandler, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 6) A recovery code completes the sign in
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/verify", map[string]string{"challengeToken": loginResponse.ChallengeToken, "recoveryCode": confirmResponse.RecoveryCodes[0]})
+	w = executeHandler(authController.VerifyMFAHandler, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "mock-token", cookieValue(w, "auth_token"))
+
+	// 7) The challenge and the recovery code are single use
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/verify", map[string]string{"challengeToken": loginResponse.ChallengeToken, "recoveryCode": confirmResponse.RecoveryCodes[0]})
+	w = executeHandler(authController.VerifyMFAHandler, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthController_MFALockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+	os.Setenv("MFA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	os.Setenv("LOCKOUT_OTP_MAX_ATTEMPTS", "7")
+	os.Setenv("LOCKOUT_BASE_DELAY", "0s")
+	defer os.Unsetenv("MFA_ENCRYPTION_KEY")
+	defer os.Unsetenv("LOCKOUT_OTP_MAX_ATTEMPTS")
+	defer os.Unsetenv("LOCKOUT_BASE_DELAY")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 3, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc:    func(id int) (*models.User, error) { return user, nil },
+		UpdateTOTPFunc: func(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error {
+			user.TOTPSecret = encryptedSecret
+			user.TOTPEnabled = enabled
+			return nil
+		},
+		ReplaceRecoveryCodesFunc: func(ext sqlx.Ext, userID int, codeHashes []string) error { return nil },
+		ConsumeRecoveryCodeFunc:  func(ext sqlx.Ext, userID int, codeHash string) (bool, error) { return false, nil },
+		BeginxFunc:               newSQLMockBeginx(t),
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	require.NoError(t, err)
+
+	// Enroll an authenticator app
+	req, _ := createTestRequest(http.MethodPost, "/api/auth/mfa/totp/enroll", nil)
+	w := executeAuthenticatedHandler(authController.EnrollTOTPHandler, user, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var enrollResponse struct {
+		Secret string `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollResponse))
+	code, err := totp.Code(enrollResponse.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	req, _ = createTestRequest(http.MethodPost, "/api/auth/mfa/totp/confirm", map[string]string{"code": code})
+	require.Equal(t, http.StatusOK, executeAuthenticatedHandler(authController.ConfirmTOTPHandler, user, req).Code)
+	for key := range store {
+		if strings.HasPrefix(key, "totp_step:") {
+			delete(store, key)
+		}
+	}
+
+	login := func() string {
+		req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+		w := executeLoginHandler(authController, req)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		var response struct {
+			ChallengeToken string `json:"challengeToken"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.ChallengeToken
+	}
+
+	verify := func(challengeToken string, code string) int {
+		req, _ := createTestRequest(http.MethodPost, "/api/auth/mfa/verify", map[string]string{"challengeToken": challengeToken, "code": code})
+		return executeHandler(authController.VerifyMFAHandler, req).Code
+	}
+
+	// 1) A challenge allows five attempts, counted before the code is checked, then even the right code is refused
+	challengeToken := login()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusUnauthorized, verify(challengeToken, "000000"))
+	}
+	assert.Equal(t, store["mfa_challenge_attempts:"+challengeToken], "5")
+
+	code, err = totp.Code(enrollResponse.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, verify(challengeToken, code))
+	assert.NotContains(t, store, "mfa_challenge:"+challengeToken)
+
+	// 2) Signing in with the password again does not reset the account's failed codes, the seventh locks it
+	challengeToken = login()
+	assert.Equal(t, http.StatusUnauthorized, verify(challengeToken, "000000"))
+	assert.Equal(t, http.StatusTooManyRequests, verify(challengeToken, "000000"))
+
+	// 3) While the account is locked the right code is refused after a fresh password sign in
+	assert.Equal(t, http.StatusTooManyRequests, verify(login(), code))
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/totp"
+)
+
+const (
+	// mfaChallengeExpiry is how long a user has to enter their second factor after a password check
+	mfaChallengeExpiry = 5 * time.Minute
+	// mfaChallengeMaxAttempts is the number of wrong codes allowed before the challenge is discarded
+	mfaChallengeMaxAttempts = 5
+	// recoveryCodeCount is the number of recovery codes issued on enrollment
+	recoveryCodeCount = 10
+)
+
+var (
+	errMFANotConfigured     = errors.New("MFA encryption key is not configured")
+	errMFAChallengeInvalid  = errors.New("MFA challenge is invalid or expired")
+	errSecondFactorRequired = errors.New("A TOTP code or recovery code is required")
+)
+
+// mfaChallenge is stored in redis while a user who passed the first factor completes the second one
+type mfaChallenge struct {
+	UserID int `json:"userId"`
+}
+
+func mfaChallengeKey(token string) string {
+	return fmt.Sprintf("mfa_challenge:%s", token)
+}
+
+func mfaChallengeAttemptsKey(token string) string {
+	return fmt.Sprintf("mfa_challenge_attempts:%s", token)
+}
+
+// encryptSecret encrypts a TOTP secret with AES-256-GCM so it is not stored in plaintext
+func (a *AuthController) encryptSecret(plaintext string) (string, error) {
+	if a.MFAKey == nil {
+		return "", errMFANotConfigured
+	}
+
+	block, err := aes.NewCipher(a.MFAKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The nonce is prepended to the ciphertext so it is available for decryption
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptSecret reverses encryptSecret
+func (a *AuthController) decryptSecret(encoded string) (string, error) {
+	if a.MFAKey == nil {
+		return "", errMFANotConfigured
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	block, err := aes.NewCipher(a.MFAKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// generateRecoveryCodes returns the plaintext codes to show the user once and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 6)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(raw)) // 10 characters
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalises a recovery code so dashes, spaces and case do not matter, then hashes it
+func hashRecoveryCode(code string) string {
+	normalised := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(hash[:])
+}
+
+// verifyTOTPCode checks a code against the user's secret and rejects codes that have already been used
+func (a *AuthController) verifyTOTPCode(user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	// 1) Decrypt the secret
+	secret, err := a.decryptSecret(*user.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+
+	// 2) Check the code
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// 3) Reject replays by claiming the step with SET NX, which is atomic so a code is accepted at most once even when
+	// it is sent twice at the same time. The earlier steps still in the window are claimed too, so an older code
+	// cannot be used after a newer one.
+	ctx := context.Background()
+	window := time.Duration(2*totp.Skew+1) * totp.Period
+
+	err = a.RedisCache.SetArgs(ctx, totpStepKey(user.ID, step), 1, redis.SetArgs{Mode: "NX", TTL: window}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim totp step: %w", err)
+	}
+
+	for earlier := step - 2*totp.Skew; earlier < step; earlier++ {
+		if err := a.RedisCache.Set(ctx, totpStepKey(user.ID, earlier), 1, window).Err(); err != nil {
+			return false, fmt.Errorf("failed to claim totp step: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+func totpStepKey(userID int, step int64) string {
+	return fmt.Sprintf("totp_step:%d:%d", userID, step)
+}
+
+// verifySecondFactor accepts either a TOTP code or a single use recovery code
+func (a *AuthController) verifySecondFactor(user *models.User, code string, recoveryCode string) (bool, error) {
+	if code != "" {
+		return a.verifyTOTPCode(user, code)
+	}
+
+	if recoveryCode == "" {
+		return false, errSecondFactorRequired
+	}
+
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	consumed, err := a.UserDB.ConsumeRecoveryCode(tx, user.ID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if consumed {
+		a.Logger.Info("Recovery code used", "userID", user.ID)
+	}
+	return consumed, nil
+}
+
+// createMFAChallenge stores a short lived challenge that VerifyMFAHandler exchanges for a session
+func (a *AuthController) createMFAChallenge(userID int) (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	record, err := json.Marshal(mfaChallenge{UserID: userID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal challenge: %w", err)
+	}
+
+	if err := a.RedisCache.Set(context.Background(), mfaChallengeKey(token), record, mfaChallengeExpiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store challenge: %w", err)
+	}
+
+	return token, nil
+}
+
+// getMFAChallenge returns the challenge for the token or errMFAChallengeInvalid
+func (a *AuthController) getMFAChallenge(token string) (*mfaChallenge, error) {
+	value, err := a.RedisCache.Get(context.Background(), mfaChallengeKey(token)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errMFAChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	var challenge mfaChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal challenge: %w", err)
+	}
+
+	return &challenge, nil
+}
+
+// claimMFAAttempt counts an attempt against the challenge before the code is checked, so parallel guesses cannot get
+// past the limit. It reports false and discards the challenge once the limit has been used up.
+func (a *AuthController) claimMFAAttempt(token string, challenge *mfaChallenge) (bool, error) {
+	ctx := context.Background()
+
+	// INCR is atomic, every request gets its own count. The expiry is set on every attempt, so a failed EXPIRE
+	// cannot leave a counter behind that never expires
+	attempts, err := a.RedisCache.Incr(ctx, mfaChallengeAttemptsKey(token)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to count challenge attempt: %w", err)
+	}
+
+	if err := a.RedisCache.Expire(ctx, mfaChallengeAttemptsKey(token), mfaChallengeExpiry).Err(); err != nil {
+		return false, fmt.Errorf("failed to set challenge attempts expiry: %w", err)
+	}
+
+	if attempts > mfaChallengeMaxAttempts {
+		a.RedisCache.Del(ctx, mfaChallengeKey(token))
+		a.Logger.Info("MFA challenge discarded after too many attempts", "userID", challenge.UserID)
+		return false, nil
+	}
+
+	return true, nil
+}
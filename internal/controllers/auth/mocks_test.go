@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 
@@ -13,9 +14,13 @@ import (
 
 // MockRedisClient is a mock implementation of the RedisClient interface.
 type MockRedisClient struct {
-	GetFunc func(ctx context.Context, key string) *redis.StringCmd
-	SetFunc func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
-	DelFunc func(ctx context.Context, keys ...string) *redis.IntCmd
+	GetFunc     func(ctx context.Context, key string) *redis.StringCmd
+	SetFunc     func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetArgsFunc func(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd
+	DelFunc     func(ctx context.Context, keys ...string) *redis.IntCmd
+	IncrFunc    func(ctx context.Context, key string) *redis.IntCmd
+	ExpireFunc  func(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	EvalFunc    func(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
 }
 
 func (m *MockRedisClient) Get(ctx context.Context, key string) *redis.StringCmd {
@@ -32,6 +37,13 @@ func (m *MockRedisClient) Set(ctx context.Context, key string, value interface{}
 	return redis.NewStatusResult("", errors.New("not implemented"))
 }
 
+func (m *MockRedisClient) SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd {
+	if m.SetArgsFunc != nil {
+		return m.SetArgsFunc(ctx, key, value, a)
+	}
+	return redis.NewStatusResult("", errors.New("not implemented"))
+}
+
 func (m *MockRedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
 	if m.DelFunc != nil {
 		return m.DelFunc(ctx, keys...)
@@ -39,18 +51,62 @@ func (m *MockRedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd
 	return redis.NewIntResult(0, errors.New("not implemented"))
 }
 
+func (m *MockRedisClient) Incr(ctx context.Context, key string) *redis.IntCmd {
+	if m.IncrFunc != nil {
+		return m.IncrFunc(ctx, key)
+	}
+	return redis.NewIntResult(0, errors.New("not implemented"))
+}
+
+func (m *MockRedisClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	if m.ExpireFunc != nil {
+		return m.ExpireFunc(ctx, key, expiration)
+	}
+	return redis.NewBoolResult(false, errors.New("not implemented"))
+}
+
+func (m *MockRedisClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	if m.EvalFunc != nil {
+		return m.EvalFunc(ctx, script, keys, args...)
+	}
+	return redis.NewCmdResult(nil, errors.New("not implemented"))
+}
+
 // MockDB is a mock implementation of the UserRepository interface.
 type MockDB struct {
-	FindUserByEmailFunc func(email string) (*models.User, error)
-	CreateUserFunc      func(ext sqlx.Ext, user *models.User) error
-	UpdateUserFunc      func(ext sqlx.Ext, user *models.User) error
-	BeginxFunc          func() (*sqlx.Tx, error)
+	FindUserByEmailFunc                  func(email string) (*models.User, error)
+	FindUserByIDFunc                     func(id int) (*models.User, error)
+	CreateUserFunc                       func(ext sqlx.Ext, user *models.User) error
+	UpdateUserFunc                       func(ext sqlx.Ext, user *models.User) error
+	UpdateUserStatusFunc                 func(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error
+	UpdateTOTPFunc                       func(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error
+	ReplaceRecoveryCodesFunc             func(ext sqlx.Ext, userID int, codeHashes []string) error
+	ConsumeRecoveryCodeFunc              func(ext sqlx.Ext, userID int, codeHash string) (bool, error)
+	FindWebAuthnCredentialsByUserIDFunc  func(userID int) ([]models.WebAuthnCredential, error)
+	CreateWebAuthnCredentialFunc         func(ext sqlx.Ext, credential *models.WebAuthnCredential) error
+	UpdateWebAuthnCredentialUsageFunc    func(ext sqlx.Ext, credential *models.WebAuthnCredential) error
+	DeleteWebAuthnCredentialFunc         func(ext sqlx.Ext, userID int, id int) (bool, error)
+	FindAuthEventsFunc                   func(filter models.AuthEventFilter) ([]models.AuthEvent, int, error)
+	FindIdentityFunc                     func(provider string, providerUserID string) (*models.UserIdentity, error)
+	FindIdentitiesByUserIDFunc           func(userID int) ([]models.UserIdentity, error)
+	CreateIdentityFunc                   func(ext sqlx.Ext, identity *models.UserIdentity) error
+	TouchIdentityFunc                    func(ext sqlx.Ext, id int) error
+	UpdateIdentityEmailFunc              func(ext sqlx.Ext, userID int, provider string, email string) error
+	DeleteIdentityFunc                   func(ext sqlx.Ext, userID int, provider string) (bool, error)
+	FindPersonalAccessTokensByUserIDFunc func(userID int) ([]models.PersonalAccessToken, error)
+	CreatePersonalAccessTokenFunc        func(ext sqlx.Ext, token *models.PersonalAccessToken) error
+	DeletePersonalAccessTokenFunc        func(ext sqlx.Ext, userID int, id int) (bool, error)
+	BeginxFunc                           func() (*sqlx.Tx, error)
 }
 
 func (m *MockDB) FindUserByEmail(email string) (*models.User, error) {
 	return m.FindUserByEmailFunc(email)
 }
 
+func (m *MockDB) FindUserByID(id int) (*models.User, error) {
+	return m.FindUserByIDFunc(id)
+}
+
 func (m *MockDB) CreateUser(ext sqlx.Ext, user *models.User) error {
 	return m.CreateUserFunc(ext, user)
 }
@@ -59,13 +115,89 @@ func (m *MockDB) UpdateUser(ext sqlx.Ext, user *models.User) error {
 	return m.UpdateUserFunc(ext, user)
 }
 
+func (m *MockDB) UpdateUserStatus(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error {
+	return m.UpdateUserStatusFunc(ext, user, status, reason, changedBy)
+}
+
+func (m *MockDB) UpdateTOTP(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error {
+	return m.UpdateTOTPFunc(ext, userID, encryptedSecret, enabled)
+}
+
+func (m *MockDB) ReplaceRecoveryCodes(ext sqlx.Ext, userID int, codeHashes []string) error {
+	return m.ReplaceRecoveryCodesFunc(ext, userID, codeHashes)
+}
+
+func (m *MockDB) ConsumeRecoveryCode(ext sqlx.Ext, userID int, codeHash string) (bool, error) {
+	return m.ConsumeRecoveryCodeFunc(ext, userID, codeHash)
+}
+
+func (m *MockDB) FindWebAuthnCredentialsByUserID(userID int) ([]models.WebAuthnCredential, error) {
+	return m.FindWebAuthnCredentialsByUserIDFunc(userID)
+}
+
+func (m *MockDB) CreateWebAuthnCredential(ext sqlx.Ext, credential *models.WebAuthnCredential) error {
+	return m.CreateWebAuthnCredentialFunc(ext, credential)
+}
+
+func (m *MockDB) UpdateWebAuthnCredentialUsage(ext sqlx.Ext, credential *models.WebAuthnCredential) error {
+	return m.UpdateWebAuthnCredentialUsageFunc(ext, credential)
+}
+
+func (m *MockDB) DeleteWebAuthnCredential(ext sqlx.Ext, userID int, id int) (bool, error) {
+	return m.DeleteWebAuthnCredentialFunc(ext, userID, id)
+}
+
+func (m *MockDB) FindAuthEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+	return m.FindAuthEventsFunc(filter)
+}
+
+func (m *MockDB) FindIdentity(provider string, providerUserID string) (*models.UserIdentity, error) {
+	return m.FindIdentityFunc(provider, providerUserID)
+}
+
+func (m *MockDB) FindIdentitiesByUserID(userID int) ([]models.UserIdentity, error) {
+	return m.FindIdentitiesByUserIDFunc(userID)
+}
+
+func (m *MockDB) CreateIdentity(ext sqlx.Ext, identity *models.UserIdentity) error {
+	return m.CreateIdentityFunc(ext, identity)
+}
+
+func (m *MockDB) TouchIdentity(ext sqlx.Ext, id int) error {
+	return m.TouchIdentityFunc(ext, id)
+}
+
+func (m *MockDB) UpdateIdentityEmail(ext sqlx.Ext, userID int, provider string, email string) error {
+	return m.UpdateIdentityEmailFunc(ext, userID, provider, email)
+}
+
+func (m *MockDB) DeleteIdentity(ext sqlx.Ext, userID int, provider string) (bool, error) {
+	return m.DeleteIdentityFunc(ext, userID, provider)
+}
+
+func (m *MockDB) FindPersonalAccessTokensByUserID(userID int) ([]models.PersonalAccessToken, error) {
+	return m.FindPersonalAccessTokensByUserIDFunc(userID)
+}
+
+func (m *MockDB) CreatePersonalAccessToken(ext sqlx.Ext, token *models.PersonalAccessToken) error {
+	return m.CreatePersonalAccessTokenFunc(ext, token)
+}
+
+func (m *MockDB) DeletePersonalAccessToken(ext sqlx.Ext, userID int, id int) (bool, error) {
+	return m.DeletePersonalAccessTokenFunc(ext, userID, id)
+}
+
 func (m *MockDB) Beginx() (*sqlx.Tx, error) {
+	if m.BeginxFunc != nil {
+		return m.BeginxFunc()
+	}
 	return nil, nil
 }
 
 // MockJWTGenerator is a mock implementation of the JWTGenerator interface.
 type MockJWTGenerator struct {
 	GenerateJWTFunc func(user *models.User) (string, error)
+	ParseJWTFunc    func(tokenString string) (jwt.MapClaims, error)
 }
 
 func (m *MockJWTGenerator) GenerateJWT(user *models.User) (string, error) {
@@ -75,6 +207,13 @@ func (m *MockJWTGenerator) GenerateJWT(user *models.User) (string, error) {
 	return "", nil
 }
 
+func (m *MockJWTGenerator) ParseJWT(tokenString string) (jwt.MapClaims, error) {
+	if m.ParseJWTFunc != nil {
+		return m.ParseJWTFunc(tokenString)
+	}
+	return nil, errors.New("not implemented")
+}
+
 // MockLogger is a mock implementation of the Logger interface.
 type MockLogger struct {
 	ErrorFunc func(msg string, keysAndValues ...interface{})
@@ -92,3 +231,12 @@ func (m *MockLogger) Info(msg string, keysAndValues ...interface{}) {
 		m.InfoFunc(msg, keysAndValues...)
 	}
 }
+
+// MockAuditLogger is a mock implementation of the AuditLogger interface that keeps every event.
+type MockAuditLogger struct {
+	Events []models.AuthEvent
+}
+
+func (m *MockAuditLogger) Record(event models.AuthEvent) {
+	m.Events = append(m.Events, event)
+}
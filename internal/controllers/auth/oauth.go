@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/markbates/goth/gothic"
@@ -10,6 +11,11 @@ import (
 	"github.com/jalil32/go-auth-module/internal/models"
 )
 
+// ProvidersHandler lists the enabled sign in providers so the frontend can draw its buttons
+func (a *AuthController) ProvidersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": a.OAuth.Providers()})
+}
+
 // SignInWithProvider handles third-party sign-in using a provider (e.g., Google)
 func (a *AuthController) SignInWithProvider(c *gin.Context) {
 	// 1) Check that provider exists
@@ -20,11 +26,7 @@ func (a *AuthController) SignInWithProvider(c *gin.Context) {
 	}
 
 	// 2) Validate provider
-	allowedProviders := map[string]bool{
-		"google": true,
-	}
-
-	if !allowedProviders[provider] {
+	if !a.OAuth.Enabled(provider) {
 		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid provider specified", errors.New("Invalid provider specified"))
 		return
 	}
@@ -54,18 +56,65 @@ func (a *AuthController) CallbackHandler(c *gin.Context) {
 
 	oauthUser, err := gothic.CompleteUserAuth(c.Writer, c.Request)
 	if err != nil {
+		a.recordAuthEvent(c, models.AuthEventOAuthLogin, models.AuthEventFailure, nil, "", "Could not complete "+provider+" sign in")
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "OAuth complete error", err)
 		return
 	}
 
-	// 3) Check if the user exists in the database
-	existingUser, err := a.UserDB.FindUserByEmail(oauthUser.Email)
+	// 3) Flows started by a signed in user link the provider account instead of signing in
+	if linkUserID, ok := a.consumeLinkState(c.Query("state")); ok {
+		a.linkProviderIdentity(c, linkUserID, oauthUser)
+		return
+	}
+
+	// 4) Find the user the provider account is linked to, falling back to the account with the same email
+	identity, err := a.UserDB.FindIdentity(oauthUser.Provider, oauthUser.UserID)
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database error during lookup", err)
 		return
 	}
 
-	// 4) Start transaction
+	var existingUser *models.User
+	if identity != nil {
+		existingUser, err = a.UserDB.FindUserByID(identity.UserID)
+	} else {
+		existingUser, err = a.UserDB.FindUserByEmail(oauthUser.Email)
+	}
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database error during lookup", err)
+		return
+	}
+
+	// 5) Accounts are only linked or created automatically when the provider has verified the email,
+	// otherwise anyone could take over an account by registering its email with the provider
+	emailVerified := a.OAuth.EmailVerified(oauthUser)
+	if identity == nil && existingUser != nil {
+		current, lookupErr := a.userIdentity(existingUser.ID, oauthUser.Provider)
+		if lookupErr != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load identities", lookupErr)
+			return
+		}
+
+		if !emailVerified || (current != nil && current.ProviderUserID != nil) {
+			a.recordAuthEvent(c, models.AuthEventOAuthLogin, models.AuthEventFailure, existingUser, "", oauthUser.Provider+" account is not linked")
+			a.HandleError(c, http.StatusConflict, "An account with this email already exists. Sign in and link your "+oauthUser.Provider+" account from your settings.", "Provider identity not linked", errors.New("Provider identity not linked"))
+			return
+		}
+	}
+
+	if identity == nil && existingUser == nil && !emailVerified {
+		a.recordAuthEvent(c, models.AuthEventOAuthLogin, models.AuthEventFailure, nil, oauthUser.Email, oauthUser.Provider+" email not verified")
+		a.HandleError(c, http.StatusForbidden, "Please verify your email address with "+oauthUser.Provider+" first", "Provider email not verified", errors.New("Provider email not verified"))
+		return
+	}
+
+	// 6) Suspended and deleted accounts cannot sign in, accounts pending verification are activated below if the provider vouches for the email
+	pendingActivation := existingUser != nil && existingUser.Status == models.AccountStatusPendingVerification && emailVerified
+	if existingUser != nil && !pendingActivation && a.rejectUnavailableAccount(c, existingUser, models.AuthEventOAuthLogin) {
+		return
+	}
+
+	// 7) Start transaction
 	tx, err := a.UserDB.Beginx()
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
@@ -81,7 +130,7 @@ func (a *AuthController) CallbackHandler(c *gin.Context) {
 		}
 	}()
 
-	// 5) If user does not exist, create user and set verified to true
+	// 8) If user does not exist, create user and set verified to true. The provider vouches for the email, so accounts pending verification are activated
 	var user *models.User
 	if existingUser == nil {
 		newUser := models.User{
@@ -98,26 +147,79 @@ func (a *AuthController) CallbackHandler(c *gin.Context) {
 			return
 		}
 		user = &newUser
+		a.recordAuthEvent(c, models.AuthEventRegister, models.AuthEventSuccess, user, "", "Signed up with "+oauthUser.Provider)
 	} else {
 		user = existingUser
+
+		if pendingActivation {
+			if activateErr := a.activateVerifiedUser(tx, user, "Email verified by "+oauthUser.Provider); activateErr != nil {
+				err = activateErr
+				a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to activate user", activateErr)
+				return
+			}
+		}
 	}
 
-	// 6) Generate JWT token
+	// 9) Link the provider account to new users and to existing users with the same verified email
+	if identity == nil {
+		identityErr := a.UserDB.CreateIdentity(tx, &models.UserIdentity{UserID: user.ID, Provider: oauthUser.Provider, ProviderUserID: &oauthUser.UserID, Email: &oauthUser.Email})
+		if identityErr != nil {
+			err = identityErr
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to link identity", identityErr)
+			return
+		}
+
+		if existingUser != nil {
+			a.recordAuthEvent(c, models.AuthEventIdentityLinked, models.AuthEventSuccess, user, "", oauthUser.Provider+", verified email matched")
+		}
+	} else if touchErr := a.UserDB.TouchIdentity(tx, identity.ID); touchErr != nil {
+		err = touchErr
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update identity", touchErr)
+		return
+	}
+
+	// 10) Users with an authenticator app finish signing in on the frontend's second factor page
+	if user.TOTPEnabled {
+		if commitErr := tx.Commit(); commitErr != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", commitErr)
+			return
+		}
+
+		challengeToken, challengeErr := a.createMFAChallenge(user.ID)
+		if challengeErr != nil {
+			a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create MFA challenge", challengeErr)
+			return
+		}
+
+		a.recordAuthEvent(c, models.AuthEventOAuthLogin, models.AuthEventSuccess, user, "", oauthUser.Provider+", second factor required")
+		c.Redirect(http.StatusFound, a.FrontendAddress+"/mfa?challengeToken="+url.QueryEscape(challengeToken))
+		return
+	}
+
+	// 11) Generate JWT token
 	token, err := a.JWTGenerator.GenerateJWT(user)
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT", err)
 		return
 	}
 
-	// 7) Set the JWT token as a cookie
+	refreshToken, err := a.issueRefreshToken(user.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	// 12) Set the JWT and refresh tokens as cookies
 	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, refreshToken)
 
-	// 8) Commit the transaction
+	// 13) Commit the transaction
 	if err := tx.Commit(); err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Faile to commit transaction", err)
 		return
 	}
 
-	// 9) Redirect to the /dashboard page
+	// 14) Redirect to the /dashboard page
+	a.recordAuthEvent(c, models.AuthEventOAuthLogin, models.AuthEventSuccess, user, "", oauthUser.Provider)
 	c.Redirect(http.StatusFound, a.FrontendAddress+"/dashboard")
 }
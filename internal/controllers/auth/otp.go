@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/lockout"
+	"github.com/jalil32/go-auth-module/internal/models"
 )
 
 type OTPRequest struct {
@@ -20,23 +23,51 @@ func (a *AuthController) VerifyOTPHandler(c *gin.Context) {
 		return
 	}
 
-	// 2) Verify the otp
+	// 2) Count the attempt against the code and the client IP before checking it, rejecting it if either is locked out
+	otpKey := lockout.AccountKey("otp", otpRequest.Email)
+	ipKey := lockout.IPKey("otp", c.ClientIP())
+	reserved, ok := a.reserveAttempts(c, failedAttempt{key: otpKey, policy: a.Lockout.OTP}, failedAttempt{key: ipKey, policy: a.Lockout.IP})
+	if !ok {
+		return
+	}
+
+	// 3) Verify the otp
 	validated := a.validateOTP(otpRequest.Email, otpRequest.OTP)
 
-	// 3) If incorrect, send back error message
+	// 4) If incorrect, send back error message. Too many wrong guesses throws the code away so it cannot be brute forced
 	if !validated {
+		if lockedOut, retryAfter := a.recordFailedAttempts(reserved); lockedOut {
+			a.invalidateOTP(otpRequest.Email)
+			a.recordAuthEvent(c, models.AuthEventOTPVerified, models.AuthEventFailure, nil, otpRequest.Email, "Locked out, code invalidated")
+			a.respondTooManyAttempts(c, retryAfter)
+			return
+		}
+		a.recordAuthEvent(c, models.AuthEventOTPVerified, models.AuthEventFailure, nil, otpRequest.Email, "Incorrect or expired code")
 		a.HandleError(c, http.StatusUnauthorized, "Incorrect or expired one time password. Please try again.", "Incorrect or expired one time password.", nil)
 		return
 	}
 
-	// 4) If correct fetch user
+	a.releaseAttempts(reserved)
+	a.resetFailedAttempts(otpKey)
+
+	// 5) If correct fetch user
 	existingUser, err := a.UserDB.FindUserByEmail(otpRequest.Email)
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database error during user lookup", err)
 		return
 	}
 
-	// 5) Update user to be verified
+	if existingUser == nil {
+		a.HandleError(c, http.StatusUnauthorized, "Incorrect or expired one time password. Please try again.", "User not found", nil)
+		return
+	}
+
+	// 6) Suspended and deleted accounts cannot be verified into a session
+	if existingUser.Status != models.AccountStatusPendingVerification && a.rejectUnavailableAccount(c, existingUser, models.AuthEventOTPVerified) {
+		return
+	}
+
+	// 7) Update user to be verified and activate the account
 	tx, err := a.UserDB.Beginx()
 	if err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
@@ -52,9 +83,7 @@ func (a *AuthController) VerifyOTPHandler(c *gin.Context) {
 		}
 	}()
 
-	existingUser.Verified = true
-
-	if updateErr := a.UserDB.UpdateUser(tx, existingUser); updateErr != nil {
+	if updateErr := a.activateVerifiedUser(tx, existingUser, "Email verified with a one time password"); updateErr != nil {
 		err = updateErr
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update user", updateErr)
 		return
@@ -66,15 +95,22 @@ func (a *AuthController) VerifyOTPHandler(c *gin.Context) {
 		return
 	}
 
-	// 7) Commit the transaction
+	refreshToken, err := a.issueRefreshToken(existingUser.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	// 8) Commit the transaction
 	if err := tx.Commit(); err != nil {
 		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
 		return
 	}
 
 	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, refreshToken)
 
-	a.Logger.Info("User email successfully verified", "email", existingUser.Email, "userID", existingUser.ID)
+	a.recordAuthEvent(c, models.AuthEventOTPVerified, models.AuthEventSuccess, existingUser, "", "")
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User successfully verified",
 	})
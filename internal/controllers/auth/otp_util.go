@@ -5,10 +5,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
-	"strconv"
 	"time"
-
-	"github.com/go-gomail/gomail"
 )
 
 func (a *AuthController) storeOTP(email string, otp string) error {
@@ -25,6 +22,13 @@ func (a *AuthController) validateOTP(email string, inputOTP string) bool {
 	return storedOTP == inputOTP
 }
 
+// invalidateOTP deletes the user's OTP so it cannot be used again
+func (a *AuthController) invalidateOTP(email string) {
+	if err := a.RedisCache.Del(context.Background(), email).Err(); err != nil {
+		a.Logger.Error("Failed to invalidate OTP", "email", email, "error", err)
+	}
+}
+
 func (a *AuthController) generateOTP() (string, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(1000000)) // Range: 0 - 999999
 	if err != nil {
@@ -46,44 +50,9 @@ func (a *AuthController) sendOTP(email string) error {
 		return fmt.Errorf("failed to store otp: %w", err)
 	}
 
-	// 3) Create new message
-	message := gomail.NewMessage()
-
-	// 4) Set email headers
-	message.SetHeader("From", "team@demomailtrap.com")
-	message.SetHeader("To", email)
-	message.SetHeader("Subject", "Your One Time Password")
-	message.SetBody("text/plain", fmt.Sprintf("Your OTP code is: %s", otp))
-
-	// 5) Convert port to int
-	port, err := strconv.Atoi(a.Port)
-	if err != nil {
-		return fmt.Errorf("failed to convert port: %w", err)
-	}
-
-	// 6) Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// 7) Create a channel to handle the result of the email sending
-	done := make(chan error, 1)
-
-	// 8) Run the email sending in a goroutine
-	go func() {
-		dialer := gomail.NewDialer(a.Host, port, a.Username, a.Password)
-		done <- dialer.DialAndSend(message)
-	}()
-
-	// 9) Wait for either the email to be sent or the context to timeout
-	select {
-	case <-ctx.Done():
-		// Context timed out
-		return fmt.Errorf("failed to send OTP: timeout reached")
-	case err := <-done:
-		// Email sending completed
-		if err != nil {
-			return fmt.Errorf("failed to send OTP: %w", err)
-		}
+	// 3) Send the OTP to the user
+	if err := a.sendEmail(email, "Your One Time Password", fmt.Sprintf("Your OTP code is: %s", otp)); err != nil {
+		return fmt.Errorf("failed to send OTP: %w", err)
 	}
 
 	return nil
@@ -1,11 +1,21 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/lockout"
+	"github.com/jalil32/go-auth-module/internal/models"
 )
 
+// recentSignInWindow is how long after signing in a user without a password can make sensitive changes
+const recentSignInWindow = 10 * time.Minute
+
 func (a *AuthController) hashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -13,3 +23,47 @@ func (a *AuthController) hashPassword(password string) (string, error) {
 	}
 	return string(hashedPassword), nil
 }
+
+// reauthenticate confirms the user is present before a sensitive change. Users with a password must enter it,
+// users who sign in with a provider must have signed in recently. It responds and reports false if the check fails.
+func (a *AuthController) reauthenticate(c *gin.Context, user *models.User, password string, eventType models.AuthEventType) bool {
+	// 1) Users without a password prove themselves by having just signed in
+	if user.PasswordHash == nil {
+		signedInAt, ok := a.signedInAt(c, user.ID)
+		if !ok || time.Since(signedInAt) > recentSignInWindow {
+			a.recordAuthEvent(c, eventType, models.AuthEventFailure, user, "", "Sign in is not recent")
+			a.HandleError(c, http.StatusForbidden, "Please sign in again to continue", "Sign in is not recent", errors.New("Sign in is not recent"))
+			return false
+		}
+		return true
+	}
+
+	// 2) Password guesses count towards a lockout like failed logins do
+	accountKey := lockout.AccountKey("reauthenticate", user.Email)
+	reserved, ok := a.reserveAttempts(c, failedAttempt{key: accountKey, policy: a.Lockout.Account})
+	if !ok {
+		a.recordAuthEvent(c, eventType, models.AuthEventFailure, user, "", "Locked out")
+		return false
+	}
+
+	if compareErr := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(password)); compareErr != nil {
+		a.recordFailedAttempts(reserved)
+		a.recordAuthEvent(c, eventType, models.AuthEventFailure, user, "", "Invalid password")
+		a.HandleError(c, http.StatusUnauthorized, "Incorrect password", "Invalid password", compareErr)
+		return false
+	}
+
+	a.releaseAttempts(reserved)
+	a.resetFailedAttempts(accountKey)
+	return true
+}
+
+// sendPasswordChangedEmail tells the user their password was changed, so they can react if it was not them
+func (a *AuthController) sendPasswordChangedEmail(email string) error {
+	body := fmt.Sprintf("The password of your account was changed and every other session was signed out.\n\nIf you did not do this, reset your password now: %s/forgot-password", a.FrontendAddress)
+	if err := a.sendEmail(email, "Your password was changed", body); err != nil {
+		return fmt.Errorf("failed to send password changed email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// maxPersonalAccessTokens is how many tokens a user can hold at once
+const maxPersonalAccessTokens = 50
+
+// ListPersonalAccessTokensHandler returns the authenticated user's tokens with when and where they were last used.
+func (a *AuthController) ListPersonalAccessTokensHandler(c *gin.Context) {
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := a.UserDB.FindPersonalAccessTokensByUserID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load personal access tokens", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// CreatePersonalAccessTokenHandler creates a token for scripts. The token is only shown in this response.
+func (a *AuthController) CreatePersonalAccessTokenHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 2) Bind and validate the request
+	var request CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid Request Payload", err)
+		return
+	}
+
+	if validationErr := request.Validate(); validationErr != nil {
+		a.HandleError(c, http.StatusBadRequest, validationErr.UserMessage, "Validation failed", validationErr.InternalError)
+		return
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		a.HandleError(c, http.StatusBadRequest, "Expiry must be in the future", "Expiry in the past", errors.New("Expiry in the past"))
+		return
+	}
+
+	// 3) Scopes are permissions, a token can only hold permissions the user has
+	for _, scope := range request.Scopes {
+		if !slices.Contains(contextUser.Permissions, scope) {
+			a.HandleError(c, http.StatusForbidden, "You cannot grant the "+scope+" scope", "Scope not held by user", errors.New("Scope not held by user"))
+			return
+		}
+	}
+
+	existing, err := a.UserDB.FindPersonalAccessTokensByUserID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load personal access tokens", err)
+		return
+	}
+
+	if len(existing) >= maxPersonalAccessTokens {
+		a.HandleError(c, http.StatusConflict, "You have too many tokens, delete one first", "Too many personal access tokens", errors.New("Too many personal access tokens"))
+		return
+	}
+
+	// 4) Generate the token, only its hash is stored
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate personal access token", err)
+		return
+	}
+	secret := models.PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+
+	token := &models.PersonalAccessToken{
+		UserID:    contextUser.ID,
+		Name:      request.Name,
+		Prefix:    secret[:len(models.PersonalAccessTokenPrefix)+8],
+		TokenHash: models.HashPersonalAccessToken(secret),
+		Scopes:    pq.StringArray(request.Scopes),
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	// 5) Store the token
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.CreatePersonalAccessToken(tx, token); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to create personal access token", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventTokenCreated, models.AuthEventSuccess, contextUser, "", token.Name)
+	c.JSON(http.StatusCreated, gin.H{"token": token, "secret": secret})
+}
+
+// DeletePersonalAccessTokenHandler revokes one of the authenticated user's tokens.
+func (a *AuthController) DeletePersonalAccessTokenHandler(c *gin.Context) {
+	// 1) Get the authenticated user and the token to delete
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Invalid token ID", "Invalid token ID parameter", err)
+		return
+	}
+
+	// 2) Delete it, scoped to the user so one user cannot revoke another's token
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	deleted, err := a.UserDB.DeletePersonalAccessToken(tx, contextUser.ID, tokenID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to delete personal access token", err)
+		return
+	}
+
+	if !deleted {
+		err = errors.New("Token not found")
+		a.HandleError(c, http.StatusNotFound, "Token not found", "Personal access token not found for user", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.recordAuthEvent(c, models.AuthEventTokenRevoked, models.AuthEventSuccess, contextUser, "", strconv.Itoa(tokenID))
+	c.JSON(http.StatusOK, gin.H{"message": "Token deleted"})
+}
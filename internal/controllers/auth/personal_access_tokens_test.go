@@ -0,0 +1,128 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_PersonalAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: 5, Email: "test@example.com", Permissions: []string{"bank:upload"}}
+	otherUser := &models.User{ID: 6, Email: "other@example.com", Permissions: []string{"bank:upload"}}
+
+	tokens := map[int]*models.PersonalAccessToken{}
+	mockRedis, _ := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindPersonalAccessTokensByUserIDFunc: func(userID int) ([]models.PersonalAccessToken, error) {
+			list := []models.PersonalAccessToken{}
+			for _, token := range tokens {
+				if token.UserID == userID {
+					list = append(list, *token)
+				}
+			}
+			return list, nil
+		},
+		CreatePersonalAccessTokenFunc: func(ext sqlx.Ext, token *models.PersonalAccessToken) error {
+			token.ID = len(tokens) + 1
+			tokens[token.ID] = token
+			return nil
+		},
+		DeletePersonalAccessTokenFunc: func(ext sqlx.Ext, userID int, id int) (bool, error) {
+			token, exists := tokens[id]
+			if !exists || token.UserID != userID {
+				return false, nil
+			}
+			delete(tokens, id)
+			return true, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+	auditLogger := &MockAuditLogger{}
+	authController.Audit = auditLogger
+
+	create := func(body string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	deleteToken := func(as *models.User, id string) int {
+		// Each delete starts on a fresh connection, a rolled back delete would otherwise break the next commit
+		mockDB.BeginxFunc = newSQLMockBeginx(t)
+		req, _ := http.NewRequest(http.MethodDelete, "/me/tokens/"+id, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Set("user", as)
+		authController.DeletePersonalAccessTokenHandler(c)
+		return w.Code
+	}
+
+	// 1) Scopes are required, must be held by the user, and expiry must be in the future
+	w := executeAuthenticatedHandler(authController.CreatePersonalAccessTokenHandler, user, create(`{"name":"Script","scopes":[]}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeAuthenticatedHandler(authController.CreatePersonalAccessTokenHandler, user, create(`{"name":"Script","scopes":["users:write"]}`))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeAuthenticatedHandler(authController.CreatePersonalAccessTokenHandler, user, create(`{"name":"Script","scopes":["bank:upload"],"expiresAt":"2020-01-01T00:00:00Z"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, tokens)
+
+	// 2) The token is shown once and only its hash is stored
+	w = executeAuthenticatedHandler(authController.CreatePersonalAccessTokenHandler, user, create(`{"name":"Script","scopes":["bank:upload"],"expiresAt":"2099-01-01T00:00:00Z"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		Token  models.PersonalAccessToken `json:"token"`
+		Secret string                     `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.True(t, strings.HasPrefix(created.Secret, models.PersonalAccessTokenPrefix))
+
+	stored := tokens[created.Token.ID]
+	require.NotNil(t, stored)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, models.HashPersonalAccessToken(created.Secret), stored.TokenHash)
+	assert.True(t, strings.HasPrefix(created.Secret, stored.Prefix))
+	require.NotNil(t, stored.ExpiresAt)
+
+	// 3) Listing shows the user's tokens without the token or its hash
+	w = executeAuthenticatedHandler(authController.ListPersonalAccessTokensHandler, user, create(""))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Script"`)
+	assert.Contains(t, w.Body.String(), `"lastUsedIp":null`)
+	assert.NotContains(t, w.Body.String(), stored.TokenHash)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	w = executeAuthenticatedHandler(authController.ListPersonalAccessTokensHandler, otherUser, create(""))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"tokens":[]}`, w.Body.String())
+
+	// 4) Users can only delete their own tokens
+	id := created.Token.ID
+	assert.Equal(t, http.StatusNotFound, deleteToken(otherUser, strconv.Itoa(id)))
+	assert.Equal(t, http.StatusBadRequest, deleteToken(user, "abc"))
+	assert.Equal(t, http.StatusOK, deleteToken(user, strconv.Itoa(id)))
+	assert.Empty(t, tokens)
+
+	// 5) Creating and deleting are recorded in the audit log
+	require.Len(t, auditLogger.Events, 2)
+	assert.Equal(t, models.AuthEventTokenCreated, auditLogger.Events[0].Type)
+	assert.Equal(t, models.AuthEventTokenRevoked, auditLogger.Events[1].Type)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// RefreshHandler swaps a valid refresh token for a new access token and a new refresh token.
+func (a *AuthController) RefreshHandler(c *gin.Context) {
+	// 1) Get the refresh token from the cookie
+	refreshToken, err := c.Cookie(refreshTokenCookie)
+	if err != nil || refreshToken == "" {
+		a.HandleError(c, http.StatusUnauthorized, "Missing refresh token", "Refresh token cookie not present", err)
+		return
+	}
+
+	// 2) Rotate the refresh token, this revokes the whole family if the token was already used
+	record, err := a.rotateRefreshToken(refreshToken)
+	if err != nil {
+		a.clearRefreshCookie(c)
+
+		if errors.Is(err, errRefreshTokenReused) {
+			a.Logger.Error("Refresh token reuse detected, token family revoked", "userID", record.UserID, "familyID", record.FamilyID)
+			a.HandleError(c, http.StatusUnauthorized, "Invalid or expired refresh token", "Refresh token reuse detected", err)
+			return
+		}
+
+		if errors.Is(err, errRefreshTokenInvalid) {
+			a.HandleError(c, http.StatusUnauthorized, "Invalid or expired refresh token", "Refresh token validation failed", err)
+			return
+		}
+
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to rotate refresh token", err)
+		return
+	}
+
+	// 3) Fetch the user so the new access token carries up to date claims
+	user, err := a.UserDB.FindUserByID(record.UserID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	if user == nil {
+		a.revokeRefreshFamily(record.FamilyID)
+		a.clearRefreshCookie(c)
+		a.HandleError(c, http.StatusUnauthorized, "Invalid or expired refresh token", "User not found", errors.New("User not found"))
+		return
+	}
+
+	if !user.Status.CanSignIn() {
+		a.revokeRefreshFamily(record.FamilyID)
+		a.clearRefreshCookie(c)
+		a.rejectUnavailableAccount(c, user, models.AuthEventLogin)
+		return
+	}
+
+	// 4) Generate a new access token
+	token, err := a.JWTGenerator.GenerateJWT(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", err)
+		return
+	}
+
+	// 5) Issue the next refresh token in the same family
+	newRefreshToken, err := a.issueRefreshToken(user.ID, record.FamilyID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	a.setAuthCookie(c, token)
+	a.setRefreshCookie(c, newRefreshToken)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token refreshed"})
+}
@@ -0,0 +1,232 @@
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// newInMemoryRedis returns a MockRedisClient backed by a map so multi step flows can be exercised.
+func newInMemoryRedis() (*MockRedisClient, map[string]string) {
+	store := map[string]string{}
+
+	return &MockRedisClient{
+		GetFunc: func(ctx context.Context, key string) *redis.StringCmd {
+			value, ok := store[key]
+			if !ok {
+				return redis.NewStringResult("", redis.Nil)
+			}
+			return redis.NewStringResult(value, nil)
+		},
+		SetFunc: func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+			switch v := value.(type) {
+			case []byte:
+				store[key] = string(v)
+			default:
+				store[key] = fmt.Sprint(v)
+			}
+			return redis.NewStatusResult("OK", nil)
+		},
+		SetArgsFunc: func(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd {
+			previous, exists := store[key]
+			if (a.Mode == "NX" && exists) || (a.Mode == "XX" && !exists) {
+				return redis.NewStatusResult("", redis.Nil)
+			}
+
+			switch v := value.(type) {
+			case []byte:
+				store[key] = string(v)
+			default:
+				store[key] = fmt.Sprint(v)
+			}
+
+			if a.Get {
+				if !exists {
+					return redis.NewStatusResult("", redis.Nil)
+				}
+				return redis.NewStatusResult(previous, nil)
+			}
+			return redis.NewStatusResult("OK", nil)
+		},
+		DelFunc: func(ctx context.Context, keys ...string) *redis.IntCmd {
+			var deleted int64
+			for _, key := range keys {
+				if _, ok := store[key]; ok {
+					delete(store, key)
+					deleted++
+				}
+			}
+			return redis.NewIntResult(deleted, nil)
+		},
+		IncrFunc: func(ctx context.Context, key string) *redis.IntCmd {
+			count, _ := strconv.ParseInt(store[key], 10, 64)
+			count++
+			store[key] = strconv.FormatInt(count, 10)
+			return redis.NewIntResult(count, nil)
+		},
+		ExpireFunc: func(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+			_, ok := store[key]
+			return redis.NewBoolResult(ok, nil)
+		},
+		// The lockout scripts: the release script decrements a positive counter, the increment script increments it
+		EvalFunc: func(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+			count, _ := strconv.ParseInt(store[keys[0]], 10, 64)
+			switch {
+			case strings.Contains(script, "DECR") && count > 0:
+				count--
+			case strings.Contains(script, "DECR"):
+				return redis.NewCmdResult(int64(0), nil)
+			default:
+				count++
+			}
+			store[keys[0]] = strconv.FormatInt(count, 10)
+			return redis.NewCmdResult(count, nil)
+		},
+	}, store
+}
+
+// cookieValue returns the value of the named cookie set on the response.
+func cookieValue(w *httptest.ResponseRecorder, name string) string {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// executeRefreshHandler calls the Refresh handler with the given refresh token cookie.
+func executeRefreshHandler(authController *auth.AuthController, refreshToken string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+	if refreshToken != "" {
+		c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	}
+
+	authController.RefreshHandler(c)
+	return w
+}
+
+func TestAuthController_RefreshRotation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 7, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc:    func(id int) (*models.User, error) { return user, nil },
+	}
+	mockLogger := &MockLogger{}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, mockLogger, mockJWT)
+	if err != nil {
+		t.Fatalf("failed to create AuthController: %v", err)
+	}
+
+	// 1) Login issues a refresh token cookie
+	req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+	w := executeLoginHandler(authController, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	firstToken := cookieValue(w, "refresh_token")
+	assert.NotEmpty(t, firstToken)
+	for key := range store {
+		assert.NotContains(t, key, firstToken, "refresh tokens must be stored hashed")
+	}
+
+	// 2) Missing cookie is rejected
+	w = executeRefreshHandler(authController, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 3) Refreshing rotates the token and issues a new access token
+	w = executeRefreshHandler(authController, firstToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "mock-token", cookieValue(w, "auth_token"))
+
+	secondToken := cookieValue(w, "refresh_token")
+	assert.NotEmpty(t, secondToken)
+	assert.NotEqual(t, firstToken, secondToken)
+
+	// 4) Presenting the rotated token again revokes the whole family
+	w = executeRefreshHandler(authController, firstToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"refresh token reuse detected", "internal_message":"Refresh token reuse detected", "message":"Invalid or expired refresh token"}`, w.Body.String())
+
+	for key := range store {
+		assert.False(t, strings.HasPrefix(key, "refresh_family:"), "token family should be revoked")
+	}
+
+	// 5) The latest token in the family no longer works either
+	w = executeRefreshHandler(authController, secondToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthController_RefreshConcurrentReuse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_EXPIRY", "1m")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPasswordStr := string(hashedPassword)
+	user := &models.User{ID: 7, Email: "test@example.com", PasswordHash: &hashedPasswordStr, Verified: true, Status: models.AccountStatusActive}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByEmailFunc: func(email string) (*models.User, error) { return user, nil },
+		FindUserByIDFunc:    func(id int) (*models.User, error) { return user, nil },
+	}
+	mockJWT := &MockJWTGenerator{GenerateJWTFunc: func(user *models.User) (string, error) { return "mock-token", nil }}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, mockJWT)
+	if err != nil {
+		t.Fatalf("failed to create AuthController: %v", err)
+	}
+
+	req, _ := createTestRequest(http.MethodPost, "/login", map[string]string{"email": user.Email, "password": "password123"})
+	w := executeLoginHandler(authController, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	token := cookieValue(w, "refresh_token")
+
+	// Both requests read the token before either marks it rotated, as when a stolen token races the real one
+	unrotated := map[string]string{}
+	for key, value := range store {
+		unrotated[key] = value
+	}
+	get := mockRedis.GetFunc
+	mockRedis.GetFunc = func(ctx context.Context, key string) *redis.StringCmd {
+		if value, ok := unrotated[key]; ok && strings.HasPrefix(key, "refresh_token:") {
+			return redis.NewStringResult(value, nil)
+		}
+		return get(ctx, key)
+	}
+
+	w = executeRefreshHandler(authController, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = executeRefreshHandler(authController, token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "refresh token reuse detected")
+
+	for key := range store {
+		assert.False(t, strings.HasPrefix(key, "refresh_family:"), "token family should be revoked")
+	}
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const refreshTokenCookie = "refresh_token"
+
+// refreshCookiePath limits the refresh token cookie to the auth endpoints that need it
+const refreshCookiePath = "/api/auth"
+
+var (
+	errRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// refreshTokenRecord is stored in redis against the hash of an issued refresh token
+type refreshTokenRecord struct {
+	UserID   int    `json:"userId"`
+	FamilyID string `json:"familyId"`
+	Rotated  bool   `json:"rotated"`
+}
+
+// refreshFamilyRecord tracks a chain of rotated refresh tokens that started from a single sign in
+type refreshFamilyRecord struct {
+	UserID      int   `json:"userId"`
+	CreatedAtMs int64 `json:"createdAtMs"` // Sign in time in milliseconds, compared with the per-user revocation cut off
+}
+
+func refreshTokenKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("refresh_token:%s", hex.EncodeToString(hash[:]))
+}
+
+func refreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh_family:%s", familyID)
+}
+
+// issueRefreshToken creates a new opaque refresh token for the user and stores its hash in redis.
+// An empty familyID starts a new token family, which happens on every fresh sign in.
+func (a *AuthController) issueRefreshToken(userID int, familyID string) (string, error) {
+	ctx := context.Background()
+
+	expiry, err := time.ParseDuration(a.RefreshExpiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse refresh expiry: %w", err)
+	}
+
+	// 1) Start a new family or extend the lifetime of the existing one
+	family := refreshFamilyRecord{UserID: userID, CreatedAtMs: time.Now().UnixMilli()}
+	if familyID == "" {
+		familyID = uuid.New().String()
+	} else if existing, getErr := a.getRefreshFamily(familyID); getErr == nil {
+		family = *existing
+	}
+
+	familyJSON, err := json.Marshal(family)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal refresh family: %w", err)
+	}
+
+	if err := a.RedisCache.Set(ctx, refreshFamilyKey(familyID), familyJSON, expiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store refresh family: %w", err)
+	}
+
+	// 2) Generate the token itself
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	// 3) Store only the hash of the token so a leaked redis dump cannot be replayed
+	record, err := json.Marshal(refreshTokenRecord{UserID: userID, FamilyID: familyID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	if err := a.RedisCache.Set(ctx, refreshTokenKey(token), record, expiry).Err(); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// rotateRefreshToken marks the presented refresh token as used and returns its record so a new token can be
+// issued in the same family. Presenting a token that was already rotated revokes the whole family.
+func (a *AuthController) rotateRefreshToken(token string) (*refreshTokenRecord, error) {
+	ctx := context.Background()
+	key := refreshTokenKey(token)
+
+	// 1) Look up the token
+	value, err := a.RedisCache.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+
+	// 2) The family must still be alive, it is deleted when reuse is detected or the user logs out
+	family, err := a.getRefreshFamily(record.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3) Families started before the user's sessions were revoked are no longer valid
+	validAfter, err := a.Sessions.TokensValidAfter(ctx, family.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.UnixMilli(family.CreatedAtMs).Before(validAfter) {
+		a.revokeRefreshFamily(record.FamilyID)
+		return nil, errRefreshTokenInvalid
+	}
+
+	// 4) A rotated token being presented again means it was stolen, so revoke every token in the family
+	if record.Rotated {
+		a.revokeRefreshFamily(record.FamilyID)
+		return &record, errRefreshTokenReused
+	}
+
+	// 5) Mark the token as rotated and read it back in one SET ... GET, so when the same token is presented twice at
+	// once only one request sees it unrotated. It is kept until it expires so reuse can be detected.
+	record.Rotated = true
+	rotated, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	previous, err := a.RedisCache.SetArgs(ctx, key, rotated, redis.SetArgs{Mode: "XX", KeepTTL: true, Get: true}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	var before refreshTokenRecord
+	if err := json.Unmarshal([]byte(previous), &before); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+
+	if before.Rotated {
+		a.revokeRefreshFamily(record.FamilyID)
+		return &record, errRefreshTokenReused
+	}
+
+	return &record, nil
+}
+
+// getRefreshFamily returns the family record or errRefreshTokenInvalid if the family has been revoked or expired
+func (a *AuthController) getRefreshFamily(familyID string) (*refreshFamilyRecord, error) {
+	value, err := a.RedisCache.Get(context.Background(), refreshFamilyKey(familyID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh family: %w", err)
+	}
+
+	var family refreshFamilyRecord
+	if err := json.Unmarshal([]byte(value), &family); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh family: %w", err)
+	}
+
+	return &family, nil
+}
+
+// revokeRefreshFamily invalidates every refresh token that descends from the same sign in
+func (a *AuthController) revokeRefreshFamily(familyID string) {
+	if err := a.RedisCache.Del(context.Background(), refreshFamilyKey(familyID)).Err(); err != nil {
+		a.Logger.Error("Failed to revoke refresh token family", "familyID", familyID, "error", err)
+	}
+}
+
+// revokeRefreshToken revokes the family of the given refresh token, used on logout
+func (a *AuthController) revokeRefreshToken(token string) {
+	value, err := a.RedisCache.Get(context.Background(), refreshTokenKey(token)).Result()
+	if err != nil {
+		return
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return
+	}
+
+	a.revokeRefreshFamily(record.FamilyID)
+}
+
+// signedInAt returns when the session behind the request's refresh token cookie signed in.
+// It reports false if the cookie is missing, no longer valid or belongs to another user.
+func (a *AuthController) signedInAt(c *gin.Context, userID int) (time.Time, bool) {
+	token, err := c.Cookie(refreshTokenCookie)
+	if err != nil || token == "" {
+		return time.Time{}, false
+	}
+
+	value, err := a.RedisCache.Get(context.Background(), refreshTokenKey(token)).Result()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil || record.UserID != userID || record.Rotated {
+		return time.Time{}, false
+	}
+
+	family, err := a.getRefreshFamily(record.FamilyID)
+	if err != nil || family.UserID != userID {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(family.CreatedAtMs), true
+}
+
+// setRefreshCookie sets the refresh token in a secure, HTTP-only cookie scoped to the auth endpoints.
+func (a *AuthController) setRefreshCookie(c *gin.Context, token string) {
+	expiryDuration, err := time.ParseDuration(a.RefreshExpiry)
+	if err != nil {
+		a.Logger.Error("Invalid refresh cookie expiry duration", "error", err)
+		return
+	}
+
+	c.SetCookie(
+		refreshTokenCookie,            // Name
+		token,                         // Value
+		int(expiryDuration.Seconds()), // MaxAge in seconds
+		refreshCookiePath,             // Path
+		"",                            // Domain (empty for default)
+		true,                          // Secure (true for HTTPS)
+		true,                          // HttpOnly
+	)
+}
+
+// clearRefreshCookie expires the refresh token cookie
+func (a *AuthController) clearRefreshCookie(c *gin.Context) {
+	c.SetCookie(refreshTokenCookie, "", -1, refreshCookiePath, "", true, true)
+}
@@ -43,6 +43,7 @@ func (a *AuthController) Register(c *gin.Context) {
 	}
 
 	if existingUser != nil {
+		a.recordAuthEvent(c, models.AuthEventRegister, models.AuthEventFailure, existingUser, "", "Email already registered")
 		a.HandleError(c, http.StatusConflict, "User with this email already exists.", "User already exists in db", errors.New("User already exists in db"))
 		return
 	}
@@ -97,5 +98,7 @@ func (a *AuthController) Register(c *gin.Context) {
 	}
 
 	// 10) Send success response
+	a.recordAuthEvent(c, models.AuthEventRegister, models.AuthEventSuccess, &newUser, "", "")
+	a.recordAuthEvent(c, models.AuthEventOTPSent, models.AuthEventSuccess, &newUser, "", "Registration")
 	c.JSON(http.StatusCreated, gin.H{"message": "User created and OTP sent successfully"})
 }
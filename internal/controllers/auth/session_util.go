@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// revokeAccessToken adds the token's jti to the revocation list until the token expires
+func (a *AuthController) revokeAccessToken(tokenString string) {
+	// 1) Invalid or expired tokens are already rejected by the middleware so there is nothing to revoke
+	claims, err := a.JWTGenerator.ParseJWT(tokenString)
+	if err != nil {
+		return
+	}
+
+	// 2) Extract the token id and expiry
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return
+	}
+
+	// 3) Add the token to the revocation list
+	if err := a.Sessions.RevokeToken(context.Background(), jti, expiresAt.Time); err != nil {
+		a.Logger.Error("Failed to revoke access token", "jti", jti, "error", err)
+	}
+}
+
+// tokenUser returns the user an access token was issued to, or nil if the token is invalid
+func (a *AuthController) tokenUser(tokenString string) *models.User {
+	claims, err := a.JWTGenerator.ParseJWT(tokenString)
+	if err != nil {
+		return nil
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil
+	}
+
+	email, _ := claims["email"].(string)
+	return &models.User{ID: int(userID), Email: email}
+}
+
+// revokeAllSessions rejects every access and refresh token issued to the user before now
+func (a *AuthController) revokeAllSessions(userID int) error {
+	if err := a.Sessions.RevokeUserTokens(context.Background(), userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	a.Logger.Info("All sessions revoked for user", "userID", userID)
+	return nil
+}
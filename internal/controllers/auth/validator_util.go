@@ -3,6 +3,7 @@ package auth
 import (
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -27,10 +28,49 @@ type ForgotPasswordRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 type ResetPasswordRequest struct {
 	NewPassword string `json:"newPassword" validate:"required,strong_password"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,strong_password"`
+}
+
+type AddPasswordRequest struct {
+	Password string `json:"password" validate:"required,strong_password"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"newEmail" validate:"required,email"`
+	Password string `json:"password"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type MFACodeRequest struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+}
+
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recoveryCode" validate:"required_without=Code"`
+}
+
 type ValidationError struct {
 	UserMessage   string            // General user-friendly message
 	FieldErrors   map[string]string // Field-specific validation errors
@@ -49,10 +89,42 @@ func (fpr *ForgotPasswordRequest) Validate() *ValidationError {
 	return validateStruct(fpr)
 }
 
+func (r *MagicLinkRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
 func (fpr *ResetPasswordRequest) Validate() *ValidationError {
 	return validateStruct(fpr)
 }
 
+func (r *ChangePasswordRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *AddPasswordRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *ChangeEmailRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *CreatePersonalAccessTokenRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *TOTPCodeRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *MFACodeRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
+func (r *MFAVerifyRequest) Validate() *ValidationError {
+	return validateStruct(r)
+}
+
 var validate *validator.Validate
 
 func init() {
@@ -93,6 +165,12 @@ func validateStruct(s interface{}) *ValidationError {
 				message = fmt.Sprintf("The %s field must be at least %s characters long.", field, err.Param())
 			case "max":
 				message = fmt.Sprintf("The %s field must be no more than %s characters long.", field, err.Param())
+			case "len":
+				message = fmt.Sprintf("The %s field must be exactly %s characters long.", field, err.Param())
+			case "numeric":
+				message = fmt.Sprintf("The %s field must only contain digits.", field)
+			case "required_without":
+				message = fmt.Sprintf("Either the %s or %s field is required.", field, err.Param())
 			case "eqfield":
 				message = fmt.Sprintf("The %s field must match the %s field.", field, err.Param())
 			default:
@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// maxPasskeyNameLength bounds the label users give their passkeys
+const maxPasskeyNameLength = 100
+
+// BeginWebAuthnRegistrationHandler returns the options the browser needs to create a new passkey.
+func (a *AuthController) BeginWebAuthnRegistrationHandler(c *gin.Context) {
+	// 1) Get the authenticated user and their existing passkeys
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	webAuthnUser, err := a.loadWebAuthnUser(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load passkeys", err)
+		return
+	}
+
+	// 2) Start the ceremony, existing passkeys are excluded so the same authenticator is not registered twice
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(webAuthnUser.credentials))
+	for _, credential := range webAuthnUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := a.WebAuthn.BeginRegistration(
+		webAuthnUser,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		}),
+	)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to begin passkey registration", err)
+		return
+	}
+
+	// 3) Keep the challenge until the browser responds
+	if err := a.storeWebAuthnSession(webAuthnRegistrationKey(user.ID), session); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to store passkey registration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishWebAuthnRegistrationHandler verifies the browser's attestation and stores the new passkey.
+func (a *AuthController) FinishWebAuthnRegistrationHandler(c *gin.Context) {
+	// 1) Get the authenticated user
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		name = "Passkey"
+	}
+
+	if len(name) > maxPasskeyNameLength {
+		a.HandleError(c, http.StatusBadRequest, "Passkey name must be at most 100 characters long.", "Passkey name too long", errors.New("Passkey name too long"))
+		return
+	}
+
+	user, err := a.UserDB.FindUserByID(contextUser.ID)
+	if err != nil || user == nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Database lookup error", err)
+		return
+	}
+
+	webAuthnUser, err := a.loadWebAuthnUser(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load passkeys", err)
+		return
+	}
+
+	// 2) Get the pending ceremony, it can only be answered once
+	session, err := a.takeWebAuthnSession(webAuthnRegistrationKey(user.ID))
+	if err != nil {
+		if errors.Is(err, errWebAuthnSessionInvalid) {
+			a.HandleError(c, http.StatusBadRequest, "Passkey registration expired, please try again.", "WebAuthn registration session missing", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to get passkey registration", err)
+		return
+	}
+
+	// 3) Verify the attestation against the challenge
+	credential, err := a.WebAuthn.FinishRegistration(webAuthnUser, *session, c.Request)
+	if err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Passkey registration failed", "WebAuthn attestation verification failed", err)
+		return
+	}
+
+	// 4) Store the passkey
+	model := fromWebAuthnCredential(user.ID, name, credential)
+
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.CreateWebAuthnCredential(tx, model); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to store passkey", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.Logger.Info("Passkey registered", "userID", user.ID, "credentialID", model.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Passkey registered",
+		"credential": model,
+	})
+}
+
+// ListWebAuthnCredentialsHandler returns the authenticated user's passkeys.
+func (a *AuthController) ListWebAuthnCredentialsHandler(c *gin.Context) {
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := a.UserDB.FindWebAuthnCredentialsByUserID(contextUser.ID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to load passkeys", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// DeleteWebAuthnCredentialHandler removes one of the authenticated user's passkeys.
+func (a *AuthController) DeleteWebAuthnCredentialHandler(c *gin.Context) {
+	// 1) Get the authenticated user and the passkey to delete
+	contextUser, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+
+	credentialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Invalid passkey ID", "Invalid credential ID parameter", err)
+		return
+	}
+
+	// 2) Delete it, scoped to the user so one user cannot remove another's passkey
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	deleted, err := a.UserDB.DeleteWebAuthnCredential(tx, contextUser.ID, credentialID)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to delete passkey", err)
+		return
+	}
+
+	if !deleted {
+		err = errors.New("Passkey not found")
+		a.HandleError(c, http.StatusNotFound, "Passkey not found", "Credential not found for user", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	a.Logger.Info("Passkey deleted", "userID", contextUser.ID, "credentialID", credentialID)
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted"})
+}
+
+// BeginWebAuthnLoginHandler returns a challenge for a passwordless login with any discoverable passkey.
+func (a *AuthController) BeginWebAuthnLoginHandler(c *gin.Context) {
+	// 1) Start a discoverable login, the authenticator tells us who the user is
+	options, session, err := a.WebAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to begin passkey login", err)
+		return
+	}
+
+	// 2) Keep the challenge against a random token held in a short lived cookie
+	token, err := generateWebAuthnLoginToken()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate passkey login token", err)
+		return
+	}
+
+	if err := a.storeWebAuthnSession(webAuthnLoginKey(token), session); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to store passkey login", err)
+		return
+	}
+
+	c.SetCookie(webAuthnLoginCookie, token, int(webAuthnSessionExpiry.Seconds()), webAuthnCookiePath, "", true, true)
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishWebAuthnLoginHandler verifies the passkey assertion and signs the user in.
+func (a *AuthController) FinishWebAuthnLoginHandler(c *gin.Context) {
+	// 1) Get the pending ceremony from the cookie
+	token, err := c.Cookie(webAuthnLoginCookie)
+	if err != nil || token == "" {
+		a.HandleError(c, http.StatusBadRequest, "Passkey login expired, please try again.", "WebAuthn login cookie not present", err)
+		return
+	}
+	c.SetCookie(webAuthnLoginCookie, "", -1, webAuthnCookiePath, "", true, true)
+
+	session, err := a.takeWebAuthnSession(webAuthnLoginKey(token))
+	if err != nil {
+		if errors.Is(err, errWebAuthnSessionInvalid) {
+			a.HandleError(c, http.StatusBadRequest, "Passkey login expired, please try again.", "WebAuthn login session missing", err)
+			return
+		}
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to get passkey login", err)
+		return
+	}
+
+	// 2) Verify the assertion, the user is resolved from the user handle stored on the authenticator
+	parsedResponse, err := protocol.ParseCredentialRequestResponse(c.Request)
+	if err != nil {
+		a.HandleError(c, http.StatusBadRequest, "Bad Request", "Invalid WebAuthn assertion payload", err)
+		return
+	}
+
+	resolvedUser, credential, err := a.WebAuthn.ValidatePasskeyLogin(a.findDiscoverableUser, *session, parsedResponse)
+	if err != nil {
+		a.HandleError(c, http.StatusUnauthorized, "Passkey login failed", "WebAuthn assertion verification failed", err)
+		return
+	}
+
+	webAuthnUser, ok := resolvedUser.(*webAuthnUser)
+	if !ok {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Unexpected WebAuthn user type", errors.New("Unexpected WebAuthn user type"))
+		return
+	}
+	user := webAuthnUser.user
+
+	// 3) A sign counter that went backwards means the authenticator may have been cloned
+	if credential.Authenticator.CloneWarning {
+		a.Logger.Error("Passkey clone warning, login rejected", "userID", user.ID)
+		a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventFailure, user, "", "Passkey sign count regression")
+		a.HandleError(c, http.StatusUnauthorized, "Passkey login failed", "WebAuthn sign count regression", errors.New("WebAuthn sign count regression"))
+		return
+	}
+
+	// Suspended and deleted accounts cannot sign in
+	if a.rejectUnavailableAccount(c, user, models.AuthEventLogin) {
+		return
+	}
+
+	// 4) Record the new sign count and last use
+	tx, err := a.UserDB.Beginx()
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to start transaction", err)
+		return
+	}
+
+	// Defer rollback in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.Logger.Error("Failed to rollback transaction", "error", rbErr)
+			}
+		}
+	}()
+
+	if err = a.UserDB.UpdateWebAuthnCredentialUsage(tx, fromWebAuthnCredential(user.ID, "", credential)); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to update passkey usage", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to commit transaction", err)
+		return
+	}
+
+	// 5) Issue the same tokens as a password login
+	jwtToken, err := a.JWTGenerator.GenerateJWT(user)
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to generate JWT Token", err)
+		return
+	}
+
+	refreshToken, err := a.issueRefreshToken(user.ID, "")
+	if err != nil {
+		a.HandleError(c, http.StatusInternalServerError, "Something went wrong...", "Failed to issue refresh token", err)
+		return
+	}
+
+	a.setAuthCookie(c, jwtToken)
+	a.setRefreshCookie(c, refreshToken)
+
+	a.recordAuthEvent(c, models.AuthEventLogin, models.AuthEventSuccess, user, "", "Passkey")
+	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+}
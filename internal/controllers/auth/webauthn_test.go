@@ -0,0 +1,167 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestAuthController_WebAuthnCeremonies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: 5, Email: "test@example.com", FirstName: "Test", LastName: "User", Verified: true, Status: models.AccountStatusActive}
+	existing := models.WebAuthnCredential{ID: 1, UserID: user.ID, Name: "Laptop", CredentialID: []byte("existing-credential")}
+	credentials := []models.WebAuthnCredential{existing}
+
+	mockRedis, store := newInMemoryRedis()
+	mockDB := &MockDB{
+		FindUserByIDFunc: func(id int) (*models.User, error) { return user, nil },
+		FindWebAuthnCredentialsByUserIDFunc: func(userID int) ([]models.WebAuthnCredential, error) {
+			return credentials, nil
+		},
+		DeleteWebAuthnCredentialFunc: func(ext sqlx.Ext, userID int, id int) (bool, error) {
+			for i, credential := range credentials {
+				if credential.ID == id && credential.UserID == userID {
+					credentials = append(credentials[:i], credentials[i+1:]...)
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+
+	authController, err := createTestAuthController(mockDB, mockRedis, &MockLogger{}, &MockJWTGenerator{})
+	require.NoError(t, err)
+
+	// 1) Registration requires a discoverable credential and excludes passkeys the user already has
+	req, _ := http.NewRequest(http.MethodPost, "/api/auth/webauthn/register/begin", nil)
+	w := executeAuthenticatedHandler(authController.BeginWebAuthnRegistrationHandler, user, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var creation struct {
+		PublicKey struct {
+			User struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"user"`
+			ExcludeCredentials []struct {
+				ID string `json:"id"`
+			} `json:"excludeCredentials"`
+			AuthenticatorSelection struct {
+				ResidentKey      string `json:"residentKey"`
+				UserVerification string `json:"userVerification"`
+			} `json:"authenticatorSelection"`
+		} `json:"publicKey"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &creation))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString([]byte("5")), creation.PublicKey.User.ID)
+	assert.Equal(t, user.Email, creation.PublicKey.User.Name)
+	require.Len(t, creation.PublicKey.ExcludeCredentials, 1)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(existing.CredentialID), creation.PublicKey.ExcludeCredentials[0].ID)
+	assert.Equal(t, "required", creation.PublicKey.AuthenticatorSelection.ResidentKey)
+	assert.Equal(t, "required", creation.PublicKey.AuthenticatorSelection.UserVerification)
+	assert.Contains(t, store, "webauthn_registration:5")
+
+	// 2) Login starts without knowing the user and ties the challenge to a cookie
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/webauthn/login/begin", nil)
+	w = executeHandler(authController.BeginWebAuthnLoginHandler, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	loginToken := cookieValue(w, "webauthn_session")
+	require.NotEmpty(t, loginToken)
+	assert.Contains(t, store, "webauthn_login:"+loginToken)
+
+	// 3) Finishing without the cookie is rejected
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/webauthn/login/finish", strings.NewReader("{}"))
+	w = executeHandler(authController.FinishWebAuthnLoginHandler, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 4) A malformed assertion still consumes the challenge so it cannot be retried
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/webauthn/login/finish", strings.NewReader("{}"))
+	req.AddCookie(&http.Cookie{Name: "webauthn_session", Value: loginToken})
+	w = executeHandler(authController.FinishWebAuthnLoginHandler, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NotContains(t, store, "webauthn_login:"+loginToken)
+	assert.Empty(t, cookieValue(w, "auth_token"))
+
+	// A second finish that read the challenge before the first one deleted it is turned away as well
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/webauthn/login/begin", nil)
+	w = executeHandler(authController.BeginWebAuthnLoginHandler, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	loginToken = cookieValue(w, "webauthn_session")
+	staleSession := store["webauthn_login:"+loginToken]
+	delete(store, "webauthn_login:"+loginToken)
+
+	get := mockRedis.GetFunc
+	mockRedis.GetFunc = func(ctx context.Context, key string) *redis.StringCmd {
+		if key == "webauthn_login:"+loginToken {
+			return redis.NewStringResult(staleSession, nil)
+		}
+		return get(ctx, key)
+	}
+	req, _ = http.NewRequest(http.MethodPost, "/api/auth/webauthn/login/finish", strings.NewReader("{}"))
+	req.AddCookie(&http.Cookie{Name: "webauthn_session", Value: loginToken})
+	w = executeHandler(authController.FinishWebAuthnLoginHandler, req)
+	mockRedis.GetFunc = get
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Passkey login expired")
+
+	// 5) Passkeys can be listed and deleted, but only by their owner
+	req, _ = http.NewRequest(http.MethodGet, "/api/auth/webauthn/credentials", nil)
+	w = executeAuthenticatedHandler(authController.ListWebAuthnCredentialsHandler, user, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Laptop"`)
+	assert.NotContains(t, w.Body.String(), "publicKey")
+
+	otherUser := &models.User{ID: 6, Email: "other@example.com"}
+	mockDB.BeginxFunc = newSQLMockRollbackBeginx(t)
+	w = executeDeleteCredential(authController, otherUser, "1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockDB.BeginxFunc = newSQLMockBeginx(t)
+	w = executeDeleteCredential(authController, user, "1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, credentials)
+}
+
+// newSQLMockRollbackBeginx returns a Beginx func backed by sqlmock that expects every transaction to roll back.
+func newSQLMockRollbackBeginx(t *testing.T) func() (*sqlx.Tx, error) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockSQL.Close() })
+
+	database := sqlx.NewDb(mockSQL, "sqlmock")
+	return func() (*sqlx.Tx, error) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		return database.Beginx()
+	}
+}
+
+// executeDeleteCredential calls the delete passkey handler with the id path parameter set.
+func executeDeleteCredential(authController *auth.AuthController, user *models.User, id string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodDelete, "/api/auth/webauthn/credentials/"+id, nil)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Set("user", user)
+
+	authController.DeleteWebAuthnCredentialHandler(c)
+	return w
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	// webAuthnSessionExpiry is how long a browser has to complete a registration or login ceremony
+	webAuthnSessionExpiry = 5 * time.Minute
+	// webAuthnLoginCookie identifies the pending login ceremony, the user is not known until the assertion arrives
+	webAuthnLoginCookie = "webauthn_session"
+	webAuthnCookiePath  = "/api/auth/webauthn"
+)
+
+var errWebAuthnSessionInvalid = errors.New("webauthn session is invalid or expired")
+
+// webAuthnUser adapts a user and their stored passkeys to the webauthn.User interface
+type webAuthnUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+// WebAuthnID is the user handle stored on the authenticator, it maps back to our user ID on login
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(u.user.ID))
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	displayName := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName)
+	if displayName == "" {
+		return u.user.Email
+	}
+	return displayName
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, credential := range u.credentials {
+		credentials = append(credentials, toWebAuthnCredential(credential))
+	}
+	return credentials
+}
+
+// toWebAuthnCredential converts a stored passkey into the library's credential type
+func toWebAuthnCredential(credential models.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(credential.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    credential.UserPresent,
+			UserVerified:   credential.UserVerified,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: uint32(credential.SignCount), // #nosec G115 -- stored from a uint32
+		},
+	}
+}
+
+// fromWebAuthnCredential converts a newly registered credential into the model we store
+func fromWebAuthnCredential(userID int, name string, credential *webauthn.Credential) *models.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return &models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		UserPresent:     credential.Flags.UserPresent,
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
+
+// loadWebAuthnUser fetches the user's passkeys so the library can check exclusions and assertions
+func (a *AuthController) loadWebAuthnUser(user *models.User) (*webAuthnUser, error) {
+	credentials, err := a.UserDB.FindWebAuthnCredentialsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// findDiscoverableUser resolves the user handle returned by the authenticator during a passkey login
+func (a *AuthController) findDiscoverableUser(rawID []byte, userHandle []byte) (webauthn.User, error) {
+	userID, err := strconv.Atoi(string(userHandle))
+	if err != nil {
+		return nil, fmt.Errorf("invalid user handle: %w", err)
+	}
+
+	user, err := a.UserDB.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return a.loadWebAuthnUser(user)
+}
+
+func webAuthnRegistrationKey(userID int) string {
+	return fmt.Sprintf("webauthn_registration:%d", userID)
+}
+
+func webAuthnLoginKey(token string) string {
+	return fmt.Sprintf("webauthn_login:%s", token)
+}
+
+// storeWebAuthnSession keeps the ceremony's challenge in redis until the browser responds
+func (a *AuthController) storeWebAuthnSession(key string, session *webauthn.SessionData) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webauthn session: %w", err)
+	}
+
+	if err := a.RedisCache.Set(context.Background(), key, value, webAuthnSessionExpiry).Err(); err != nil {
+		return fmt.Errorf("failed to store webauthn session: %w", err)
+	}
+	return nil
+}
+
+// takeWebAuthnSession returns and deletes the ceremony's session so each challenge can only be answered once
+func (a *AuthController) takeWebAuthnSession(key string) (*webauthn.SessionData, error) {
+	ctx := context.Background()
+
+	value, err := a.RedisCache.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errWebAuthnSessionInvalid
+		}
+		return nil, fmt.Errorf("failed to get webauthn session: %w", err)
+	}
+
+	// Only the request whose delete removed the session may use it, a concurrent one answering the same challenge
+	// finds it already gone
+	deleted, err := a.RedisCache.Del(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete webauthn session: %w", err)
+	}
+	if deleted != 1 {
+		return nil, errWebAuthnSessionInvalid
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(value), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webauthn session: %w", err)
+	}
+	return &session, nil
+}
+
+// generateWebAuthnLoginToken returns a random identifier for a pending passkey login
+func generateWebAuthnLoginToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate webauthn session token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
@@ -3,9 +3,6 @@ package bank
 import (
 	"log/slog"
 	"net/http"
-	"regexp"
-	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -13,140 +10,124 @@ import (
 	"github.com/jalil32/go-auth-module/internal/models"
 )
 
+// previewRows is how many parsed transactions the preview returns
+const previewRows = 50
+
+// Import modes say what an upload does with rows of the statement that cannot be read
+const (
+	importAllOrNothing = "all_or_nothing" // the default, nothing is stored and every rejected row is reported
+	importSkipInvalid  = "skip_invalid"   // the other rows are stored and the rejected rows are reported
+)
+
+// BankRepository stores bank transactions and CSV profiles. Transactions are scoped to the organization whose members
+// share them, CSV profiles to the user who saved them.
+type BankRepository interface {
+	CreateTransactions(ext sqlx.Ext, transactions []models.Transaction) ([]models.Transaction, error)
+	FindTransactionsByOrganization(organizationID int) ([]models.Transaction, error)
+	FindTransaction(organizationID int, transactionID int) (*models.Transaction, error)
+	DeleteTransaction(ext sqlx.Ext, organizationID int, transactionID int) (bool, error)
+	FindCSVProfilesByUserID(userID int) ([]models.CSVProfile, error)
+	FindCSVProfile(userID int, id int) (*models.CSVProfile, error)
+	CreateCSVProfile(ext sqlx.Ext, profile *models.CSVProfile) error
+	DeleteCSVProfile(ext sqlx.Ext, userID int, id int) (bool, error)
+	Beginx() (*sqlx.Tx, error)
+}
+
 type BankController struct {
 	Logger *slog.Logger
-	DB     *sqlx.DB
+	DB     BankRepository
 }
 
-func NewBankController(logger *slog.Logger, db *sqlx.DB) *BankController {
+func NewBankController(logger *slog.Logger, db BankRepository) *BankController {
 	return &BankController{
 		Logger: logger,
 		DB:     db,
 	}
 }
 
+// UploadBankStatement stores the statement's transactions in the organization set by RequireOrganization, recording
+// the signed in user as the uploader.
+// The "mode" query parameter decides what happens to rows that cannot be read, see importAllOrNothing and importSkipInvalid.
 func (bc *BankController) UploadBankStatement(c *gin.Context) {
-	var records [][]interface{} // two-dimensional slice to represent transaction history table
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
 
-	// Bind the JSON data from the request body to the records interface
-	if err := c.ShouldBindJSON(&records); err != nil {
-		bc.Logger.Error("Failed to parse JSON", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON"})
+	mode := c.DefaultQuery("mode", importAllOrNothing)
+	if mode != importAllOrNothing && mode != importSkipInvalid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be all_or_nothing or skip_invalid"})
 		return
 	}
 
-	// Regex pattern to validate date and amount values
-	datePattern := regexp.MustCompile(`^\d{1,2}/\d{1,2}/\d{4}$`) // e.g. "11/11/2011"
-	amountPattern := regexp.MustCompile(`^-?\d+(\.\d{1,2})?$`)   // e.g. "-23.50", "11.40", or "100"
-
-	// Create a slice to store the transactions
-	var transactions []models.Transaction
-
-	// Grab the index of each header to identify which column is date, amount and description
-	header := records[0]
-	dateIndex, amountIndex, descriptionIndex := -1, -1, -1
-	for i, h := range header {
-		switch h {
-		case "Date":
-			dateIndex = i
-		case "Amount":
-			amountIndex = i
-		case "Description":
-			descriptionIndex = i
-		}
+	// 1) Parse the statement file or JSON rows
+	result, ok := bc.readStatement(c, owner)
+	if !ok {
+		return
 	}
 
-	if dateIndex == -1 || amountIndex == -1 || descriptionIndex == -1 {
-		bc.Logger.Error("Missing required headers")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required headers"})
+	if len(result.Rejected) > 0 && mode == importAllOrNothing {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Some rows could not be read, nothing was imported",
+			"rejected": result.Rejected,
+		})
 		return
 	}
 
-	// Iterate over the records and store them in the slice
-	for _, record := range records[1:] { // Skip the header row
-
-		// Date data
-		dateStr, ok := record[dateIndex].(string)
-		if !ok {
-			bc.Logger.Error("Date field is not a string")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Date field must be a string"})
-			return
-		}
-		if !datePattern.MatchString(dateStr) { // User regex to validate date format
-			bc.Logger.Error("Invalid date format", "date", dateStr)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
-			return
-		}
-		date, err := time.Parse("2/1/2006", dateStr) // DD/MM.YYYY
-		if err != nil {
-			bc.Logger.Error("Failed to parse date", "error", err) // User regex to validate amount format
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse date"})
-			return
-		}
-
-		// Amount data
-		amountStr, ok := record[amountIndex].(string)
-		if !ok {
-			bc.Logger.Error("Amount field is not a string")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount field must be a string"})
-			return
-		}
-		if !amountPattern.MatchString(amountStr) {
-			bc.Logger.Error("Invalid amount format", "amount", amountStr)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount format"})
-			return
-		}
-		amountDollar, err := strconv.ParseFloat(amountStr, 64)
-		if err != nil {
-			bc.Logger.Error("Failed to parse amount", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse amount"})
-			return
-		}
-		amount := int(amountDollar * 100) // Convert dollars to cents
-
-		// Description data
-		description, success := record[descriptionIndex].(string)
-		if !success {
-			// If type assertion fails = record[descriptionIndex] is not a valid string
-			description = "No description" // Default description
-		}
-
-		// TODO: [WEALT-15] Once Jalil completes authentication, replace the hardcoded User ID with the authenticated User ID
-		transaction := models.Transaction{
-			UserId:      1, // Hardcoded User ID for now
-			Date:        date,
-			AmountCents: amount,
-			Description: description, // Default description is "No description"
-		}
-
-		// Insert the transaction into the database
-		transactionId, err := bc.insertTransaction(transaction)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert transaction"})
-			return
-		}
-		transaction.TransactionId = transactionId
-		transactions = append(transactions, transaction)
-
-		// [Optional] Log the transaction
-		bc.Logger.Info("Transaction inserted successfully", "transaction", transaction)
+	transactions := result.Transactions
+	for i := range transactions {
+		transactions[i].UserId = owner.UserID
+		transactions[i].OrganizationId = owner.OrganizationID
+	}
 
+	// 2) Insert the transactions in batches, a statement is stored completely or not at all.
+	// Transactions whose FITID is already stored are skipped, so a statement can be uploaded again.
+	var inserted []models.Transaction
+	if !bc.inTransaction(c, "Failed to insert transactions", func(tx *sqlx.Tx) error {
+		var err error
+		inserted, err = bc.DB.CreateTransactions(tx, transactions)
+		return err
+	}) {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully", "transactions": transactions})
+	duplicates := len(transactions) - len(inserted)
+	bc.Logger.Info("Transactions inserted successfully", "userID", owner.UserID, "organizationID", owner.OrganizationID,
+		"count", len(inserted), "duplicates", duplicates, "rejected", len(result.Rejected))
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "File uploaded successfully",
+		"transactions": inserted,
+		"duplicates":   duplicates,
+		"rejected":     result.Rejected,
+	})
 }
 
-func (bc *BankController) insertTransaction(transaction models.Transaction) (int, error) {
-	// Insert the transaction into the database
-	query := `INSERT INTO bank_transactions (user_id, date, amount_cents, description)
-			  VALUES ($1, $2, $3, $4) RETURNING transaction_id`
-	var transactionId int
-	err := bc.DB.QueryRowx(query, transaction.UserId, transaction.Date, transaction.AmountCents, transaction.Description).Scan(&transactionId)
-	if err != nil {
-		bc.Logger.Error("Failed to insert transaction", "details", transaction, "error", err)
-		return -1, err
+// PreviewBankStatementHandler parses a statement like UploadBankStatement but stores nothing, so the user can check
+// the profile reads it correctly. It returns the detected format, encoding and delimiter, the header, the first rows
+// and every row that could not be read.
+func (bc *BankController) PreviewBankStatementHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	result, ok := bc.readStatement(c, owner)
+	if !ok {
+		return
+	}
+
+	total := len(result.Transactions)
+	if total > previewRows {
+		result.Transactions = result.Transactions[:previewRows]
 	}
 
-	// Return the transaction ID of the newly inserted transaction
-	return transactionId, nil
+	c.JSON(http.StatusOK, gin.H{
+		"format":       result.Format,
+		"encoding":     result.Encoding,
+		"delimiter":    result.Delimiter,
+		"header":       result.Header,
+		"transactions": result.Transactions,
+		"total":        total,
+		"rejected":     result.Rejected,
+	})
 }
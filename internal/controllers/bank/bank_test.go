@@ -0,0 +1,489 @@
+package bank_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/bank"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// executeBankHandler runs a handler as the principal, acting in the organization as a member with the path parameters set.
+func executeBankHandler(handler gin.HandlerFunc, principal *models.Principal, organizationID int, params gin.Params, body string) *httptest.ResponseRecorder {
+	return executeBankHandlerAs(handler, principal, organizationID, models.OrganizationRoleMember, params, body)
+}
+
+// executeBankHandlerAs runs a handler as the principal holding the role in the organization
+func executeBankHandlerAs(handler gin.HandlerFunc, principal *models.Principal, organizationID int, role models.OrganizationRole, params gin.Params, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	if principal != nil {
+		c.Set("principal", principal)
+		c.Set("organization", &models.Membership{OrganizationID: organizationID, Role: role})
+	}
+
+	handler(c)
+	return w
+}
+
+// executeBankUpload posts the CSV file and form fields to a handler as multipart/form-data
+func executeBankUpload(t *testing.T, handler gin.HandlerFunc, principal *models.Principal, organizationID int, fields map[string]string, file string) *httptest.ResponseRecorder {
+	return executeBankUploadTo(t, handler, "/", principal, organizationID, fields, file)
+}
+
+// executeBankUploadTo posts the file to the target, which carries the query parameters
+func executeBankUploadTo(t *testing.T, handler gin.HandlerFunc, target string, principal *models.Principal, organizationID int, fields map[string]string, file string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	part, err := form.CreateFormFile("file", "statement.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(file))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, target, &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	c.Set("principal", principal)
+	c.Set("organization", &models.Membership{OrganizationID: organizationID, Role: models.OrganizationRoleMember})
+
+	handler(c)
+	return w
+}
+
+func userPrincipal(id int) *models.Principal {
+	return &models.Principal{Type: models.PrincipalUser, User: &models.User{ID: id, Email: "user" + strconv.Itoa(id) + "@example.com"}}
+}
+
+func idParam(id int) gin.Params {
+	return gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+}
+
+const statement = `[["Date", "Amount", "Description"], ["11/11/2024", "-23.50", "Groceries"], ["12/11/2024", "100", "Salary"]]`
+
+func TestBankController_SharedTransactions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice, bob, carol := userPrincipal(5), userPrincipal(6), userPrincipal(7)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	list := func(principal *models.Principal, organizationID int) []models.Transaction {
+		w := executeBankHandler(controller.ListTransactionsHandler, principal, organizationID, nil, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Transactions []models.Transaction `json:"transactions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Transactions
+	}
+
+	// 1) Uploads are stored in the organization, recording the authenticated user as the uploader
+	w := executeBankHandler(controller.UploadBankStatement, alice, household, nil, statement)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, repo.rows, 2)
+	for _, row := range repo.rows {
+		assert.Equal(t, 5, row.UserId)
+		assert.Equal(t, household, row.OrganizationId)
+	}
+	aliceTransaction := repo.rows[0].TransactionId
+
+	// 2) Every member of the organization can list and read them
+	assert.Len(t, list(alice, household), 2)
+	assert.Len(t, list(bob, household), 2)
+
+	w = executeBankHandler(controller.GetTransactionHandler, bob, household, idParam(aliceTransaction), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 3) Members cannot delete what other members uploaded
+	w = executeBankHandler(controller.DeleteTransactionHandler, bob, household, idParam(aliceTransaction), "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, list(alice, household), 2)
+
+	// 4) Uploads of other members join the same list
+	w = executeBankHandler(controller.UploadBankStatement, bob, household, nil, statement)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, list(alice, household), 4)
+	bobTransaction := repo.rows[2].TransactionId
+
+	// 5) Nothing is visible from another organization
+	assert.Empty(t, list(alice, 8))
+	w = executeBankHandler(controller.GetTransactionHandler, alice, 8, idParam(aliceTransaction), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = executeBankHandlerAs(controller.DeleteTransactionHandler, alice, 8, models.OrganizationRoleOwner, idParam(aliceTransaction), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 6) The uploader can delete their own transaction
+	w = executeBankHandler(controller.DeleteTransactionHandler, alice, household, idParam(aliceTransaction), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, list(bob, household), 3)
+
+	// 7) Owners and admins can delete any of the organization's transactions
+	w = executeBankHandlerAs(controller.DeleteTransactionHandler, carol, household, models.OrganizationRoleAdmin, idParam(bobTransaction), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, list(alice, household), 2)
+}
+
+func TestBankController_RequiresOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	// 1) Unauthenticated requests are rejected
+	w := executeBankHandler(controller.UploadBankStatement, nil, household, nil, statement)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 2) Transactions belong to people, an API key whose creator is gone cannot upload or read them
+	service := &models.Principal{Type: models.PrincipalService, APIKey: &models.APIKey{ID: 1, Scopes: []string{"bank:upload"}, OrganizationID: &household}}
+	w = executeBankHandler(controller.UploadBankStatement, service, household, nil, statement)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeBankHandler(controller.ListTransactionsHandler, service, household, nil, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, repo.rows)
+
+	// 3) Invalid transaction IDs are rejected
+	w = executeBankHandler(controller.GetTransactionHandler, userPrincipal(5), household, gin.Params{{Key: "id", Value: "abc"}}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBankController_APIKeyUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice, bob := userPrincipal(5), userPrincipal(6)
+	owner := 5
+	service := &models.Principal{Type: models.PrincipalService, APIKey: &models.APIKey{ID: 1, Scopes: []string{"bank:upload", "bank:read"}, OrganizationID: &household, CreatedBy: &owner}}
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	// 1) A batch job uploads with the key, the key's owner is recorded as the uploader in its organization
+	w := executeBankUpload(t, controller.UploadBankStatement, service, household, nil, "Date;Amount;Description\n11/11/2024;-23.50;Groceries\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, repo.rows, 1)
+	assert.Equal(t, 5, repo.rows[0].UserId)
+	assert.Equal(t, household, repo.rows[0].OrganizationId)
+
+	// 2) The key, its owner and the other members see the same transactions
+	for _, principal := range []*models.Principal{service, alice, bob} {
+		w = executeBankHandler(controller.ListTransactionsHandler, principal, household, nil, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Groceries")
+	}
+
+	// 3) Other members cannot delete what the key uploaded
+	w = executeBankHandler(controller.DeleteTransactionHandler, bob, household, idParam(repo.rows[0].TransactionId), "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeBankHandler(controller.GetTransactionHandler, service, household, idParam(repo.rows[0].TransactionId), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBankController_CSVUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice, bob := userPrincipal(5), userPrincipal(6)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	// 1) A CSV file is read with the default profile
+	w := executeBankUpload(t, controller.UploadBankStatement, alice, household, nil, "Date;Amount;Description\n11/11/2024;-23.50;Groceries\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, repo.rows, 1)
+	assert.Equal(t, -2350, repo.rows[0].AmountCents)
+	assert.Equal(t, 5, repo.rows[0].UserId)
+
+	// 2) Alice saves a profile for her bank's layout
+	w = executeBankHandler(controller.CreateCSVProfileHandler, alice, household, nil, `{
+		"name": "My bank", "headerRow": 2, "dateColumn": "Booked", "dateFormats": ["YYYY-MM-DD"],
+		"descriptionColumn": "Payee", "debitColumn": "Out", "creditColumn": "In", "decimalSeparator": ","
+	}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var profile models.CSVProfile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	require.NotZero(t, profile.ID)
+	profileID := strconv.Itoa(profile.ID)
+
+	bankFile := "Account 1234\nBooked,Payee,Out,In\n2024-11-01,Rent,\"1.250,00\",\n2024-11-15,Salary,,\"3.100,45\"\n"
+
+	// 3) The preview reads the file with the profile without storing anything
+	w = executeBankUpload(t, controller.PreviewBankStatementHandler, alice, household, map[string]string{"profileId": profileID}, bankFile)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var preview struct {
+		Encoding     string               `json:"encoding"`
+		Delimiter    string               `json:"delimiter"`
+		Header       []string             `json:"header"`
+		Total        int                  `json:"total"`
+		Transactions []models.Transaction `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, "utf-8", preview.Encoding)
+	assert.Equal(t, ",", preview.Delimiter)
+	assert.Equal(t, []string{"Booked", "Payee", "Out", "In"}, preview.Header)
+	assert.Equal(t, 2, preview.Total)
+	require.Len(t, preview.Transactions, 2)
+	assert.Equal(t, -125000, preview.Transactions[0].AmountCents)
+	assert.Equal(t, 310045, preview.Transactions[1].AmountCents)
+	assert.Len(t, repo.rows, 1)
+
+	// 4) The upload stores them
+	w = executeBankUpload(t, controller.UploadBankStatement, alice, household, map[string]string{"profileId": profileID}, bankFile)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, repo.rows, 3)
+
+	// 5) Bob cannot see, use or delete Alice's profile
+	w = executeBankHandler(controller.ListCSVProfilesHandler, bob, household, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"profiles": []}`, w.Body.String())
+
+	w = executeBankUpload(t, controller.UploadBankStatement, bob, household, map[string]string{"profileId": profileID}, bankFile)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = executeBankHandler(controller.DeleteCSVProfileHandler, bob, household, gin.Params{{Key: "id", Value: profileID}}, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, repo.rows, 3)
+
+	// 6) Rows that cannot be read are reported with their line and nothing is stored
+	w = executeBankUpload(t, controller.UploadBankStatement, alice, household, map[string]string{"profileId": profileID}, bankFile+"2024-13-01,Refund,,\"5,00\"\n")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": "Some rows could not be read, nothing was imported", "rejected": [{"line": 5, "reason": "invalid date \"2024-13-01\""}]}`, w.Body.String())
+	assert.Len(t, repo.rows, 3)
+
+	// 7) Alice can delete her profile
+	w = executeBankHandler(controller.DeleteCSVProfileHandler, alice, household, gin.Params{{Key: "id", Value: profileID}}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, repo.profiles)
+}
+
+func TestBankController_InvalidStatements(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice := userPrincipal(5)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty statement", `[]`},
+		{"missing headers", `[["Date", "Description"], ["11/11/2024", "Groceries"]]`},
+		{"amount that is not a string", `[["Date", "Amount", "Description"], ["11/11/2024", 12, "Groceries"]]`},
+		{"invalid date", `[["Date", "Amount", "Description"], ["2024/11/11", "12.00", "Groceries"]]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeBankHandler(controller.UploadBankStatement, alice, household, nil, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+	assert.Empty(t, repo.rows)
+
+	// Profiles that could not read a statement are rejected
+	w := executeBankHandler(controller.CreateCSVProfileHandler, alice, household, nil, `{"name": "Broken", "dateColumn": "Date", "descriptionColumn": "Memo"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, repo.profiles)
+}
+
+func TestBankController_OFXReimport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice, bob := userPrincipal(5), userPrincipal(6)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	ofx := "OFXHEADER:100\nDATA:OFXSGML\n\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKACCTFROM><ACCTID>123</BANKACCTFROM><BANKTRANLIST>" +
+		"<STMTTRN><DTPOSTED>20241104<TRNAMT>-42.17<FITID>A1<NAME>Coffee</STMTTRN>" +
+		"<STMTTRN><DTPOSTED>20241105<TRNAMT>100.00<FITID>A2<NAME>Refund</STMTTRN>" +
+		"</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>"
+
+	upload := func(principal *models.Principal, organizationID int, file string) (int, int) {
+		w := executeBankUpload(t, controller.UploadBankStatement, principal, organizationID, nil, file)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Transactions []models.Transaction `json:"transactions"`
+			Duplicates   int                  `json:"duplicates"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return len(response.Transactions), response.Duplicates
+	}
+
+	// 1) The first upload stores every transaction with its FITID
+	inserted, duplicates := upload(alice, household, ofx)
+	assert.Equal(t, 2, inserted)
+	assert.Equal(t, 0, duplicates)
+	assert.Equal(t, "123", repo.rows[0].Account)
+	assert.Equal(t, "A1", repo.rows[0].FitId)
+
+	// 2) Uploading an overlapping statement only stores the new transactions
+	next := strings.Replace(ofx, "<FITID>A1<NAME>Coffee", "<FITID>A3<NAME>Lunch", 1)
+	inserted, duplicates = upload(alice, household, next)
+	assert.Equal(t, 1, inserted)
+	assert.Equal(t, 1, duplicates)
+	assert.Len(t, repo.rows, 3)
+
+	// 3) The transactions are shared, another member importing the same file stores nothing new
+	inserted, duplicates = upload(bob, household, ofx)
+	assert.Equal(t, 0, inserted)
+	assert.Equal(t, 2, duplicates)
+
+	// 4) Another organization importing the same file gets its own copy
+	inserted, duplicates = upload(bob, 8, ofx)
+	assert.Equal(t, 2, inserted)
+	assert.Equal(t, 0, duplicates)
+}
+
+func TestBankController_BusinessStatements(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice := userPrincipal(5)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	camt := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"><BkToCstmrStmt><Stmt>
+<Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+<Ntry><Amt Ccy="EUR">1190.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+<BookgDt><Dt>2024-11-04</Dt></BookgDt><ValDt><Dt>2024-11-05</Dt></ValDt><AcctSvcrRef>REF-1</AcctSvcrRef>
+<NtryDtls><TxDtls><RltdPties><Dbtr><Nm>Beispiel AG</Nm></Dbtr></RltdPties><RmtInf><Ustrd>Rechnung 117</Ustrd></RmtInf></TxDtls></NtryDtls>
+</Ntry></Stmt></BkToCstmrStmt></Document>`
+
+	mt940 := ":20:1\n:25:NL91INGB0001234567\n:60F:C241101EUR0,00\n:61:2411041105D12,50NTRFNONREF//B-1\n:86:/NAME/J. de Vries/REMI/Lunch/\n:62F:C241105EUR0,00\n"
+
+	// 1) The format is detected and the statement's details are stored
+	w := executeBankUpload(t, controller.UploadBankStatement, alice, household, nil, camt)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, repo.rows, 1)
+
+	row := repo.rows[0]
+	assert.Equal(t, 119000, row.AmountCents)
+	assert.Equal(t, "EUR", row.Currency)
+	assert.Equal(t, "Beispiel AG", row.Counterparty)
+	assert.Equal(t, "Rechnung 117", row.RemittanceInfo)
+	require.NotNil(t, row.ValueDate)
+	assert.Equal(t, "2024-11-05", row.ValueDate.Format("2006-01-02"))
+
+	w = executeBankUpload(t, controller.PreviewBankStatementHandler, alice, household, nil, mt940)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"format":"mt940"`)
+
+	w = executeBankUpload(t, controller.UploadBankStatement, alice, household, nil, mt940)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, repo.rows, 2)
+	assert.Equal(t, -1250, repo.rows[1].AmountCents)
+	assert.Equal(t, "J. de Vries", repo.rows[1].Counterparty)
+
+	// 2) Statements are deduplicated by the bank's reference like OFX files
+	w = executeBankUpload(t, controller.UploadBankStatement, alice, household, nil, camt)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"duplicates":1`)
+	assert.Len(t, repo.rows, 2)
+}
+
+func TestBankController_ImportModes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	household := 7
+	alice := userPrincipal(5)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	file := "Date,Amount,Description\n01/11/2024,-10.00,Coffee\n31/31/2024,-5.00,Lunch\n03/11/2024,ten,Dinner\n04/11/2024,250.00,Refund\n"
+
+	var response struct {
+		Transactions []models.Transaction `json:"transactions"`
+		Rejected     []struct {
+			Line   int    `json:"line"`
+			Reason string `json:"reason"`
+		} `json:"rejected"`
+	}
+
+	// 1) By default nothing is stored and every rejected row is reported
+	w := executeBankUpload(t, controller.UploadBankStatement, alice, household, nil, file)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Rejected, 2)
+	assert.Equal(t, 3, response.Rejected[0].Line)
+	assert.Equal(t, 4, response.Rejected[1].Line)
+	assert.Contains(t, response.Rejected[1].Reason, "ten")
+	assert.Empty(t, repo.rows)
+
+	// 2) The preview reports them too
+	w = executeBankUpload(t, controller.PreviewBankStatementHandler, alice, household, nil, file)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Transactions, 2)
+	assert.Len(t, response.Rejected, 2)
+
+	// 3) Skipping invalid rows stores the others
+	w = executeBankUploadTo(t, controller.UploadBankStatement, "/?mode=skip_invalid", alice, household, nil, file)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Transactions, 2)
+	assert.Len(t, response.Rejected, 2)
+	require.Len(t, repo.rows, 2)
+	assert.Equal(t, "Coffee", repo.rows[0].Description)
+	assert.Equal(t, "Refund", repo.rows[1].Description)
+
+	// 4) Unknown modes are rejected before the statement is read
+	w = executeBankUploadTo(t, controller.UploadBankStatement, "/?mode=partial", alice, household, nil, file)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Len(t, repo.rows, 2)
+}
+
+func TestBankController_LargeStatement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &memoryTransactions{t: t}
+	controller := bank.NewBankController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+
+	var file strings.Builder
+	file.WriteString("Date,Amount,Description\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&file, "%02d/11/2024,-%d.%02d,Card payment %d\n", i%28+1, i%500, i%100, i)
+	}
+
+	w := executeBankUpload(t, controller.UploadBankStatement, userPrincipal(5), 7, nil, file.String())
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, repo.rows, 50000)
+	assert.Contains(t, w.Body.String(), `"rejected":[]`)
+}
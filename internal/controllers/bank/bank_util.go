@@ -0,0 +1,73 @@
+package bank
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// owner identifies who uploads transactions and which organization's transactions a request may see and change
+type owner struct {
+	UserID         int
+	OrganizationID int
+	Role           models.OrganizationRole
+}
+
+// currentOwner returns the signed in user and their organization, set by AuthMiddleware and RequireOrganization.
+// Transactions are uploaded by a person, so an API key uploads as the user who created it, in the organization it is
+// bound to. RequireOrganization checks the creator can still sign in and is still a member.
+func currentOwner(c *gin.Context) (owner, bool) {
+	principal, ok := middleware.CurrentPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return owner{}, false
+	}
+
+	var userID int
+	switch {
+	case principal.Type == models.PrincipalUser && principal.User != nil:
+		userID = principal.User.ID
+	case principal.Type == models.PrincipalService && principal.APIKey != nil && principal.APIKey.CreatedBy != nil:
+		userID = *principal.APIKey.CreatedBy
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Bank transactions need a user or an API key with an owner"})
+		return owner{}, false
+	}
+
+	organization, ok := middleware.CurrentOrganization(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No active organization"})
+		return owner{}, false
+	}
+
+	return owner{UserID: userID, OrganizationID: organization.OrganizationID, Role: organization.Role}, true
+}
+
+// inTransaction runs fn in a transaction and responds with the failure message if it cannot be committed
+func (bc *BankController) inTransaction(c *gin.Context, failure string, fn func(tx *sqlx.Tx) error) bool {
+	tx, err := bc.DB.Beginx()
+	if err != nil {
+		bc.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		bc.Logger.Error(failure, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	if err := tx.Commit(); err != nil {
+		bc.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,122 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// memoryTransactions is an in-memory BankRepository that applies the same filters as the SQL queries.
+type memoryTransactions struct {
+	t        *testing.T
+	nextID   int
+	rows     []models.Transaction
+	profiles []models.CSVProfile
+}
+
+func (m *memoryTransactions) CreateTransactions(ext sqlx.Ext, transactions []models.Transaction) ([]models.Transaction, error) {
+	inserted := []models.Transaction{}
+	for _, transaction := range transactions {
+		if transaction.FitId != "" && m.stored(transaction) {
+			continue
+		}
+
+		m.nextID++
+		transaction.TransactionId = m.nextID
+		m.rows = append(m.rows, transaction)
+		inserted = append(inserted, transaction)
+	}
+	return inserted, nil
+}
+
+// stored reports whether the organization already has a transaction with the same account and FITID
+func (m *memoryTransactions) stored(transaction models.Transaction) bool {
+	for _, row := range m.rows {
+		if row.OrganizationId == transaction.OrganizationId && row.Account == transaction.Account && row.FitId == transaction.FitId {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memoryTransactions) FindTransactionsByOrganization(organizationID int) ([]models.Transaction, error) {
+	transactions := []models.Transaction{}
+	for _, row := range m.rows {
+		if row.OrganizationId == organizationID {
+			transactions = append(transactions, row)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *memoryTransactions) FindTransaction(organizationID int, transactionID int) (*models.Transaction, error) {
+	for _, row := range m.rows {
+		if row.OrganizationId == organizationID && row.TransactionId == transactionID {
+			found := row
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTransactions) DeleteTransaction(ext sqlx.Ext, organizationID int, transactionID int) (bool, error) {
+	for i, row := range m.rows {
+		if row.OrganizationId == organizationID && row.TransactionId == transactionID {
+			m.rows = append(m.rows[:i], m.rows[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryTransactions) FindCSVProfilesByUserID(userID int) ([]models.CSVProfile, error) {
+	profiles := []models.CSVProfile{}
+	for _, profile := range m.profiles {
+		if profile.UserID == userID {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+func (m *memoryTransactions) FindCSVProfile(userID int, id int) (*models.CSVProfile, error) {
+	for _, profile := range m.profiles {
+		if profile.UserID == userID && profile.ID == id {
+			found := profile
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTransactions) CreateCSVProfile(ext sqlx.Ext, profile *models.CSVProfile) error {
+	m.nextID++
+	profile.ID = m.nextID
+	m.profiles = append(m.profiles, *profile)
+	return nil
+}
+
+func (m *memoryTransactions) DeleteCSVProfile(ext sqlx.Ext, userID int, id int) (bool, error) {
+	for i, profile := range m.profiles {
+		if profile.UserID == userID && profile.ID == id {
+			m.profiles = append(m.profiles[:i], m.profiles[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Beginx returns a sqlmock transaction on a fresh connection that expects to be committed.
+func (m *memoryTransactions) Beginx() (*sqlx.Tx, error) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(m.t, err)
+	m.t.Cleanup(func() { mockSQL.Close() })
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	return sqlx.NewDb(mockSQL, "sqlmock").Beginx()
+}
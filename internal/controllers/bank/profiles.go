@@ -0,0 +1,100 @@
+package bank
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/bank/statement"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// ListCSVProfilesHandler returns the signed in user's CSV profiles
+func (bc *BankController) ListCSVProfilesHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	profiles, err := bc.DB.FindCSVProfilesByUserID(owner.UserID)
+	if err != nil {
+		bc.Logger.Error("Failed to find csv profiles", "userID", owner.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// CreateCSVProfileHandler saves how to read a bank's CSV statements, so later uploads can name it with profileId
+func (bc *BankController) CreateCSVProfileHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	// 1) Bind and check the profile
+	var profile models.CSVProfile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if profile.HeaderRow == 0 {
+		profile.HeaderRow = 1
+	}
+	if profile.DecimalSeparator == "" {
+		profile.DecimalSeparator = "."
+	}
+	if profile.DateFormats == nil {
+		profile.DateFormats = []string{}
+	}
+
+	if err := statement.ValidateProfile(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2) Store it for the signed in user
+	profile.ID = 0
+	profile.UserID = owner.UserID
+	if !bc.inTransaction(c, "Failed to create profile", func(tx *sqlx.Tx) error {
+		return bc.DB.CreateCSVProfile(tx, &profile)
+	}) {
+		return
+	}
+
+	bc.Logger.Info("CSV profile created", "userID", owner.UserID, "profileID", profile.ID)
+	c.JSON(http.StatusCreated, profile)
+}
+
+// DeleteCSVProfileHandler deletes one of the signed in user's CSV profiles
+func (bc *BankController) DeleteCSVProfileHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+		return
+	}
+
+	var deleted bool
+	if !bc.inTransaction(c, "Failed to delete profile", func(tx *sqlx.Tx) error {
+		deleted, err = bc.DB.DeleteCSVProfile(tx, owner.UserID, profileID)
+		return err
+	}) {
+		return
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted"})
+}
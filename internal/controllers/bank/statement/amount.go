@@ -0,0 +1,113 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var errMissingAmount = errors.New("missing amount")
+
+// amountPattern is an unsigned amount once currency symbols and thousands separators are removed, e.g. "1234.5"
+var amountPattern = regexp.MustCompile(`^\d+(\.\d{1,2})?$`)
+
+// ParseAmount converts an amount as written on a statement to cents, e.g. "-1,234.50", "(12.00)" or "1.234,50 €".
+// The decimal separator is "." or ",", the other one is read as a thousands separator between groups of three digits.
+func ParseAmount(value string, decimalSeparator string) (int, error) {
+	// 1) Drop currency symbols and spaces, including the non-breaking spaces some banks group digits with
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\u00a0', '\u202f', '\'', '$', '€', '£', '¥':
+			return -1
+		}
+		return r
+	}, value)
+
+	if cleaned == "" {
+		return 0, errMissingAmount
+	}
+
+	// 2) Negative amounts are written with a leading or trailing minus, or in parentheses
+	negative := false
+	switch {
+	case strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")"):
+		negative, cleaned = true, cleaned[1:len(cleaned)-1]
+	case strings.HasPrefix(cleaned, "-"):
+		negative, cleaned = true, cleaned[1:]
+	case strings.HasSuffix(cleaned, "-"):
+		negative, cleaned = true, cleaned[:len(cleaned)-1]
+	case strings.HasPrefix(cleaned, "+"):
+		cleaned = cleaned[1:]
+	}
+
+	// 3) Normalise the separators so the decimal point is a ".", a thousands separator is only read between groups
+	// of three digits, so "12,50" with a "." decimal separator is an error rather than 1250
+	decimal, thousands := ".", ","
+	if decimalSeparator == "," {
+		decimal, thousands = ",", "."
+	}
+
+	whole, fraction, hasFraction := strings.Cut(cleaned, decimal)
+	if strings.Contains(whole, thousands) {
+		groups := strings.Split(whole, thousands)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, fmt.Errorf("invalid amount %q", value)
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+
+	cleaned = whole
+	if hasFraction {
+		cleaned += "." + fraction
+	}
+
+	if !amountPattern.MatchString(cleaned) {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	// 4) Convert to cents without going through floats, which cannot hold most amounts exactly
+	whole, fraction, _ = strings.Cut(cleaned, ".")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	cents, err := strconv.Atoi(whole + fraction)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// splitAmount combines separate debit and credit columns into one signed amount, money out is negative
+func splitAmount(debit string, credit string, decimalSeparator string) (int, error) {
+	debitCents, debitErr := ParseAmount(debit, decimalSeparator)
+	if debitErr != nil && !errors.Is(debitErr, errMissingAmount) {
+		return 0, debitErr
+	}
+
+	creditCents, creditErr := ParseAmount(credit, decimalSeparator)
+	if creditErr != nil && !errors.Is(creditErr, errMissingAmount) {
+		return 0, creditErr
+	}
+
+	if debitErr != nil && creditErr != nil {
+		return 0, errMissingAmount
+	}
+
+	// Some banks write debits as negative numbers, others as positive ones
+	if debitCents < 0 {
+		debitCents = -debitCents
+	}
+	return creditCents - debitCents, nil
+}
@@ -0,0 +1,301 @@
+package statement
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// camtAccount is a statement's Acct, identified by IBAN or by another scheme
+type camtAccount struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+	Ccy   string `xml:"Ccy"`
+}
+
+// camtDate is a date written as a day (Dt) or a moment (DtTm)
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+// camtAmount is an amount with its currency attribute
+type camtAmount struct {
+	Value string `xml:",chardata"`
+	Ccy   string `xml:"Ccy,attr"`
+}
+
+// camtStatus is an entry's Sts, a code in version 2 and a Cd element in later versions
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Cd    string `xml:"Cd"`
+}
+
+// camtParty is a debtor or creditor. Version 2 names it directly, later versions wrap it in Pty.
+type camtParty struct {
+	Nm    string `xml:"Nm"`
+	PtyNm string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	if p.Nm != "" {
+		return strings.TrimSpace(p.Nm)
+	}
+	return strings.TrimSpace(p.PtyNm)
+}
+
+// camtDetails is one TxDtls of an entry, a batch booking has one for every payment in it
+type camtDetails struct {
+	AcctSvcrRef string     `xml:"Refs>AcctSvcrRef"`
+	Amt         camtAmount `xml:"Amt"`
+	TxAmt       camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Debtor      camtParty  `xml:"RltdPties>Dbtr"`
+	Creditor    camtParty  `xml:"RltdPties>Cdtr"`
+	Ustrd       []string   `xml:"RmtInf>Ustrd"`
+	StrdRef     []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AddtlTxInf  string     `xml:"AddtlTxInf"`
+}
+
+// camtEntry is one Ntry of a statement
+type camtEntry struct {
+	Amt          camtAmount    `xml:"Amt"`
+	CdtDbtInd    string        `xml:"CdtDbtInd"`
+	RvslInd      bool          `xml:"RvslInd"`
+	Sts          camtStatus    `xml:"Sts"`
+	BookgDt      camtDate      `xml:"BookgDt"`
+	ValDt        camtDate      `xml:"ValDt"`
+	AcctSvcrRef  string        `xml:"AcctSvcrRef"`
+	Details      []camtDetails `xml:"NtryDtls>TxDtls"`
+	AddtlNtryInf string        `xml:"AddtlNtryInf"`
+}
+
+// ParseCAMT053 reads the booked entries of an ISO 20022 camt.053 bank to customer statement, of any version.
+// The document is read one entry at a time, so large statements are never held in memory as a whole.
+// Batch bookings with the amount of each payment are split into one transaction per payment.
+func ParseCAMT053(r io.Reader) (*Result, error) {
+	decoder := xml.NewDecoder(skipBOM(r))
+	decoder.CharsetReader = charsetReader
+
+	result := &Result{Format: FormatCAMT053, Encoding: "utf-8", Transactions: []models.Transaction{}, Rejected: []RowError{}}
+	var stack []string
+	var account camtAccount
+	found := false
+
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &RowError{Line: line, Reason: err.Error()}
+		}
+
+		switch element := token.(type) {
+		case xml.ProcInst:
+			if element.Target == "xml" {
+				if encoding := declaredEncoding(string(element.Inst)); encoding != "" {
+					result.Encoding = encoding
+				}
+			}
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			switch {
+			case element.Name.Local == "Stmt":
+				found = true
+				account = camtAccount{}
+			case element.Name.Local == "Acct" && parent == "Stmt":
+				if err := decoder.DecodeElement(&account, &element); err != nil {
+					return nil, &RowError{Line: line, Reason: err.Error()}
+				}
+				continue
+			case element.Name.Local == "Ntry" && parent == "Stmt":
+				var entry camtEntry
+				if err := decoder.DecodeElement(&entry, &element); err != nil {
+					return nil, &RowError{Line: line, Reason: err.Error()}
+				}
+
+				// An entry that cannot be read is rejected, the rest of the statement is still read
+				transactions, err := entry.transactions(account)
+				if err != nil {
+					result.reject(line, err)
+					continue
+				}
+				result.Transactions = append(result.Transactions, transactions...)
+				continue
+			}
+			stack = append(stack, element.Name.Local)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("missing Stmt element, the file is not a camt.053 statement")
+	}
+	return result, nil
+}
+
+// transactions converts a booked entry, pending and informational entries return none
+func (e camtEntry) transactions(account camtAccount) ([]models.Transaction, error) {
+	status := strings.TrimSpace(e.Sts.Cd)
+	if status == "" {
+		status = strings.TrimSpace(e.Sts.Value)
+	}
+	if status != "" && status != "BOOK" {
+		return nil, nil
+	}
+
+	// 1) Shared by every payment of the entry
+	date, err := camtDay(e.BookgDt)
+	if err != nil {
+		return nil, err
+	}
+	if date == nil {
+		return nil, errors.New("missing booking date")
+	}
+
+	valueDate, err := camtDay(e.ValDt)
+	if err != nil {
+		return nil, err
+	}
+
+	// A reversal keeps the direction of the entry it reverses, so its amount has the opposite sign
+	var debit bool
+	switch strings.TrimSpace(e.CdtDbtInd) {
+	case "DBIT":
+		debit = true
+	case "CRDT":
+	default:
+		return nil, fmt.Errorf("invalid credit debit indicator %q", e.CdtDbtInd)
+	}
+	negative := debit != e.RvslInd
+
+	accountID := account.IBAN
+	if accountID == "" {
+		accountID = account.Other
+	}
+
+	build := func(amount camtAmount, details camtDetails, fitID string) (models.Transaction, error) {
+		cents, err := ParseAmount(amount.Value, ".")
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		if negative {
+			cents = -cents
+		}
+
+		currency := amount.Ccy
+		if currency == "" {
+			currency = account.Ccy
+		}
+
+		// The counterparty is the creditor of a debit and the debtor of a credit
+		counterparty := details.Debtor.name()
+		if debit {
+			counterparty = details.Creditor.name()
+		}
+
+		remittance := strings.TrimSpace(strings.Join(details.Ustrd, " "))
+		if remittance == "" {
+			remittance = strings.TrimSpace(strings.Join(details.StrdRef, " "))
+		}
+		if remittance == "" {
+			remittance = strings.TrimSpace(details.AddtlTxInf)
+		}
+
+		description := describe(counterparty, remittance)
+		if description == defaultDescription && strings.TrimSpace(e.AddtlNtryInf) != "" {
+			description = strings.TrimSpace(e.AddtlNtryInf)
+		}
+
+		return models.Transaction{
+			Date:           *date,
+			ValueDate:      valueDate,
+			AmountCents:    cents,
+			Currency:       currency,
+			Description:    description,
+			Counterparty:   counterparty,
+			RemittanceInfo: remittance,
+			Account:        accountID,
+			FitId:          strings.TrimSpace(fitID),
+		}, nil
+	}
+
+	// 2) A batch booking whose payments all have their own amount is split up
+	if len(e.Details) > 1 {
+		split := true
+		for _, details := range e.Details {
+			if details.Amt.Value == "" && details.TxAmt.Value == "" {
+				split = false
+				break
+			}
+		}
+
+		if split {
+			transactions := make([]models.Transaction, 0, len(e.Details))
+			for i, details := range e.Details {
+				amount := details.Amt
+				if amount.Value == "" {
+					amount = details.TxAmt
+				}
+
+				fitID := details.AcctSvcrRef
+				if fitID == "" && e.AcctSvcrRef != "" {
+					fitID = fmt.Sprintf("%s/%d", e.AcctSvcrRef, i+1)
+				}
+
+				transaction, err := build(amount, details, fitID)
+				if err != nil {
+					return nil, err
+				}
+				transactions = append(transactions, transaction)
+			}
+			return transactions, nil
+		}
+	}
+
+	// 3) Otherwise the entry is one transaction, described by its first payment
+	var details camtDetails
+	if len(e.Details) > 0 {
+		details = e.Details[0]
+	}
+
+	transaction, err := build(e.Amt, details, e.AcctSvcrRef)
+	if err != nil {
+		return nil, err
+	}
+	return []models.Transaction{transaction}, nil
+}
+
+// camtDay returns the day of a date, nil when it is empty
+func camtDay(date camtDate) (*time.Time, error) {
+	value := strings.TrimSpace(date.Dt)
+	if value == "" {
+		value = strings.TrimSpace(date.DtTm)
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	// The time of day of a DtTm is in the bank's zone, only the day is kept like for the other formats
+	if len(value) < 10 {
+		return nil, fmt.Errorf("invalid date %q", value)
+	}
+	day, err := time.Parse("2006-01-02", value[:10])
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", value)
+	}
+	return &day, nil
+}
@@ -0,0 +1,366 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	// maxHeaderRow is how far down a profile can place the header, banks put a few lines of account details above it
+	maxHeaderRow = 50
+	// detectionLines is how many lines are read to detect the delimiter
+	detectionLines = 20
+	// defaultDescription is stored for rows without one
+	defaultDescription = "No description"
+)
+
+// delimiters that are detected, in order of preference when several fit a file equally well
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// RowError is a row of a statement that could not be read, Line is the line of the file it starts on
+type RowError struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Record is a row of a statement split into fields, with the line of the file it starts on
+type Record struct {
+	Line   int
+	Fields []string
+}
+
+// Result is a parsed statement. Its transactions are not stored yet, so they have no ID, user or organization.
+// Rows that could not be read are in Rejected in the order of the file, the rest of the statement is still read.
+type Result struct {
+	Format       Format               `json:"format,omitempty"`
+	Encoding     string               `json:"encoding,omitempty"`
+	Delimiter    string               `json:"delimiter,omitempty"`
+	Header       []string             `json:"header,omitempty"`
+	Transactions []models.Transaction `json:"transactions"`
+	Rejected     []RowError           `json:"rejected"`
+}
+
+// reject records a row that could not be read
+func (r *Result) reject(line int, err error) {
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		r.Rejected = append(r.Rejected, *rowErr)
+		return
+	}
+	r.Rejected = append(r.Rejected, RowError{Line: line, Reason: err.Error()})
+}
+
+// DefaultProfile reads statements with "Date", "Amount" and "Description" headers on the first line
+func DefaultProfile() models.CSVProfile {
+	return models.CSVProfile{
+		Name:              "Default",
+		HeaderRow:         1,
+		DateColumn:        "Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+		DecimalSeparator:  ".",
+	}
+}
+
+// ValidateProfile checks that statements can be read with the profile
+func ValidateProfile(profile models.CSVProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	if profile.Delimiter != "" && !validDelimiter(profile.Delimiter) {
+		return errors.New(`delimiter must be one of ",", ";", "|" or a tab`)
+	}
+
+	if !ValidEncoding(profile.Encoding) {
+		return fmt.Errorf("encoding must be one of utf-8, utf-16le, utf-16be, windows-1252 or iso-8859-1")
+	}
+
+	if profile.HeaderRow < 1 || profile.HeaderRow > maxHeaderRow {
+		return fmt.Errorf("headerRow must be between 1 and %d", maxHeaderRow)
+	}
+
+	if strings.TrimSpace(profile.DateColumn) == "" || strings.TrimSpace(profile.DescriptionColumn) == "" {
+		return errors.New("dateColumn and descriptionColumn are required")
+	}
+
+	split := profile.DebitColumn != "" || profile.CreditColumn != ""
+	switch {
+	case profile.AmountColumn != "" && split:
+		return errors.New("use either amountColumn or debitColumn and creditColumn")
+	case profile.AmountColumn == "" && (profile.DebitColumn == "" || profile.CreditColumn == ""):
+		return errors.New("amountColumn, or both debitColumn and creditColumn, are required")
+	}
+
+	if profile.DecimalSeparator != "" && profile.DecimalSeparator != "." && profile.DecimalSeparator != "," {
+		return errors.New(`decimalSeparator must be "." or ","`)
+	}
+
+	for _, format := range profile.DateFormats {
+		if _, err := DateLayout(format); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validDelimiter(delimiter string) bool {
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) {
+		return false
+	}
+
+	for _, candidate := range delimiters {
+		if r == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectDelimiter picks the delimiter that splits the most of the first lines into the same number of fields
+func DetectDelimiter(text string) rune {
+	lines := strings.SplitN(text, "\n", detectionLines+1)
+	if len(lines) > detectionLines {
+		lines = lines[:detectionLines]
+	}
+	sample := strings.Join(lines, "\n")
+
+	best, bestRows, bestFields := delimiters[0], 0, 0
+	for _, delimiter := range delimiters {
+		reader := csv.NewReader(strings.NewReader(sample))
+		reader.Comma = delimiter
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		rowsByFields := map[int]int{}
+		for {
+			fields, err := reader.Read()
+			if err != nil {
+				break
+			}
+			if len(fields) > 1 {
+				rowsByFields[len(fields)]++
+			}
+		}
+
+		for fields, rows := range rowsByFields {
+			if rows > bestRows || (rows == bestRows && fields > bestFields) {
+				best, bestRows, bestFields = delimiter, rows, fields
+			}
+		}
+	}
+
+	return best
+}
+
+// ParseCSV decodes a CSV statement as it is read and reads its transactions with the profile
+func ParseCSV(r io.Reader, profile models.CSVProfile) (*Result, error) {
+	// 1) Decode the file to UTF-8
+	text, err := newTextReader(r, profile.Encoding, "windows-1252")
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(text)
+
+	// 2) Split it into records, the delimiter is detected from the first lines
+	delimiter, _ := utf8.DecodeRuneInString(profile.Delimiter)
+	if profile.Delimiter == "" {
+		sample, err := reader.Peek(sniffSize)
+		if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		delimiter = DetectDelimiter(string(sample))
+	}
+
+	records := csv.NewReader(reader)
+	records.Comma = delimiter
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+	records.ReuseRecord = true
+
+	// 3) Read the transactions as the records are read
+	var rows *rowParser
+	for {
+		fields, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, &RowError{Line: parseErr.StartLine, Reason: parseErr.Err.Error()}
+			}
+			return nil, err
+		}
+
+		line, _ := records.FieldPos(0)
+		record := Record{Line: line, Fields: fields}
+		if rows == nil {
+			if line < max(profile.HeaderRow, 1) {
+				continue
+			}
+			record.Fields = append([]string(nil), fields...)
+			if rows, err = newRowParser(record, profile); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		rows.read(record)
+	}
+
+	if rows == nil {
+		return nil, &RowError{Line: max(profile.HeaderRow, 1), Reason: "missing header row"}
+	}
+
+	result := rows.result
+	result.Format = FormatCSV
+	result.Encoding = text.Encoding()
+	result.Delimiter = string(delimiter)
+	return result, nil
+}
+
+// ParseRecords reads transactions from a statement that is already split into records
+func ParseRecords(records []Record, profile models.CSVProfile) (*Result, error) {
+	// The header is the first record on or after the profile's header row
+	headerRow := max(profile.HeaderRow, 1)
+	for i, record := range records {
+		if record.Line < headerRow {
+			continue
+		}
+
+		rows, err := newRowParser(record, profile)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range records[i+1:] {
+			rows.read(row)
+		}
+		return rows.result, nil
+	}
+
+	return nil, &RowError{Line: headerRow, Reason: "missing header row"}
+}
+
+// rowParser reads the rows below a statement's header with the columns the profile names in it
+type rowParser struct {
+	profile models.CSVProfile
+	dates   *dateParser
+	result  *Result
+
+	// Columns of the header, -1 when the profile does not use them
+	dateIndex, descriptionIndex, amountIndex, debitIndex, creditIndex int
+}
+
+// newRowParser finds the profile's columns in the header
+func newRowParser(header Record, profile models.CSVProfile) (*rowParser, error) {
+	dates, err := newDateParser(profile.DateFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header.Fields {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, exists := columns[name]; !exists {
+			columns[name] = i
+		}
+	}
+
+	column := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		index, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return -1, &RowError{Line: header.Line, Reason: fmt.Sprintf("missing column %q", name)}
+		}
+		return index, nil
+	}
+
+	p := &rowParser{
+		profile: profile,
+		dates:   dates,
+		result:  &Result{Header: header.Fields, Transactions: []models.Transaction{}, Rejected: []RowError{}},
+	}
+	for _, lookup := range []struct {
+		name  string
+		index *int
+	}{
+		{profile.DateColumn, &p.dateIndex},
+		{profile.DescriptionColumn, &p.descriptionIndex},
+		{profile.AmountColumn, &p.amountIndex},
+		{profile.DebitColumn, &p.debitIndex},
+		{profile.CreditColumn, &p.creditIndex},
+	} {
+		if *lookup.index, err = column(lookup.name); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// read adds a row's transaction to the result, blank rows are skipped and rows that cannot be read are rejected
+func (p *rowParser) read(record Record) {
+	if blank(record.Fields) {
+		return
+	}
+
+	field := func(index int) string {
+		if index < 0 || index >= len(record.Fields) {
+			return ""
+		}
+		return strings.TrimSpace(record.Fields[index])
+	}
+
+	date, err := p.dates.parse(field(p.dateIndex))
+	if err != nil {
+		p.result.reject(record.Line, err)
+		return
+	}
+
+	var amount int
+	if p.amountIndex >= 0 {
+		amount, err = ParseAmount(field(p.amountIndex), p.profile.DecimalSeparator)
+	} else {
+		amount, err = splitAmount(field(p.debitIndex), field(p.creditIndex), p.profile.DecimalSeparator)
+	}
+	if err != nil {
+		p.result.reject(record.Line, err)
+		return
+	}
+
+	description := field(p.descriptionIndex)
+	if description == "" {
+		description = defaultDescription
+	}
+
+	p.result.Transactions = append(p.result.Transactions, models.Transaction{
+		Date:        date,
+		AmountCents: amount,
+		Description: description,
+	})
+}
+
+func blank(fields []string) bool {
+	for _, field := range fields {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package statement_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/bank/statement"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// utf16LE encodes text as UTF-16 little endian with a byte order mark, as Excel exports "Unicode text"
+func utf16LE(text string) []byte {
+	data := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+func TestParseCSV_DefaultProfile(t *testing.T) {
+	data := []byte("\xef\xbb\xbfDate,Amount,Description\n11/11/2024,-23.50,Groceries\n2024-11-12,\"1,000\",Salary\n\n")
+
+	result, err := statement.ParseCSV(bytes.NewReader(data), statement.DefaultProfile())
+	require.NoError(t, err)
+
+	assert.Equal(t, "utf-8", result.Encoding)
+	assert.Equal(t, ",", result.Delimiter)
+	assert.Equal(t, []string{"Date", "Amount", "Description"}, result.Header)
+	require.Len(t, result.Transactions, 2)
+	assert.Equal(t, date(2024, time.November, 11), result.Transactions[0].Date)
+	assert.Equal(t, -2350, result.Transactions[0].AmountCents)
+	assert.Equal(t, "Groceries", result.Transactions[0].Description)
+	assert.Equal(t, 100000, result.Transactions[1].AmountCents)
+}
+
+func TestParseCSV_BankProfile(t *testing.T) {
+	// A European export with account details above the header, split debit and credit columns and decimal commas
+	data := []byte("Account;DE89 3704 0044 0532 0130 00\n" +
+		"Period;01.11.2024 - 30.11.2024\n" +
+		"Buchungstag;Verwendungszweck;Soll;Haben\n" +
+		"01.11.2024;Miete;1.250,00;\n" +
+		"15 Nov 2024;Gehalt;;3.100,45\n" +
+		"20.11.2024;;-4,99;\n")
+
+	profile := models.CSVProfile{
+		Name:              "Sparkasse",
+		HeaderRow:         3,
+		DateColumn:        "buchungstag",
+		DateFormats:       []string{"DD.MM.YYYY", "D MMM YYYY"},
+		DescriptionColumn: "Verwendungszweck",
+		DebitColumn:       "Soll",
+		CreditColumn:      "Haben",
+		DecimalSeparator:  ",",
+	}
+	require.NoError(t, statement.ValidateProfile(profile))
+
+	result, err := statement.ParseCSV(bytes.NewReader(data), profile)
+	require.NoError(t, err)
+
+	assert.Equal(t, ";", result.Delimiter)
+	require.Len(t, result.Transactions, 3)
+
+	assert.Equal(t, date(2024, time.November, 1), result.Transactions[0].Date)
+	assert.Equal(t, -125000, result.Transactions[0].AmountCents)
+
+	assert.Equal(t, date(2024, time.November, 15), result.Transactions[1].Date)
+	assert.Equal(t, 310045, result.Transactions[1].AmountCents)
+
+	assert.Equal(t, -499, result.Transactions[2].AmountCents)
+	assert.Equal(t, "No description", result.Transactions[2].Description)
+}
+
+func TestParseCSV_Encodings(t *testing.T) {
+	profile := statement.DefaultProfile()
+
+	// 1) UTF-16 is recognised by its byte order mark and tabs are detected as the delimiter
+	result, err := statement.ParseCSV(bytes.NewReader(utf16LE("Date\tAmount\tDescription\r\n01/02/2024\t12.00\tCafé\r\n")), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "utf-16le", result.Encoding)
+	assert.Equal(t, "\t", result.Delimiter)
+	require.Len(t, result.Transactions, 1)
+	assert.Equal(t, "Café", result.Transactions[0].Description)
+
+	// 2) Files that are not valid UTF-8 are read as Windows-1252
+	result, err = statement.ParseCSV(strings.NewReader("Date,Amount,Description\n01/02/2024,12.00,Caf\xe9 \x80\n"), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "windows-1252", result.Encoding)
+	assert.Equal(t, "Café €", result.Transactions[0].Description)
+
+	// 3) Lines are checked as the file is read, so a Windows-1252 row far below the start is still decoded
+	data := "Date,Amount,Description\n" + strings.Repeat("01/02/2024,1.00,Coffee\n", 1000) + "01/02/2024,12.00,Caf\xe9\n"
+	result, err = statement.ParseCSV(strings.NewReader(data), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "windows-1252", result.Encoding)
+	require.Len(t, result.Transactions, 1001)
+	assert.Equal(t, "Café", result.Transactions[1000].Description)
+
+	// 4) A profile can name the encoding
+	profile.Encoding = "iso-8859-1"
+	result, err = statement.ParseCSV(strings.NewReader("Date,Amount,Description\n01/02/2024,12.00,Caf\xe9\n"), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "iso-8859-1", result.Encoding)
+	assert.Equal(t, "Café", result.Transactions[0].Description)
+
+	// 5) A file read as UTF-8 because the profile says so is rejected when it is not
+	profile.Encoding = "utf-8"
+	_, err = statement.ParseCSV(strings.NewReader(data), profile)
+	assert.EqualError(t, err, "file is not valid utf-8")
+}
+
+func TestParseCSV_RejectedRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		valid int
+		line  int
+	}{
+		{"invalid date", "Date,Amount,Description\n01/02/2024,1.00,a\n31/31/2024,1.00,b\n", 1, 3},
+		{"invalid amount", "Date,Amount,Description\n\n01/02/2024,ten,a\n", 0, 3},
+		{"quoted field spanning lines", "Date,Amount,Description\n01/02/2024,1.00,\"multi\nline\"\n01/02/2024,x,b\n", 1, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := statement.ParseCSV(strings.NewReader(tt.data), statement.DefaultProfile())
+			require.NoError(t, err)
+			assert.Len(t, result.Transactions, tt.valid)
+			require.Len(t, result.Rejected, 1)
+			assert.Equal(t, tt.line, result.Rejected[0].Line)
+		})
+	}
+}
+
+func TestParseCSV_RowErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"missing column", "Date,Value,Description\n01/02/2024,1.00,a\n", 1},
+		{"missing header", "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := statement.ParseCSV(strings.NewReader(tt.data), statement.DefaultProfile())
+
+			var rowErr *statement.RowError
+			require.True(t, errors.As(err, &rowErr), "expected a row error, got %v", err)
+			assert.Equal(t, tt.line, rowErr.Line)
+		})
+	}
+}
+
+// BenchmarkParseCSV reads a statement of 50,000 rows, the size of a large business account's year
+func BenchmarkParseCSV(b *testing.B) {
+	var data strings.Builder
+	data.WriteString("Date,Amount,Description\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&data, "%02d/11/2024,-%d.%02d,Card payment %d\n", i%28+1, i%500, i%100, i)
+	}
+
+	for i := 0; i < b.N; i++ {
+		result, err := statement.ParseCSV(strings.NewReader(data.String()), statement.DefaultProfile())
+		if err != nil || len(result.Transactions) != 50000 {
+			b.Fatalf("expected 50000 transactions, got %v", err)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value     string
+		separator string
+		cents     int
+		wantErr   bool
+	}{
+		{"100", ".", 10000, false},
+		{"-23.5", ".", -2350, false},
+		{"$1,234.56", ".", 123456, false},
+		{"(12.00)", ".", -1200, false},
+		{"12.00-", ".", -1200, false},
+		{"1.234,56 €", ",", 123456, false},
+		{"1 234,5", ",", 123450, false},
+		{"0.1", ".", 10, false},
+		{"1.234", ".", 0, true},
+		{"1,234", ".", 123400, false},
+		{"1,234,567.89", ".", 123456789, false},
+		{"1.234.567", ",", 123456700, false},
+		{"12,50", ".", 0, true},
+		{"1,23", ".", 0, true},
+		{"1234,567.00", ".", 0, true},
+		{",500", ".", 0, true},
+		{"1,,234", ".", 0, true},
+		{"1,234.5,6", ".", 0, true},
+		{"12.50", ",", 0, true},
+		{"1.23,45", ",", 0, true},
+		{"1,5", ",", 150, false},
+		{"abc", ".", 0, true},
+		{"", ".", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cents, err := statement.ParseAmount(tt.value, tt.separator)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.cents, cents)
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	valid := statement.DefaultProfile()
+	require.NoError(t, statement.ValidateProfile(valid))
+
+	tests := []struct {
+		name   string
+		mutate func(profile *models.CSVProfile)
+	}{
+		{"missing name", func(p *models.CSVProfile) { p.Name = " " }},
+		{"unsupported delimiter", func(p *models.CSVProfile) { p.Delimiter = "::" }},
+		{"unsupported encoding", func(p *models.CSVProfile) { p.Encoding = "ebcdic" }},
+		{"header row out of range", func(p *models.CSVProfile) { p.HeaderRow = 0 }},
+		{"amount and debit columns", func(p *models.CSVProfile) { p.DebitColumn = "Debit" }},
+		{"debit without credit", func(p *models.CSVProfile) { p.AmountColumn, p.DebitColumn = "", "Debit" }},
+		{"unsupported decimal separator", func(p *models.CSVProfile) { p.DecimalSeparator = "'" }},
+		{"date format without a day", func(p *models.CSVProfile) { p.DateFormats = []string{"MM/YYYY"} }},
+		{"date format with unknown tokens", func(p *models.CSVProfile) { p.DateFormats = []string{"%d/%m/%Y"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := statement.DefaultProfile()
+			tt.mutate(&profile)
+			assert.Error(t, statement.ValidateProfile(profile))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateTokens map the date format placeholders users write to Go layouts, longest first so "MM" wins over "M"
+var dateTokens = []struct {
+	token  string
+	layout string
+}{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"M", "1"},
+	{"DD", "02"},
+	{"D", "2"},
+}
+
+// DefaultDateFormats are tried when a profile has none, day first like the original JSON upload, then ISO 8601
+var DefaultDateFormats = []string{"D/M/YYYY", "YYYY-MM-DD"}
+
+// DateLayout converts a format such as "DD/MM/YYYY" or "D MMM YY" to a Go time layout.
+// Only the placeholders and the separators "/", "-", ".", " " are allowed.
+func DateLayout(format string) (string, error) {
+	var layout strings.Builder
+	hasYear, hasMonth, hasDay := false, false, false
+
+	for rest := format; rest != ""; {
+		matched := false
+		for _, token := range dateTokens {
+			if strings.HasPrefix(rest, token.token) {
+				layout.WriteString(token.layout)
+				rest = rest[len(token.token):]
+				matched = true
+
+				switch token.token[0] {
+				case 'Y':
+					hasYear = true
+				case 'M':
+					hasMonth = true
+				case 'D':
+					hasDay = true
+				}
+				break
+			}
+		}
+
+		if matched {
+			continue
+		}
+
+		switch rest[0] {
+		case '/', '-', '.', ' ':
+			layout.WriteByte(rest[0])
+			rest = rest[1:]
+		default:
+			return "", fmt.Errorf("invalid date format %q", format)
+		}
+	}
+
+	if !hasYear || !hasMonth || !hasDay {
+		return "", fmt.Errorf("date format %q needs a year, month and day", format)
+	}
+	return layout.String(), nil
+}
+
+// dateParser tries each of a profile's date formats in order
+type dateParser struct {
+	layouts []string
+}
+
+func newDateParser(formats []string) (*dateParser, error) {
+	if len(formats) == 0 {
+		formats = DefaultDateFormats
+	}
+
+	parser := &dateParser{}
+	for _, format := range formats {
+		layout, err := DateLayout(format)
+		if err != nil {
+			return nil, err
+		}
+		parser.layouts = append(parser.layouts, layout)
+	}
+	return parser, nil
+}
+
+func (p *dateParser) parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range p.layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
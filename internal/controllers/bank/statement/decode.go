@@ -0,0 +1,187 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings statements can be read in, banks mostly export UTF-8 or the Windows code page
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        unicode.UTF8,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	"windows-1252": charmap.Windows1252,
+	"iso-8859-1":   charmap.ISO8859_1,
+}
+
+// encodingAliases are other names files declare the encodings by
+var encodingAliases = map[string]string{
+	"utf8":       "utf-8",
+	"cp1252":     "windows-1252",
+	"1252":       "windows-1252",
+	"latin1":     "iso-8859-1",
+	"iso8859-1":  "iso-8859-1",
+	"8859-1":     "iso-8859-1",
+	"iso_8859-1": "iso-8859-1",
+}
+
+// declaredEncodingPattern finds the encoding in an XML declaration
+var declaredEncodingPattern = regexp.MustCompile(`encoding\s*=\s*["']([^"']+)["']`)
+
+var boms = []struct {
+	encoding string
+	bom      []byte
+}{
+	{"utf-8", []byte{0xEF, 0xBB, 0xBF}},
+	{"utf-16le", []byte{0xFF, 0xFE}},
+	{"utf-16be", []byte{0xFE, 0xFF}},
+}
+
+// ValidEncoding reports whether the encoding can be decoded, empty means it is detected
+func ValidEncoding(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, ok := encodings[strings.ToLower(name)]
+	return ok
+}
+
+// textReader reads a statement as UTF-8 text without a byte order mark, so files are never held in memory whole.
+// Unless the encoding is known, lines are checked as they are read and those that are not valid UTF-8 are read in
+// the fallback encoding, or rejected when there is none.
+type textReader struct {
+	decoded  io.Reader
+	lines    *bufio.Reader
+	fallback string
+	encoding string
+	pending  []byte
+	err      error
+}
+
+// newTextReader returns a reader of the statement as UTF-8 text. An empty encoding is detected: a BOM decides it,
+// otherwise each line that is valid UTF-8 is read as such and any other in the fallback encoding.
+func newTextReader(r io.Reader, name string, fallback string) (*textReader, error) {
+	reader := bufio.NewReader(r)
+	name = strings.ToLower(name)
+
+	// 1) A byte order mark names the encoding and is never part of the text
+	prefix, _ := reader.Peek(3)
+	for _, candidate := range boms {
+		if bytes.HasPrefix(prefix, candidate.bom) {
+			reader.Discard(len(candidate.bom))
+			if name == "" {
+				name = candidate.encoding
+			}
+			break
+		}
+	}
+
+	// 2) Without one the lines decide, and a file that names UTF-8 must be valid UTF-8 throughout
+	switch name {
+	case "":
+		if _, ok := encodings[fallback]; !ok {
+			return nil, fmt.Errorf("unsupported encoding %q", fallback)
+		}
+		return &textReader{lines: reader, fallback: fallback, encoding: "utf-8"}, nil
+	case "utf-8":
+		return &textReader{lines: reader, encoding: name}, nil
+	}
+
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return &textReader{decoded: enc.NewDecoder().Reader(reader), encoding: name}, nil
+}
+
+// Encoding is the encoding the text was read in, it is only final once the text has been read to the end
+func (t *textReader) Encoding() string {
+	return t.encoding
+}
+
+func (t *textReader) Read(p []byte) (int, error) {
+	if t.decoded != nil {
+		return t.decoded.Read(p)
+	}
+
+	for len(t.pending) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+
+		line, err := t.lines.ReadBytes('\n')
+		t.err = err
+		if utf8.Valid(line) {
+			t.pending = line
+			continue
+		}
+
+		if t.fallback == "" {
+			t.err = errors.New("file is not valid utf-8")
+			return 0, t.err
+		}
+
+		decoded, decodeErr := encodings[t.fallback].NewDecoder().Bytes(line)
+		if decodeErr != nil {
+			t.err = fmt.Errorf("failed to decode %s: %w", t.fallback, decodeErr)
+			return 0, t.err
+		}
+		t.pending, t.encoding = decoded, t.fallback
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// encodingName returns the name statements are read in for an encoding label by, or "" when it is not supported
+func encodingName(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if alias, ok := encodingAliases[label]; ok {
+		label = alias
+	}
+	if _, ok := encodings[label]; !ok {
+		return ""
+	}
+	return label
+}
+
+// declaredEncoding returns the encoding named by an XML declaration, utf-8 when it names none
+func declaredEncoding(declaration string) string {
+	match := declaredEncodingPattern.FindStringSubmatch(declaration)
+	if match == nil {
+		return "utf-8"
+	}
+	if name := encodingName(match[1]); name != "" {
+		return name
+	}
+	return strings.ToLower(match[1])
+}
+
+// charsetReader decodes XML documents that declare an encoding other than UTF-8
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	name := encodingName(label)
+	if name == "" {
+		return nil, fmt.Errorf("unsupported encoding %q", label)
+	}
+	return encodings[name].NewDecoder().Reader(input), nil
+}
+
+// skipBOM drops a UTF-8 byte order mark from the start of a stream
+func skipBOM(r io.Reader) io.Reader {
+	reader := bufio.NewReader(r)
+	if bom, err := reader.Peek(3); err == nil && bytes.Equal(bom, boms[0].bom) {
+		reader.Discard(3)
+	}
+	return reader
+}
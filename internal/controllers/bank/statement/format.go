@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// Format is a statement file format
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx" // OFX 1.x and 2.x, and QFX which is OFX with Quicken's extensions
+	FormatQIF     Format = "qif"
+	FormatCAMT053 Format = "camt.053" // ISO 20022 bank to customer statement
+	FormatMT940   Format = "mt940"    // SWIFT customer statement
+)
+
+// mt940Start matches the first field of an MT940 statement, or the SWIFT envelope around it
+var mt940Start = regexp.MustCompile(`(?m)^(\{1:|:20:)`)
+
+// camtNamespace starts the namespace of every version of camt.053, e.g. urn:iso:std:iso:20022:tech:xsd:camt.053.001.08
+const camtNamespace = "urn:iso:std:iso:20022:tech:xsd:camt.053."
+
+// sniffSize is how much of a file is read to detect its format, OFX 1.x headers come before the <OFX> element
+const sniffSize = 4096
+
+// Detect returns the format of a statement from the start of its content, anything that is not recognised is read as CSV
+func Detect(data []byte) Format {
+	sample := data[:min(len(data), sniffSize)]
+	sample = bytes.TrimPrefix(sample, []byte{0xEF, 0xBB, 0xBF})
+	sample = bytes.TrimSpace(sample)
+	upper := bytes.ToUpper(sample)
+
+	switch {
+	case bytes.HasPrefix(upper, []byte("OFXHEADER")), bytes.Contains(upper, []byte("<OFX>")), bytes.Contains(upper, []byte("<?OFX")):
+		return FormatOFX
+	case isCAMT053(sample):
+		return FormatCAMT053
+	case bytes.HasPrefix(sample, []byte("!")):
+		return FormatQIF
+	case mt940Start.Match(sample) && bytes.Contains(sample, []byte(":25:")):
+		return FormatMT940
+	}
+	return FormatCSV
+}
+
+// isCAMT053 reports whether the document's root element is a camt.053 Document. Text that mentions camt.053, such as
+// a CSV row describing a bank file, is not one.
+func isCAMT053(sample []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(sample))
+	decoder.CharsetReader = charsetReader
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			return element.Name.Local == "Document" && strings.HasPrefix(element.Name.Space, camtNamespace)
+		case xml.CharData:
+			// Only whitespace may come before the root element
+			if len(bytes.TrimSpace(element)) > 0 {
+				return false
+			}
+		}
+	}
+}
+
+// Parse detects the statement's format from its first bytes and reads its transactions as the file is read. The
+// profile maps the columns of CSV files, its date formats and decimal separator are also used for QIF files which do
+// not say which they use.
+func Parse(r io.Reader, profile models.CSVProfile) (*Result, error) {
+	reader := bufio.NewReaderSize(r, sniffSize)
+	sample, err := reader.Peek(sniffSize)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+
+	switch Detect(sample) {
+	case FormatOFX:
+		return ParseOFX(reader)
+	case FormatQIF:
+		return ParseQIF(reader, profile.DateFormats, profile.DecimalSeparator)
+	case FormatCAMT053:
+		return ParseCAMT053(reader)
+	case FormatMT940:
+		return ParseMT940(reader)
+	}
+	return ParseCSV(reader, profile)
+}
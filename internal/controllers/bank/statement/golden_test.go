@@ -0,0 +1,92 @@
+package statement_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/bank/statement"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestParse_Golden parses every statement in testdata and compares the result, or the error, with its .golden file.
+// Run `go test ./internal/controllers/bank/statement -update` after an intended change and review the diff.
+func TestParse_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*"))
+	require.NoError(t, err)
+
+	for _, file := range files {
+		// Only statements are parsed, not golden files, hidden files such as .gitattributes or directories
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		if info.IsDir() || strings.HasPrefix(filepath.Base(file), ".") || strings.HasSuffix(file, ".golden") {
+			continue
+		}
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.Open(file)
+			require.NoError(t, err)
+			defer data.Close()
+
+			var output any
+			result, err := statement.Parse(data, statement.DefaultProfile())
+			if err != nil {
+				output = map[string]string{"error": err.Error()}
+			} else {
+				output = result
+			}
+
+			var buffer bytes.Buffer
+			encoder := json.NewEncoder(&buffer)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			require.NoError(t, encoder.Encode(output))
+			got := buffer.Bytes()
+
+			golden := file + ".golden"
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "run the test with -update to create the golden file")
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want statement.Format
+	}{
+		{"ofx 1.x header", "OFXHEADER:100\nDATA:OFXSGML\n\n<OFX>", statement.FormatOFX},
+		{"ofx 2.x declaration", "<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\"?>\n<OFX></OFX>", statement.FormatOFX},
+		{"ofx without headers", "\xef\xbb\xbf  <ofx><BANKMSGSRSV1>", statement.FormatOFX},
+		{"qif", "!Type:Bank\nD1/1/2024\n", statement.FormatQIF},
+		{"qif after blank lines", "\r\n!Account\nNChecking\n^\n", statement.FormatQIF},
+		{"camt.053", "<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.08\">", statement.FormatCAMT053},
+		{"camt.053 in latin-1", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<!-- Z\xfcrich -->\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02\">", statement.FormatCAMT053},
+		{"camt.052 report", "<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.052.001.08\">", statement.FormatCSV},
+		{"csv mentioning camt.053", "Date,Amount,Description\n01/02/2024,1.00,Fee for camt.053 export\n", statement.FormatCSV},
+		{"mt940", ":20:STARTUMS\r\n:25:37040044/0532013000\r\n", statement.FormatMT940},
+		{"mt940 in a swift envelope", "{1:F01INGBNL2AXXXX0000000000}{2:I940INGBNL2AXXXXN}{4:\n:20:P1\n:25:NL91\n", statement.FormatMT940},
+		{"csv", "Date,Amount,Description\n", statement.FormatCSV},
+		{"csv mentioning a field tag", "Date,Amount,Description\n01/02/2024,1.00,:20: off\n", statement.FormatCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, statement.Detect([]byte(tt.data)))
+		})
+	}
+}
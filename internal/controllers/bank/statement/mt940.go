@@ -0,0 +1,338 @@
+package statement
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+var errNotMT940 = errors.New("missing :25: account field, the file is not an MT940 statement")
+
+// mt940Tag starts a field, e.g. ":61:" or ":60F:"
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+// mt940StatementLine is the :61: field: value date, optional booking date (MMDD), debit or credit mark, funds code,
+// amount, transaction type, then the customer reference and the bank's reference after "//"
+var mt940StatementLine = regexp.MustCompile(`^(\d{6})(\d{4})?(RD|RC|D|C)([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})(.*)$`)
+
+// mt940Balance is the :60F: or :60M: opening balance, which names the statement's currency
+var mt940Balance = regexp.MustCompile(`^[DC]\d{6}([A-Z]{3})`)
+
+// mt940GermanField splits the structured :86: of German banks into ?NN subfields
+var mt940GermanField = regexp.MustCompile(`\?(\d{2})`)
+
+// mt940Keywords are the subfields of the structured :86: used by Dutch and other SEPA banks, e.g. /NAME/ and /REMI/
+var mt940Keywords = []string{"TRTP", "IBAN", "BIC", "NAME", "CNTP", "REMI", "EREF", "MARF", "CSID", "ORDP", "BENM", "ID", "ADDR", "SVCL", "PURP", "RTRN", "ISDT", "CDTRREF", "CDTRREFTP", "CD", "CDTRREFTPCD"}
+
+// mt940Transaction collects a :61: line and the :86: that describes it
+type mt940Transaction struct {
+	line        int
+	statement   string
+	information []string
+}
+
+// mt940Parser keeps the statement fields the transactions depend on
+type mt940Parser struct {
+	found        bool
+	account      string
+	currency     string
+	current      *mt940Transaction
+	encoding     string
+	transactions []models.Transaction
+	rejected     []RowError
+}
+
+// ParseMT940 reads the transactions of SWIFT MT940 customer statements, one line at a time. A file may hold several
+// statements, with or without the SWIFT message envelope. Lines that are not valid UTF-8 are read as Windows-1252.
+func ParseMT940(r io.Reader) (*Result, error) {
+	scanner := bufio.NewScanner(skipBOM(r))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	parser := &mt940Parser{encoding: "utf-8", transactions: []models.Transaction{}, rejected: []RowError{}}
+	tag, value, tagLine := "", []string{}, 0
+
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !utf8.ValidString(line) {
+			decoded, err := charmap.Windows1252.NewDecoder().String(line)
+			if err != nil {
+				return nil, &RowError{Line: number, Reason: err.Error()}
+			}
+			line, parser.encoding = decoded, "windows-1252"
+		}
+
+		// 1) A new field starts with its tag, anything else continues the field before it
+		if match := mt940Tag.FindStringSubmatch(line); match != nil {
+			parser.field(tag, value, tagLine)
+			tag, value, tagLine = match[1], []string{match[2]}, number
+			continue
+		}
+
+		// 2) The SWIFT envelope, the end of a message and blank lines close the open field
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "-" || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "-}") {
+			parser.field(tag, value, tagLine)
+			tag, value = "", []string{}
+			continue
+		}
+
+		if tag != "" {
+			value = append(value, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	parser.field(tag, value, tagLine)
+	parser.flush()
+
+	if !parser.found {
+		return nil, errNotMT940
+	}
+	return &Result{Format: FormatMT940, Encoding: parser.encoding, Transactions: parser.transactions, Rejected: parser.rejected}, nil
+}
+
+// field handles a complete field of the statement
+func (p *mt940Parser) field(tag string, lines []string, line int) {
+	switch tag {
+	case "":
+		// No field was open
+	case "25":
+		p.flush()
+		p.found = true
+		p.account, p.currency = strings.TrimSpace(strings.Join(lines, "")), ""
+	case "60F", "60M":
+		if match := mt940Balance.FindStringSubmatch(strings.TrimSpace(lines[0])); match != nil {
+			p.currency = match[1]
+		}
+	case "61":
+		p.flush()
+		p.current = &mt940Transaction{line: line, statement: strings.TrimSpace(lines[0])}
+	case "86":
+		// Information to the account owner describes the statement line before it, or the statement as a whole
+		if p.current != nil && p.current.information == nil {
+			p.current.information = lines
+		}
+	default:
+		// Any other field ends the statement line, e.g. :62F: the closing balance
+		p.flush()
+	}
+}
+
+// flush converts the open statement line to a transaction, or rejects it when it cannot be read
+func (p *mt940Parser) flush() {
+	if p.current == nil {
+		return
+	}
+	current := p.current
+	p.current = nil
+
+	transaction, err := current.transaction(p.account, p.currency)
+	if err != nil {
+		p.rejected = append(p.rejected, RowError{Line: current.line, Reason: err.Error()})
+		return
+	}
+	p.transactions = append(p.transactions, transaction)
+}
+
+func (t *mt940Transaction) transaction(account string, currency string) (models.Transaction, error) {
+	match := mt940StatementLine.FindStringSubmatch(t.statement)
+	if match == nil {
+		return models.Transaction{}, fmt.Errorf("invalid statement line %q", t.statement)
+	}
+
+	// 1) Dates, the booking date has no year so it is taken from the value date, across the turn of the year too
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("invalid value date %q", match[1])
+	}
+
+	date := valueDate
+	if match[2] != "" {
+		booked, err := time.Parse("0102", match[2])
+		if err != nil {
+			return models.Transaction{}, fmt.Errorf("invalid booking date %q", match[2])
+		}
+
+		year := valueDate.Year()
+		switch {
+		case booked.Month() == time.January && valueDate.Month() == time.December:
+			year++
+		case booked.Month() == time.December && valueDate.Month() == time.January:
+			year--
+		}
+		date = time.Date(year, booked.Month(), booked.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	// 2) Amounts always use a decimal comma, which ends whole amounts too. A reversal of a debit is money coming back.
+	amount, err := ParseAmount(strings.TrimSuffix(match[5], ","), ",")
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if match[3] == "D" || match[3] == "RC" {
+		amount = -amount
+	}
+
+	// 3) The bank's reference identifies the transaction, NONREF means it has none
+	_, reference, _ := strings.Cut(match[7], "//")
+	reference = strings.TrimSpace(reference)
+	if strings.EqualFold(reference, "NONREF") {
+		reference = ""
+	}
+
+	counterparty, remittance, bookingText := mt940Information(t.information)
+	description := describe(counterparty, remittance)
+	if description == defaultDescription && bookingText != "" {
+		description = bookingText
+	}
+
+	return models.Transaction{
+		Date:           date,
+		ValueDate:      &valueDate,
+		AmountCents:    amount,
+		Currency:       currency,
+		Description:    description,
+		Counterparty:   counterparty,
+		RemittanceInfo: remittance,
+		Account:        account,
+		FitId:          reference,
+	}, nil
+}
+
+// mt940Information reads the counterparty, remittance information and booking text from a :86: field. Banks
+// structure it in one of two ways, ?NN subfields in Germany and /KEYWORD/ subfields in the Netherlands and for SEPA,
+// anything else is kept as free text.
+func mt940Information(lines []string) (string, string, string) {
+	if len(lines) == 0 {
+		return "", "", ""
+	}
+
+	// Structured fields are cut at the line length wherever they are, so their lines are joined as they are
+	joined := strings.Join(lines, "")
+
+	// 1) German banks: a three digit business code then ?00 booking text, ?20-?29 and ?60-?63 purpose, ?32-?33 name
+	if len(joined) > 3 && strings.HasPrefix(joined[3:], "?") && mt940GermanField.MatchString(joined) {
+		var name, purpose strings.Builder
+		bookingText := ""
+
+		indexes := mt940GermanField.FindAllStringSubmatchIndex(joined, -1)
+		for i, index := range indexes {
+			end := len(joined)
+			if i+1 < len(indexes) {
+				end = indexes[i+1][0]
+			}
+			code, text := joined[index[2]:index[3]], joined[index[1]:end]
+
+			switch {
+			case code == "00":
+				bookingText = strings.TrimSpace(text)
+			case code >= "20" && code <= "29", code >= "60" && code <= "63":
+				purpose.WriteString(text)
+			case code == "32" || code == "33":
+				name.WriteString(text)
+			}
+		}
+		return strings.TrimSpace(name.String()), mt940Purpose(purpose.String()), bookingText
+	}
+
+	// 2) Dutch and SEPA banks: /NAME/Jane Doe/REMI/Invoice 12/
+	if strings.HasPrefix(joined, "/") {
+		fields := mt940KeywordFields(joined)
+		if fields != nil {
+			remittance := fields["REMI"]
+			// ING writes structured remittance as /REMI/USTD//text/ or /REMI/STRD/CUR/reference/
+			for _, prefix := range []string{"USTD//", "STRD/CUR/"} {
+				remittance = strings.TrimPrefix(remittance, prefix)
+			}
+
+			// ING writes the counterparty as /CNTP/account/BIC/name/ID/
+			name := fields["NAME"]
+			if parts := strings.Split(fields["CNTP"], "/"); name == "" && len(parts) >= 3 {
+				name = strings.TrimSpace(parts[2])
+			}
+			return name, strings.TrimSpace(remittance), fields["TRTP"]
+		}
+	}
+
+	// 3) Free text, whose lines break between words
+	text := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	return "", strings.Join(text, " "), ""
+}
+
+// mt940Purpose drops the SEPA keywords German banks put in front of the purpose, keeping the remittance text
+func mt940Purpose(purpose string) string {
+	purpose = strings.TrimSpace(purpose)
+	if index := strings.Index(purpose, "SVWZ+"); index != -1 {
+		purpose = purpose[index+len("SVWZ+"):]
+		// Later SEPA keywords such as ABWA+ follow the remittance text
+		for _, keyword := range []string{"ABWA+", "ABWE+"} {
+			if end := strings.Index(purpose, keyword); end != -1 {
+				purpose = purpose[:end]
+			}
+		}
+	}
+	return strings.TrimSpace(purpose)
+}
+
+// mt940KeywordFields splits "/KEY/value/KEY/value/" into its fields, or returns nil when a key is not recognised
+func mt940KeywordFields(text string) map[string]string {
+	fields := map[string]string{}
+	rest := text
+
+	for strings.HasPrefix(rest, "/") {
+		rest = rest[1:]
+		end := strings.Index(rest, "/")
+		if end == -1 {
+			break
+		}
+		key := rest[:end]
+		if !isMT940Keyword(key) {
+			return nil
+		}
+		rest = rest[end+1:]
+
+		// The value runs until the next "/KEYWORD/", values such as dates and references may contain slashes
+		valueEnd := len(rest)
+		for i := 0; i < len(rest); i++ {
+			if rest[i] != '/' {
+				continue
+			}
+			if next := strings.Index(rest[i+1:], "/"); next != -1 && isMT940Keyword(rest[i+1:i+1+next]) {
+				valueEnd = i
+				break
+			}
+		}
+		fields[key] = strings.TrimSuffix(strings.TrimSpace(rest[:valueEnd]), "/")
+		rest = rest[valueEnd:]
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func isMT940Keyword(key string) bool {
+	for _, keyword := range mt940Keywords {
+		if key == keyword {
+			return true
+		}
+	}
+	return false
+}
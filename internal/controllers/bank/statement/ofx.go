@@ -0,0 +1,356 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// ofxCharsets map the character sets OFX headers declare to the encodings statements are read in
+var ofxCharsets = map[string]string{
+	"1252":         "windows-1252",
+	"windows-1252": "windows-1252",
+	"8859-1":       "iso-8859-1",
+	"iso-8859-1":   "iso-8859-1",
+	"utf-8":        "utf-8",
+}
+
+// ofxStartPattern finds the OFX element after the headers
+var ofxStartPattern = regexp.MustCompile(`(?i)<OFX>`)
+
+// ofxCharsetPattern finds the character set in an OFX 1.x header (CHARSET:1252) or an OFX 2.x XML declaration
+var ofxCharsetPattern = regexp.MustCompile(`(?i)(?:CHARSET:\s*|ENCODING:\s*|encoding=["'])([\w-]+)`)
+
+// ofxElements are never aggregates, so when one is empty in an SGML file it is not mistaken for one
+var ofxElements = map[string]bool{
+	"TRNTYPE": true, "DTPOSTED": true, "DTUSER": true, "DTAVAIL": true, "TRNAMT": true, "FITID": true,
+	"CORRECTFITID": true, "CORRECTACTION": true, "SRVRTID": true, "CHECKNUM": true, "REFNUM": true, "SIC": true,
+	"PAYEEID": true, "NAME": true, "EXTDNAME": true, "MEMO": true, "INV401KSOURCE": true,
+	"BANKID": true, "BRANCHID": true, "ACCTID": true, "ACCTTYPE": true, "ACCTKEY": true,
+}
+
+// ofxTransaction collects the elements of a STMTTRN aggregate
+type ofxTransaction struct {
+	line      int
+	posted    string
+	user      string
+	available string
+	amount    string
+	fitID     string
+	name      string
+	memo      string
+	checkNum  string
+}
+
+// ofxParser follows the aggregates of an OFX file as its tags are read
+type ofxParser struct {
+	started      bool
+	stack        []string
+	account      string
+	currency     string
+	current      *ofxTransaction
+	transactions []models.Transaction
+	rejected     []RowError
+}
+
+// ParseOFX reads the bank and credit card transactions of an OFX 1.x (SGML) or 2.x (XML) statement, QFX files included.
+// Each transaction keeps its FITID and the account it was posted to, so importing the file again skips them.
+func ParseOFX(r io.Reader) (*Result, error) {
+	// 1) OFX 1.x headers often declare a Windows code page while banks send UTF-8, so the declaration is only used
+	// for lines that are not valid UTF-8
+	reader := bufio.NewReaderSize(r, sniffSize)
+	headers, err := reader.Peek(sniffSize)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if start := ofxStartPattern.FindIndex(headers); start != nil {
+		headers = headers[:start[0]]
+	}
+
+	fallback := "windows-1252"
+	if match := ofxCharsetPattern.FindSubmatch(headers); match != nil {
+		if name := ofxCharsets[strings.ToLower(string(match[1]))]; name != "" && name != "utf-8" {
+			fallback = name
+		}
+	}
+
+	text, err := newTextReader(reader, "", fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2) Read the tags, the headers before the OFX element are text that belongs to no element
+	parser := &ofxParser{transactions: []models.Transaction{}, rejected: []RowError{}}
+	if err := parser.read(bufio.NewReader(text)); err != nil {
+		return nil, err
+	}
+
+	if !parser.started {
+		return nil, errors.New("missing <OFX> element")
+	}
+	return &Result{Format: FormatOFX, Encoding: text.Encoding(), Transactions: parser.transactions, Rejected: parser.rejected}, nil
+}
+
+// read walks the tags of the file. SGML files may leave elements unclosed, so an element is a tag followed by text
+// and an aggregate is a tag followed by another tag. Aggregates are always closed.
+func (p *ofxParser) read(r *bufio.Reader) error {
+	open, openLine, line := "", 0, 1
+
+	for {
+		// 1) Text after an opening tag is that element's value
+		text, err := r.ReadString('<')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		text = strings.TrimSuffix(text, "<")
+
+		if value := strings.TrimSpace(text); open != "" && value != "" {
+			p.element(open, html.UnescapeString(value))
+			open = ""
+		}
+
+		line += strings.Count(text, "\n")
+		if err == io.EOF {
+			return nil
+		}
+
+		// 2) Skip comments and processing instructions
+		tag, closer, err := readOFXTag(r)
+		if err == io.EOF {
+			return &RowError{Line: line, Reason: "unterminated tag"}
+		}
+		if err != nil {
+			return err
+		}
+		line += strings.Count(tag, "\n")
+
+		if closer != ">" {
+			continue
+		}
+
+		closing := strings.HasPrefix(tag, "/")
+		tagName := strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(tag, "/"), "/")))
+		if tagName == "OFX" {
+			p.started = true
+		}
+
+		// 3) A tag straight after an opening tag means the open tag was an aggregate, unless it was an empty element
+		if open != "" {
+			if closing && tagName == open {
+				open = ""
+				continue
+			}
+			if !ofxElements[open] {
+				p.push(open, openLine)
+			}
+			open = ""
+		}
+
+		switch {
+		case closing:
+			p.close(tagName)
+		case strings.HasSuffix(tag, "/"):
+			// An empty XML element has no value
+		default:
+			open, openLine = tagName, line
+		}
+	}
+}
+
+// readOFXTag reads a tag after its "<" up to the closer that ends it, "-->" for comments, "?>" for processing
+// instructions and ">" for the rest. The tag is returned without its brackets.
+func readOFXTag(r *bufio.Reader) (string, string, error) {
+	closer := ">"
+	switch next, _ := r.Peek(3); {
+	case bytes.HasPrefix(next, []byte("!--")):
+		closer = "-->"
+	case bytes.HasPrefix(next, []byte("?")):
+		closer = "?>"
+	}
+
+	var tag strings.Builder
+	for {
+		chunk, err := r.ReadString('>')
+		tag.WriteString(chunk)
+		if err != nil {
+			return "", closer, err
+		}
+		if strings.HasSuffix(tag.String(), closer) {
+			return strings.TrimSuffix(tag.String(), closer), closer, nil
+		}
+	}
+}
+
+// push opens an aggregate
+func (p *ofxParser) push(name string, line int) {
+	p.stack = append(p.stack, name)
+
+	switch name {
+	case "STMTRS", "CCSTMTRS":
+		p.account, p.currency = "", ""
+	case "STMTTRN":
+		p.current = &ofxTransaction{line: line}
+	}
+}
+
+// close ends the aggregate and any aggregates left open inside it. Closing tags of elements are ignored.
+func (p *ofxParser) close(name string) {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i] != name {
+			continue
+		}
+
+		for len(p.stack) > i {
+			closed := p.stack[len(p.stack)-1]
+			p.stack = p.stack[:len(p.stack)-1]
+
+			if closed == "STMTTRN" && p.current != nil {
+				// A transaction that cannot be read is rejected, the rest of the statement is still read
+				transaction, err := p.current.transaction(p.account, p.currency)
+				if err != nil {
+					p.rejected = append(p.rejected, RowError{Line: p.current.line, Reason: err.Error()})
+				} else {
+					p.transactions = append(p.transactions, transaction)
+				}
+				p.current = nil
+			}
+		}
+		return
+	}
+}
+
+// element stores the value of an element the parser needs
+func (p *ofxParser) element(name string, value string) {
+	parent := ""
+	if len(p.stack) > 0 {
+		parent = p.stack[len(p.stack)-1]
+	}
+
+	// The account the statement is for, a transfer's BANKACCTTO inside a STMTTRN is not it
+	if name == "ACCTID" && (parent == "BANKACCTFROM" || parent == "CCACCTFROM") {
+		p.account = value
+		return
+	}
+
+	if name == "CURDEF" && (parent == "STMTRS" || parent == "CCSTMTRS") {
+		p.currency = value
+		return
+	}
+
+	if p.current == nil {
+		return
+	}
+
+	switch parent {
+	case "STMTTRN":
+		switch name {
+		case "DTPOSTED":
+			p.current.posted = value
+		case "DTUSER":
+			p.current.user = value
+		case "DTAVAIL":
+			p.current.available = value
+		case "TRNAMT":
+			p.current.amount = value
+		case "FITID":
+			p.current.fitID = value
+		case "NAME":
+			p.current.name = value
+		case "MEMO":
+			p.current.memo = value
+		case "CHECKNUM":
+			p.current.checkNum = value
+		}
+	case "PAYEE":
+		// Some banks send a full PAYEE aggregate instead of NAME
+		if name == "NAME" && p.current.name == "" {
+			p.current.name = value
+		}
+	}
+}
+
+func (t *ofxTransaction) transaction(account string, currency string) (models.Transaction, error) {
+	posted := t.posted
+	if posted == "" {
+		posted = t.user
+	}
+
+	date, err := ofxDate(posted)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	// The specification allows a comma as the decimal separator and some European banks use it
+	separator := "."
+	if strings.Contains(t.amount, ",") && !strings.Contains(t.amount, ".") {
+		separator = ","
+	}
+
+	amount, err := ParseAmount(t.amount, separator)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	// DTAVAIL is when the funds are available, the value date
+	var valueDate *time.Time
+	if t.available != "" {
+		available, err := ofxDate(t.available)
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		valueDate = &available
+	}
+
+	description := describe(t.name, t.memo)
+	if description == defaultDescription && t.checkNum != "" {
+		description = "Check " + t.checkNum
+	}
+
+	return models.Transaction{
+		Date:           date,
+		ValueDate:      valueDate,
+		AmountCents:    amount,
+		Currency:       currency,
+		Description:    description,
+		Counterparty:   t.name,
+		RemittanceInfo: t.memo,
+		Account:        account,
+		FitId:          t.fitID,
+	}, nil
+}
+
+// ofxDate reads the day of an OFX date, e.g. "20241111", "20241111120000" or "20241111120000.000[-5:EST]".
+// Banks write the time in their own zone, so the time of day is dropped rather than converted.
+func ofxDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// describe combines a payee and memo into a description, either may be empty
+func describe(payee string, memo string) string {
+	payee, memo = strings.TrimSpace(payee), strings.TrimSpace(memo)
+	switch {
+	case payee == "" && memo == "":
+		return defaultDescription
+	case payee == "":
+		return memo
+	case memo == "" || strings.EqualFold(payee, memo):
+		return payee
+	}
+	return payee + " - " + memo
+}
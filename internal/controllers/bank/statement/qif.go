@@ -0,0 +1,145 @@
+package statement
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// qifDateFormats are tried when no profile names them, Quicken writes the month first
+var qifDateFormats = []string{"M/D/YYYY", "M/D/YY", "YYYY-MM-DD"}
+
+// qifTransactionTypes are the QIF sections that hold bank, cash and credit card transactions.
+// Investment, category, class and memorized transaction lists are skipped.
+var qifTransactionTypes = map[string]bool{
+	"!type:bank":  true,
+	"!type:cash":  true,
+	"!type:ccard": true,
+	"!type:oth a": true,
+	"!type:oth l": true,
+}
+
+// qifEntry collects the fields of a QIF entry up to its "^"
+type qifEntry struct {
+	line   int
+	fields map[byte]string
+}
+
+// ParseQIF reads the bank, cash and credit card transactions of a QIF file. QIF has no transaction IDs, so
+// importing a file again stores its transactions again. The account is the last one named in an !Account list.
+// The file is read one line at a time, lines that are not valid UTF-8 are read as Windows-1252.
+func ParseQIF(r io.Reader, dateFormats []string, decimalSeparator string) (*Result, error) {
+	if len(dateFormats) == 0 {
+		dateFormats = qifDateFormats
+	}
+
+	dates, err := newDateParser(dateFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := newTextReader(r, "", "windows-1252")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Format: FormatQIF, Transactions: []models.Transaction{}, Rejected: []RowError{}}
+	section, account := "!type:bank", ""
+	entry := qifEntry{fields: map[byte]string{}}
+
+	// finish turns the entry into a transaction or an account, depending on the section it is in
+	finish := func() {
+		defer func() { entry = qifEntry{fields: map[byte]string{}} }()
+		if len(entry.fields) == 0 {
+			return
+		}
+
+		switch {
+		case section == "!account":
+			account = entry.fields['N']
+		case qifTransactionTypes[section]:
+			transaction, err := entry.transaction(dates, decimalSeparator)
+			if err != nil {
+				result.reject(entry.line, err)
+				return
+			}
+			transaction.Account = account
+			result.Transactions = append(result.Transactions, transaction)
+		}
+	}
+
+	scanner := bufio.NewScanner(text)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '!':
+			// Options such as !Option:AutoSwitch do not start a new section
+			header := strings.ToLower(strings.TrimSpace(line))
+			if strings.HasPrefix(header, "!option:") || strings.HasPrefix(header, "!clear:") {
+				continue
+			}
+			finish()
+			section = header
+		case '^':
+			finish()
+		default:
+			if len(entry.fields) == 0 {
+				entry.line = number
+			}
+			// Split lines (S, E, $) and address lines (A) repeat, only the first of a code is kept
+			if _, exists := entry.fields[line[0]]; !exists {
+				entry.fields[line[0]] = strings.TrimSpace(line[1:])
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// The last entry of a file is sometimes not ended with "^"
+	finish()
+
+	result.Encoding = text.Encoding()
+
+	return result, nil
+}
+
+func (e qifEntry) transaction(dates *dateParser, decimalSeparator string) (models.Transaction, error) {
+	// Quicken writes years from 2000 with an apostrophe and pads single digits with spaces, e.g. "1/ 5'24"
+	value := strings.NewReplacer("'", "/", " ", "").Replace(e.fields['D'])
+	if value == "" {
+		return models.Transaction{}, &RowError{Line: e.line, Reason: "missing date"}
+	}
+
+	date, err := dates.parse(value)
+	if err != nil {
+		return models.Transaction{}, &RowError{Line: e.line, Reason: err.Error()}
+	}
+
+	amountValue, ok := e.fields['T']
+	if !ok {
+		amountValue = e.fields['U']
+	}
+
+	amount, err := ParseAmount(amountValue, decimalSeparator)
+	if err != nil {
+		return models.Transaction{}, &RowError{Line: e.line, Reason: err.Error()}
+	}
+
+	return models.Transaction{
+		Date:           date,
+		AmountCents:    amount,
+		Description:    describe(e.fields['P'], e.fields['M']),
+		Counterparty:   e.fields['P'],
+		RemittanceInfo: e.fields['M'],
+	}, nil
+}
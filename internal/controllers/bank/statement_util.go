@@ -0,0 +1,139 @@
+package bank
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/bank/statement"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+const (
+	// maxStatementSize is the largest statement that can be uploaded
+	maxStatementSize = 10 << 20
+	// statementMemory is how much of an upload is kept in memory, the rest is spooled to a temporary file which the
+	// server removes after the request. The parsers read the file as a stream, so it is never held in memory whole.
+	statementMemory = 1 << 20
+)
+
+// readStatement parses the statement in the request and responds with 400 if it cannot be read.
+// Files are sent as multipart/form-data in the "file" field and their format is detected. CSV and QIF files are read
+// with the user's profile in "profileId" or the default profile. The original JSON array of rows is still accepted
+// and read with the default profile.
+func (bc *BankController) readStatement(c *gin.Context, owner owner) (*statement.Result, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxStatementSize)
+
+	if c.ContentType() != "multipart/form-data" {
+		return bc.readJSONStatement(c)
+	}
+
+	// 1) Open the uploaded file
+	var fileHeader *multipart.FileHeader
+	err := c.Request.ParseMultipartForm(statementMemory)
+	if err == nil {
+		fileHeader, err = c.FormFile("file")
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Statement is too large"})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A statement file is required in the file field"})
+		return nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		bc.Logger.Error("Failed to open uploaded statement", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil, false
+	}
+	defer file.Close()
+
+	// 2) Pick the profile
+	profile, ok := bc.uploadProfile(c, owner)
+	if !ok {
+		return nil, false
+	}
+
+	// 3) Parse the file as it is read
+	result, err := statement.Parse(file, *profile)
+	if err != nil {
+		respondParseError(c, err)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// readJSONStatement reads a statement the frontend already split into rows, the first row is the header
+func (bc *BankController) readJSONStatement(c *gin.Context) (*statement.Result, bool) {
+	var rows [][]interface{}
+	if err := c.ShouldBindJSON(&rows); err != nil {
+		bc.Logger.Error("Failed to parse JSON", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON"})
+		return nil, false
+	}
+
+	// Values that are not strings are treated as empty, so a date or amount sent as a number is rejected
+	records := make([]statement.Record, len(rows))
+	for i, row := range rows {
+		fields := make([]string, len(row))
+		for j, value := range row {
+			fields[j], _ = value.(string)
+		}
+		records[i] = statement.Record{Line: i + 1, Fields: fields}
+	}
+
+	result, err := statement.ParseRecords(records, statement.DefaultProfile())
+	if err != nil {
+		respondParseError(c, err)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// uploadProfile returns the user's profile named by the "profileId" form field, or the default profile
+func (bc *BankController) uploadProfile(c *gin.Context, owner owner) (*models.CSVProfile, bool) {
+	value := c.PostForm("profileId")
+	if value == "" {
+		profile := statement.DefaultProfile()
+		return &profile, true
+	}
+
+	profileID, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+		return nil, false
+	}
+
+	profile, err := bc.DB.FindCSVProfile(owner.UserID, profileID)
+	if err != nil {
+		bc.Logger.Error("Failed to find csv profile", "profileID", profileID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+		return nil, false
+	}
+
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// respondParseError tells the user why their statement could not be read, with the line when it is known
+func respondParseError(c *gin.Context, err error) {
+	var rowErr *statement.RowError
+	if errors.As(err, &rowErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": rowErr.Error(), "line": rowErr.Line})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
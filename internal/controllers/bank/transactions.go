@@ -0,0 +1,102 @@
+package bank
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListTransactionsHandler returns the transactions every member of the organization uploaded, newest first
+func (bc *BankController) ListTransactionsHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	transactions, err := bc.DB.FindTransactionsByOrganization(owner.OrganizationID)
+	if err != nil {
+		bc.Logger.Error("Failed to find transactions", "organizationID", owner.OrganizationID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+// GetTransactionHandler returns one of the organization's transactions.
+// Transactions of other organizations get a 404 so their IDs cannot be probed.
+func (bc *BankController) GetTransactionHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	transaction, err := bc.DB.FindTransaction(owner.OrganizationID, transactionID)
+	if err != nil {
+		bc.Logger.Error("Failed to find transaction", "transactionID", transactionID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+		return
+	}
+
+	if transaction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// DeleteTransactionHandler deletes one of the organization's transactions. Members delete the ones they uploaded,
+// owners and admins any of them.
+func (bc *BankController) DeleteTransactionHandler(c *gin.Context) {
+	owner, ok := currentOwner(c)
+	if !ok {
+		return
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	transaction, err := bc.DB.FindTransaction(owner.OrganizationID, transactionID)
+	if err != nil {
+		bc.Logger.Error("Failed to find transaction", "transactionID", transactionID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
+
+	if transaction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	if transaction.UserId != owner.UserID && !owner.Role.CanManageData() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can delete transactions other members uploaded"})
+		return
+	}
+
+	var deleted bool
+	if !bc.inTransaction(c, "Failed to delete transaction", func(tx *sqlx.Tx) error {
+		deleted, err = bc.DB.DeleteTransaction(tx, owner.OrganizationID, transactionID)
+		return err
+	}) {
+		return
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted"})
+}
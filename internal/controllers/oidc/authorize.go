@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// AuthorizeHandler is the authorization endpoint. Signed in users are sent straight back to the client with a code,
+// everyone else signs in on the frontend first, which returns them here afterwards.
+func (o *OIDCController) AuthorizeHandler(c *gin.Context) {
+	clientID := c.Query("client_id")
+	redirectURI := c.Query("redirect_uri")
+	state := c.Query("state")
+
+	// 1) Find the client, errors are shown to the user until the redirect URI is known to belong to the client
+	client, err := o.DB.FindOAuthClient(clientID)
+	if err != nil {
+		o.Logger.Error("Failed to find OAuth client", "clientID", clientID, "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if client == nil {
+		o.oauthError(c, http.StatusBadRequest, "invalid_request", "Unknown client_id")
+		return
+	}
+
+	if !client.AllowsRedirect(redirectURI) {
+		o.oauthError(c, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for this client")
+		return
+	}
+
+	// 2) Validate the request, only the authorization code flow with PKCE is supported
+	if c.Query("response_type") != "code" {
+		o.redirectError(c, redirectURI, state, "unsupported_response_type", "Only the code response type is supported")
+		return
+	}
+
+	scopes := grantedScopes(c.Query("scope"))
+	if !hasScope(scopes, "openid") {
+		o.redirectError(c, redirectURI, state, "invalid_scope", "The openid scope is required")
+		return
+	}
+
+	codeChallenge := c.Query("code_challenge")
+	if codeChallenge == "" || c.Query("code_challenge_method") != "S256" {
+		o.redirectError(c, redirectURI, state, "invalid_request", "PKCE with the S256 code_challenge_method is required")
+		return
+	}
+
+	// 3) Users who are not signed in sign in on the frontend and come back here
+	value, _ := c.Get("user")
+	sessionUser, ok := value.(*models.User)
+	if !ok || sessionUser == nil {
+		if c.Query("prompt") == "none" {
+			o.redirectError(c, redirectURI, state, "login_required", "The user is not signed in")
+			return
+		}
+
+		returnTo := o.Issuer + c.Request.URL.RequestURI()
+		c.Redirect(http.StatusFound, o.FrontendAddress+"/login?returnTo="+url.QueryEscape(returnTo))
+		return
+	}
+
+	// 4) Only active accounts can sign in to other applications
+	user, err := o.DB.FindUserByID(sessionUser.ID)
+	if err != nil {
+		o.Logger.Error("Failed to find user", "userID", sessionUser.ID, "error", err)
+		o.redirectError(c, redirectURI, state, "server_error", "Something went wrong...")
+		return
+	}
+
+	if user == nil || !user.Status.CanSignIn() {
+		o.redirectError(c, redirectURI, state, "access_denied", "The account cannot sign in")
+		return
+	}
+
+	// 5) Issue a single use code bound to the client, the redirect URI and the PKCE challenge
+	code, err := randomToken()
+	if err != nil {
+		o.Logger.Error("Failed to generate authorization code", "error", err)
+		o.redirectError(c, redirectURI, state, "server_error", "Something went wrong...")
+		return
+	}
+
+	record := authorizationCode{
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		Nonce:         c.Query("nonce"),
+		CodeChallenge: codeChallenge,
+	}
+	if err := o.storeJSON(codeKey(code), record, o.CodeExpiry); err != nil {
+		o.Logger.Error("Failed to store authorization code", "error", err)
+		o.redirectError(c, redirectURI, state, "server_error", "Something went wrong...")
+		return
+	}
+
+	// 6) Send the user back to the client
+	o.Logger.Info("Issued authorization code", "clientID", client.ClientID, "userID", user.ID)
+	c.Redirect(http.StatusFound, withQuery(redirectURI, url.Values{"code": {code}, "state": {state}}))
+}
@@ -0,0 +1,27 @@
+package oidc
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiscoveryHandler publishes the provider's configuration as described in OpenID Connect Discovery 1.0
+func (o *OIDCController) DiscoveryHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                o.Issuer,
+		"authorization_endpoint":                o.Issuer + "/oauth2/authorize",
+		"token_endpoint":                        o.Issuer + "/oauth2/token",
+		"userinfo_endpoint":                     o.Issuer + "/oauth2/userinfo",
+		"jwks_uri":                              o.Issuer + "/.well-known/jwks.json",
+		"scopes_supported":                      supportedScopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{o.Keys.SigningAlgorithm()},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "email_verified", "name", "given_name", "family_name", "updated_at"},
+	})
+}
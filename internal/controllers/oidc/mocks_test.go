@@ -0,0 +1,64 @@
+package oidc_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// MockRepository is a mock implementation of the Repository interface.
+type MockRepository struct {
+	FindOAuthClientFunc func(clientID string) (*models.OAuthClient, error)
+	FindUserByIDFunc    func(id int) (*models.User, error)
+}
+
+func (m *MockRepository) FindOAuthClient(clientID string) (*models.OAuthClient, error) {
+	return m.FindOAuthClientFunc(clientID)
+}
+
+func (m *MockRepository) FindUserByID(id int) (*models.User, error) {
+	return m.FindUserByIDFunc(id)
+}
+
+// MockCache is an in memory implementation of the Cache interface, expirations are ignored. When Err is set every
+// command fails with it, as it would while redis is down.
+type MockCache struct {
+	Store map[string]string
+	Err   error
+}
+
+func (m *MockCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	if m.Err != nil {
+		return redis.NewStringResult("", m.Err)
+	}
+	value, ok := m.Store[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (m *MockCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if m.Err != nil {
+		return redis.NewStatusResult("", m.Err)
+	}
+	m.Store[key] = string(value.([]byte))
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *MockCache) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	if m.Err != nil {
+		return redis.NewIntResult(0, m.Err)
+	}
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := m.Store[key]; ok {
+			delete(m.Store, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
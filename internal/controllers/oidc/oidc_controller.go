@@ -0,0 +1,80 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// Repository is the data access the OpenID Connect provider needs
+type Repository interface {
+	FindOAuthClient(clientID string) (*models.OAuthClient, error)
+	FindUserByID(id int) (*models.User, error)
+}
+
+// Cache stores authorization codes and access tokens until they expire
+type Cache interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// OIDCController lets our other applications sign their users in with their account here
+type OIDCController struct {
+	Logger            *slog.Logger
+	DB                Repository
+	Cache             Cache
+	Keys              *signing.KeyManager
+	Issuer            string
+	FrontendAddress   string
+	CodeExpiry        time.Duration
+	AccessTokenExpiry time.Duration
+	IDTokenExpiry     time.Duration
+}
+
+// NewOIDCController initializes a new OIDCController, ID tokens must be signed with a key clients can verify from the JWKS
+func NewOIDCController(logger *slog.Logger, db Repository, cache Cache, keys *signing.KeyManager, cfg *config.Config) (*OIDCController, error) {
+	if !keys.Asymmetric() {
+		return nil, errors.New("the OpenID Connect provider needs asymmetric JWT_SIGNING_KEYS")
+	}
+
+	if cfg.OIDC.Issuer == "" {
+		return nil, errors.New("OIDC_ISSUER is required")
+	}
+
+	codeExpiry, err := time.ParseDuration(cfg.OIDC.CodeExpiry)
+	if err != nil || codeExpiry <= 0 {
+		return nil, fmt.Errorf("invalid OIDC_CODE_EXPIRY %q", cfg.OIDC.CodeExpiry)
+	}
+
+	accessTokenExpiry, err := time.ParseDuration(cfg.OIDC.AccessTokenExpiry)
+	if err != nil || accessTokenExpiry <= 0 {
+		return nil, fmt.Errorf("invalid OIDC_ACCESS_TOKEN_EXPIRY %q", cfg.OIDC.AccessTokenExpiry)
+	}
+
+	idTokenExpiry, err := time.ParseDuration(cfg.OIDC.IDTokenExpiry)
+	if err != nil || idTokenExpiry <= 0 {
+		return nil, fmt.Errorf("invalid OIDC_ID_TOKEN_EXPIRY %q", cfg.OIDC.IDTokenExpiry)
+	}
+
+	return &OIDCController{
+		Logger:            logger,
+		DB:                db,
+		Cache:             cache,
+		Keys:              keys,
+		Issuer:            strings.TrimSuffix(cfg.OIDC.Issuer, "/"),
+		FrontendAddress:   cfg.Frontend.Addr,
+		CodeExpiry:        codeExpiry,
+		AccessTokenExpiry: accessTokenExpiry,
+		IDTokenExpiry:     idTokenExpiry,
+	}, nil
+}
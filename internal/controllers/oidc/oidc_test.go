@@ -0,0 +1,370 @@
+package oidc_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/controllers/oidc"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+const (
+	testIssuer      = "https://auth.example.com"
+	testRedirectURI = "https://budget.example.com/callback"
+	testSecret      = "budgeting-secret"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// newTestKeys writes an Ed25519 key to a temporary PEM file and loads it.
+func newTestKeys(t *testing.T) *signing.KeyManager {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ed25519.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	keys, err := signing.LoadKeyManager(config.JWTConfig{SigningKeys: "oidc=" + path})
+	require.NoError(t, err)
+	return keys
+}
+
+func newTestController(t *testing.T, user *models.User) (*oidc.OIDCController, *MockCache) {
+	t.Helper()
+
+	secretHash := models.HashClientSecret(testSecret)
+	clients := map[string]*models.OAuthClient{
+		"budgeting": {ClientID: "budgeting", ClientSecretHash: &secretHash, Name: "Budgeting", RedirectURIs: []string{testRedirectURI}},
+		"mobile":    {ClientID: "mobile", Name: "Mobile", RedirectURIs: []string{"http://localhost:5173/callback"}},
+	}
+
+	repo := &MockRepository{
+		FindOAuthClientFunc: func(clientID string) (*models.OAuthClient, error) {
+			return clients[clientID], nil
+		},
+		FindUserByIDFunc: func(id int) (*models.User, error) {
+			if id == user.ID {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+
+	cache := &MockCache{Store: map[string]string{}}
+
+	cfg := &config.Config{
+		Frontend: config.FrontendConfig{Addr: "https://app.example.com"},
+		OIDC:     config.OIDCConfig{Issuer: testIssuer + "/", CodeExpiry: "1m", AccessTokenExpiry: "1h", IDTokenExpiry: "1h"},
+	}
+
+	controller, err := oidc.NewOIDCController(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, cache, newTestKeys(t), cfg)
+	require.NoError(t, err)
+	return controller, cache
+}
+
+func challenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// authorize calls the authorization endpoint, user is nil for a visitor who has not signed in.
+func authorize(controller *oidc.OIDCController, user *models.User, query url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+query.Encode(), nil)
+	if user != nil {
+		c.Set("user", user)
+	}
+
+	controller.AuthorizeHandler(c)
+	return w
+}
+
+func authorizeQuery(clientID string, redirectURI string) url.Values {
+	return url.Values{
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"response_type":         {"code"},
+		"scope":                 {"openid email profile offline_access"},
+		"state":                 {"xyz"},
+		"nonce":                 {"n-0S6"},
+		"code_challenge":        {challenge(testVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// exchange posts a form to the token endpoint, the client authenticates with basic auth when a secret is given.
+func exchange(controller *oidc.OIDCController, form url.Values, clientID string, secret string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if secret != "" {
+		c.Request.SetBasicAuth(clientID, secret)
+	}
+
+	controller.TokenHandler(c)
+	return w
+}
+
+func userInfo(controller *oidc.OIDCController, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+
+	controller.UserInfoHandler(c)
+	return w
+}
+
+// redirectQuery returns the query of the Location header.
+func redirectQuery(t *testing.T, w *httptest.ResponseRecorder) url.Values {
+	t.Helper()
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestOIDCController_AuthorizationCodeFlow(t *testing.T) {
+	user := &models.User{ID: 7, Email: "jane@example.com", FirstName: "Jane", LastName: "Doe", Verified: true, Status: models.AccountStatusActive}
+	controller, cache := newTestController(t, user)
+
+	// 1) The discovery document points at our endpoints and advertises the signing algorithm
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	controller.DiscoveryHandler(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var discovery map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &discovery))
+	assert.Equal(t, testIssuer, discovery["issuer"])
+	assert.Equal(t, testIssuer+"/oauth2/token", discovery["token_endpoint"])
+	assert.Equal(t, []interface{}{"EdDSA"}, discovery["id_token_signing_alg_values_supported"])
+
+	// 2) A signed in user is sent back to the client with a code and the state
+	query := redirectQuery(t, authorize(controller, user, authorizeQuery("budgeting", testRedirectURI)))
+	assert.Equal(t, "xyz", query.Get("state"))
+	code := query.Get("code")
+	require.NotEmpty(t, code)
+	for key := range cache.Store {
+		assert.NotContains(t, key, code, "codes are stored by hash")
+	}
+
+	// 3) The client exchanges the code for tokens
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testVerifier},
+	}
+	w = exchange(controller, form, "budgeting", testSecret)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		IDToken     string `json:"id_token"`
+		Scope       string `json:"scope"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, 3600, tokens.ExpiresIn)
+	assert.Equal(t, "openid email profile", tokens.Scope, "unsupported scopes are not granted")
+
+	// 4) The ID token verifies with the published keys and carries the user's claims
+	claims, err := controller.Keys.Parse(tokens.IDToken)
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer, claims["iss"])
+	assert.Equal(t, "budgeting", claims["aud"])
+	assert.Equal(t, "7", claims["sub"])
+	assert.Equal(t, "n-0S6", claims["nonce"])
+	assert.Equal(t, "jane@example.com", claims["email"])
+	assert.Equal(t, "Jane Doe", claims["name"])
+	assert.NotContains(t, claims, "user_id", "ID tokens must not be accepted as session tokens")
+
+	// 5) A code can only be exchanged once
+	w = exchange(controller, form, "budgeting", testSecret)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_grant")
+
+	// 6) The access token works on the userinfo endpoint until the account is suspended
+	w = userInfo(controller, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"sub":"7"`)
+	assert.Contains(t, w.Body.String(), `"email_verified":true`)
+
+	w = userInfo(controller, "not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+
+	user.Status = models.AccountStatusSuspended
+	w = userInfo(controller, tokens.AccessToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOIDCController_PublicClient(t *testing.T) {
+	user := &models.User{ID: 7, Email: "jane@example.com", Status: models.AccountStatusActive}
+	controller, _ := newTestController(t, user)
+	redirectURI := "http://localhost:5173/callback"
+
+	code := redirectQuery(t, authorize(controller, user, authorizeQuery("mobile", redirectURI))).Get("code")
+	require.NotEmpty(t, code)
+
+	// Public clients have no secret and rely on PKCE alone
+	w := exchange(controller, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"mobile"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {testVerifier},
+	}, "", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestOIDCController_Errors(t *testing.T) {
+	user := &models.User{ID: 7, Email: "jane@example.com", Status: models.AccountStatusActive}
+	controller, _ := newTestController(t, user)
+
+	newCode := func() string {
+		code := redirectQuery(t, authorize(controller, user, authorizeQuery("budgeting", testRedirectURI))).Get("code")
+		require.NotEmpty(t, code)
+		return code
+	}
+
+	tokenForm := func(code string, verifier string) url.Values {
+		return url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {testRedirectURI},
+			"code_verifier": {verifier},
+		}
+	}
+
+	// 1) Unknown clients and unregistered redirect URIs are never redirected to
+	w := authorize(controller, user, authorizeQuery("unknown", testRedirectURI))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = authorize(controller, user, authorizeQuery("budgeting", "https://evil.example.com/callback"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+
+	// 2) Requests without PKCE or the openid scope are sent back with an error
+	query := authorizeQuery("budgeting", testRedirectURI)
+	query.Del("code_challenge")
+	assert.Equal(t, "invalid_request", redirectQuery(t, authorize(controller, user, query)).Get("error"))
+
+	query = authorizeQuery("budgeting", testRedirectURI)
+	query.Set("scope", "email")
+	assert.Equal(t, "invalid_scope", redirectQuery(t, authorize(controller, user, query)).Get("error"))
+
+	// 3) Visitors sign in on the frontend first, unless the client asked us not to prompt
+	w = authorize(controller, nil, authorizeQuery("budgeting", testRedirectURI))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Location"), "https://app.example.com/login?returnTo="+url.QueryEscape(testIssuer+"/oauth2/authorize?")))
+
+	query = authorizeQuery("budgeting", testRedirectURI)
+	query.Set("prompt", "none")
+	assert.Equal(t, "login_required", redirectQuery(t, authorize(controller, nil, query)).Get("error"))
+
+	// 4) The client must authenticate with its secret
+	w = exchange(controller, tokenForm(newCode(), testVerifier), "budgeting", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_client")
+
+	// 5) The verifier must match the challenge, and a failed exchange burns the code
+	code := newCode()
+	w = exchange(controller, tokenForm(code, strings.Repeat("a", 43)), "budgeting", testSecret)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_grant")
+
+	w = exchange(controller, tokenForm(code, testVerifier), "budgeting", testSecret)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 6) The redirect URI must match the one the code was issued for
+	form := tokenForm(newCode(), testVerifier)
+	form.Set("redirect_uri", "https://budget.example.com/other")
+	w = exchange(controller, form, "budgeting", testSecret)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 7) Only the authorization code grant is supported
+	form = tokenForm(newCode(), testVerifier)
+	form.Set("grant_type", "password")
+	w = exchange(controller, form, "budgeting", testSecret)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unsupported_grant_type")
+
+	// 8) Suspended users cannot sign in to other applications
+	user.Status = models.AccountStatusSuspended
+	assert.Equal(t, "access_denied", redirectQuery(t, authorize(controller, user, authorizeQuery("budgeting", testRedirectURI))).Get("error"))
+}
+
+func TestOIDCController_CacheOutage(t *testing.T) {
+	user := &models.User{ID: 7, Email: "jane@example.com", Status: models.AccountStatusActive}
+	controller, cache := newTestController(t, user)
+
+	code := redirectQuery(t, authorize(controller, user, authorizeQuery("budgeting", testRedirectURI))).Get("code")
+	require.NotEmpty(t, code)
+
+	// 1) While redis is down a code cannot be redeemed, and the client is told to retry rather than that it is invalid
+	cache.Err = errors.New("connection refused")
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testVerifier},
+	}
+	w := exchange(controller, form, "budgeting", testSecret)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "server_error")
+
+	// 2) Access tokens cannot be checked either
+	w = userInfo(controller, "token")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "server_error")
+
+	// 3) Once redis is back the code still works
+	cache.Err = nil
+	w = exchange(controller, form, "budgeting", testSecret)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNewOIDCController_RequiresAsymmetricKeys(t *testing.T) {
+	keys, err := signing.LoadKeyManager(config.JWTConfig{Token: "secret"})
+	require.NoError(t, err)
+
+	cfg := &config.Config{OIDC: config.OIDCConfig{Issuer: testIssuer, CodeExpiry: "1m", AccessTokenExpiry: "1h", IDTokenExpiry: "1h"}}
+	_, err = oidc.NewOIDCController(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockRepository{}, &MockCache{}, keys, cfg)
+	assert.Error(t, err)
+
+	cfg.OIDC.CodeExpiry = "soon"
+	_, err = oidc.NewOIDCController(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockRepository{}, &MockCache{}, newTestKeys(t), cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// supportedScopes are the scopes we grant, anything else a client asks for is ignored
+var supportedScopes = []string{"openid", "email", "profile"}
+
+// authorizationCode is stored in redis against the hash of a code until the client exchanges it
+type authorizationCode struct {
+	ClientID      string   `json:"clientId"`
+	UserID        int      `json:"userId"`
+	RedirectURI   string   `json:"redirectUri"`
+	Scopes        []string `json:"scopes"`
+	Nonce         string   `json:"nonce,omitempty"`
+	CodeChallenge string   `json:"codeChallenge"`
+}
+
+// accessToken is stored in redis against the hash of an access token, it only grants access to the userinfo endpoint
+type accessToken struct {
+	ClientID string   `json:"clientId"`
+	UserID   int      `json:"userId"`
+	Scopes   []string `json:"scopes"`
+}
+
+func codeKey(code string) string {
+	return "oidc_code:" + hashToken(code)
+}
+
+func accessTokenKey(token string) string {
+	return "oidc_access_token:" + hashToken(token)
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// randomToken returns 32 random bytes, base64url encoded
+func randomToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// storeJSON saves a record in redis until it expires
+func (o *OIDCController) storeJSON(key string, record interface{}, expiry time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	if err := o.Cache.Set(context.Background(), key, data, expiry).Err(); err != nil {
+		return fmt.Errorf("failed to store record: %w", err)
+	}
+	return nil
+}
+
+// loadJSON reads a record from redis, it reports false if the record does not exist. Any other redis error is
+// returned, so an outage is a server error rather than an invalid code or token.
+func (o *OIDCController) loadJSON(key string, record interface{}) (bool, error) {
+	data, err := o.Cache.Get(context.Background(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load record: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return false, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return true, nil
+}
+
+// grantedScopes keeps the supported scopes out of a space separated scope parameter
+func grantedScopes(scope string) []string {
+	requested := strings.Fields(scope)
+
+	var granted []string
+	for _, supported := range supportedScopes {
+		for _, s := range requested {
+			if s == supported {
+				granted = append(granted, supported)
+				break
+			}
+		}
+	}
+	return granted
+}
+
+func joinScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyPKCE checks the code verifier against the S256 challenge from the authorization request (RFC 7636)
+func verifyPKCE(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(hash[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// userClaims returns the claims about the user that the scopes allow the client to see
+func userClaims(user *models.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": strconv.Itoa(user.ID),
+	}
+
+	if hasScope(scopes, "email") {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.Verified
+	}
+
+	if hasScope(scopes, "profile") {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+
+	return claims
+}
+
+// oauthError responds with an error in the format defined by RFC 6749 section 5.2
+func (o *OIDCController) oauthError(c *gin.Context, status int, code string, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// redirectError sends the user back to the client with an error, only used once the redirect URI has been validated
+func (o *OIDCController) redirectError(c *gin.Context, redirectURI string, state string, code string, description string) {
+	c.Redirect(http.StatusFound, withQuery(redirectURI, url.Values{
+		"error":             {code},
+		"error_description": {description},
+		"state":             {state},
+	}))
+}
+
+// withQuery adds the values to the URI's query string, empty values are left out
+func withQuery(uri string, values url.Values) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := parsed.Query()
+	for key, value := range values {
+		if len(value) > 0 && value[0] != "" {
+			query.Set(key, value[0])
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
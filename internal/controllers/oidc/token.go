@@ -0,0 +1,138 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenHandler exchanges an authorization code for an ID token and an access token for the userinfo endpoint
+func (o *OIDCController) TokenHandler(c *gin.Context) {
+	// 1) Only the authorization code grant is supported
+	if c.PostForm("grant_type") != "authorization_code" {
+		o.oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only the authorization_code grant is supported")
+		return
+	}
+
+	// 2) Authenticate the client, confidential clients send their secret with HTTP basic auth or in the form
+	clientID, clientSecret, basicAuth := c.Request.BasicAuth()
+	if !basicAuth {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	client, err := o.DB.FindOAuthClient(clientID)
+	if err != nil {
+		o.Logger.Error("Failed to find OAuth client", "clientID", clientID, "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if client == nil || (!client.Public() && !client.VerifySecret(clientSecret)) {
+		o.Logger.Error("OAuth client authentication failed", "clientID", clientID)
+		o.oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	// 3) Redeem the code, it is loaded and then deleted, and only the request whose delete removed it may use it,
+	// so a code can only ever be exchanged once
+	code := c.PostForm("code")
+	var record authorizationCode
+	found, err := o.loadJSON(codeKey(code), &record)
+	if err != nil {
+		o.Logger.Error("Failed to load authorization code", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if !found || code == "" {
+		o.oauthError(c, http.StatusBadRequest, "invalid_grant", "The code is invalid or expired")
+		return
+	}
+
+	deleted, err := o.Cache.Del(context.Background(), codeKey(code)).Result()
+	if err != nil {
+		o.Logger.Error("Failed to delete authorization code", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if deleted != 1 {
+		o.oauthError(c, http.StatusBadRequest, "invalid_grant", "The code is invalid or expired")
+		return
+	}
+
+	// 4) The code must have been issued to this client and redirect URI, and the verifier must match the challenge
+	if record.ClientID != client.ClientID || record.RedirectURI != c.PostForm("redirect_uri") {
+		o.oauthError(c, http.StatusBadRequest, "invalid_grant", "The code was issued to another client or redirect_uri")
+		return
+	}
+
+	if !verifyPKCE(c.PostForm("code_verifier"), record.CodeChallenge) {
+		o.oauthError(c, http.StatusBadRequest, "invalid_grant", "The code_verifier does not match the code_challenge")
+		return
+	}
+
+	// 5) The user may have been suspended since the code was issued
+	user, err := o.DB.FindUserByID(record.UserID)
+	if err != nil {
+		o.Logger.Error("Failed to find user", "userID", record.UserID, "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if user == nil || !user.Status.CanSignIn() {
+		o.oauthError(c, http.StatusBadRequest, "invalid_grant", "The account cannot sign in")
+		return
+	}
+
+	// 6) Issue the access token, it is opaque and only accepted by the userinfo endpoint
+	token, err := randomToken()
+	if err != nil {
+		o.Logger.Error("Failed to generate access token", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if err := o.storeJSON(accessTokenKey(token), accessToken{ClientID: client.ClientID, UserID: user.ID, Scopes: record.Scopes}, o.AccessTokenExpiry); err != nil {
+		o.Logger.Error("Failed to store access token", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	// 7) Sign the ID token with the same keys as our own tokens, clients verify it with the JWKS
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": o.Issuer,
+		"aud": client.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(o.IDTokenExpiry).Unix(),
+	}
+	for name, value := range userClaims(user, record.Scopes) {
+		claims[name] = value
+	}
+	if record.Nonce != "" {
+		claims["nonce"] = record.Nonce
+	}
+
+	idToken, err := o.Keys.Sign(claims)
+	if err != nil {
+		o.Logger.Error("Failed to sign ID token", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	o.Logger.Info("Issued ID token", "clientID", client.ClientID, "userID", user.ID)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(o.AccessTokenExpiry.Seconds()),
+		"id_token":     idToken,
+		"scope":        joinScopes(record.Scopes),
+	})
+}
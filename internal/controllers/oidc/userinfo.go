@@ -0,0 +1,53 @@
+package oidc
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserInfoHandler returns the claims the access token's scopes allow the client to see
+func (o *OIDCController) UserInfoHandler(c *gin.Context) {
+	// 1) Look up the bearer token
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		o.invalidToken(c, "Missing bearer token")
+		return
+	}
+
+	var record accessToken
+	found, err := o.loadJSON(accessTokenKey(token), &record)
+	if err != nil {
+		o.Logger.Error("Failed to load access token", "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if !found {
+		o.invalidToken(c, "The access token is invalid or expired")
+		return
+	}
+
+	// 2) The token stops working as soon as the account can no longer sign in
+	user, err := o.DB.FindUserByID(record.UserID)
+	if err != nil {
+		o.Logger.Error("Failed to find user", "userID", record.UserID, "error", err)
+		o.oauthError(c, http.StatusInternalServerError, "server_error", "Something went wrong...")
+		return
+	}
+
+	if user == nil || !user.Status.CanSignIn() {
+		o.invalidToken(c, "The account cannot sign in")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userClaims(user, record.Scopes))
+}
+
+// invalidToken responds as described by RFC 6750 section 3
+func (o *OIDCController) invalidToken(c *gin.Context, description string) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	o.oauthError(c, http.StatusUnauthorized, "invalid_token", description)
+}
@@ -0,0 +1,151 @@
+package org
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+type InviteMemberRequest struct {
+	Email string                  `json:"email" binding:"required,email"`
+	Role  models.OrganizationRole `json:"role"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// InviteMemberHandler emails an invitation to join the organization. Owners can invite admins and members,
+// admins can only invite members. Owners are made by changing a member's role.
+func (o *OrgController) InviteMemberHandler(c *gin.Context) {
+	// 1) Only owners and admins invite
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	membership, ok := o.currentMembership(c, user)
+	if !ok {
+		return
+	}
+
+	if !membership.Role.CanManageMembers() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can invite members"})
+		return
+	}
+
+	// 2) Validate the request
+	var request InviteMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		o.Logger.Error("Invalid invite request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid email is required"})
+		return
+	}
+
+	if request.Role == "" {
+		request.Role = models.OrganizationRoleMember
+	}
+
+	if request.Role != models.OrganizationRoleAdmin && request.Role != models.OrganizationRoleMember {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be admin or member"})
+		return
+	}
+
+	if request.Role == models.OrganizationRoleAdmin && membership.Role != models.OrganizationRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can invite admins"})
+		return
+	}
+
+	organization, err := o.DB.FindOrganizationByID(membership.OrganizationID)
+	if err != nil || organization == nil {
+		o.Logger.Error("Failed to find organization", "organizationID", membership.OrganizationID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send invitation"})
+		return
+	}
+
+	// 3) Email the invitation
+	invitation := models.OrganizationInvitation{
+		OrganizationID:   organization.ID,
+		OrganizationName: organization.Name,
+		Email:            strings.ToLower(request.Email),
+		Role:             request.Role,
+		InvitedBy:        user.ID,
+	}
+
+	if err := o.Invitations.SendOrganizationInvitation(invitation); err != nil {
+		o.Logger.Error("Failed to send invitation", "organizationID", organization.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send invitation"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Invitation sent"})
+}
+
+// AcceptInvitationHandler adds the user to the organization they were invited to.
+// The invitation must have been sent to the user's email, it can only be accepted once.
+func (o *OrgController) AcceptInvitationHandler(c *gin.Context) {
+	// 1) Get the user and the invitation
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		return
+	}
+
+	invitation, err := o.Invitations.FindOrganizationInvitation(request.Token)
+	if err != nil {
+		o.Logger.Error("Failed to find invitation", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	if invitation == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invitation"})
+		return
+	}
+
+	// 2) Someone else signed in cannot use up the invitation
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This invitation was sent to another email address"})
+		return
+	}
+
+	consumed, err := o.Invitations.ConsumeOrganizationInvitation(request.Token)
+	if err != nil {
+		o.Logger.Error("Failed to consume invitation", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	if !consumed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invitation"})
+		return
+	}
+
+	// 3) Add the membership
+	membership := &models.Membership{OrganizationID: invitation.OrganizationID, UserID: user.ID, Role: invitation.Role}
+
+	var created bool
+	if !o.inTransaction(c, "Failed to accept invitation", func(tx *sqlx.Tx) error {
+		created, err = o.DB.CreateMembership(tx, membership)
+		return err
+	}) {
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already a member of this organization"})
+		return
+	}
+
+	o.audit(c, user, membership.OrganizationID, "join "+string(membership.Role), user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted", "membership": membership})
+}
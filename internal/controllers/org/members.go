@@ -0,0 +1,219 @@
+package org
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// errLastOwner stops a change that would leave an organization without an owner
+var errLastOwner = errors.New("organization must keep an owner")
+
+type UpdateMemberRoleRequest struct {
+	Role models.OrganizationRole `json:"role" binding:"required"`
+}
+
+// ListMembersHandler returns the members of an organization the user belongs to
+func (o *OrgController) ListMembersHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	membership, ok := o.currentMembership(c, user)
+	if !ok {
+		return
+	}
+
+	members, err := o.DB.FindOrganizationMembers(membership.OrganizationID)
+	if err != nil {
+		o.Logger.Error("Failed to list members", "organizationID", membership.OrganizationID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// UpdateMemberRoleHandler changes a member's role, only owners can change roles and the last owner cannot step down
+func (o *OrgController) UpdateMemberRoleHandler(c *gin.Context) {
+	// 1) Only owners change roles
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	membership, ok := o.currentMembership(c, user)
+	if !ok {
+		return
+	}
+
+	if membership.Role != models.OrganizationRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can change roles"})
+		return
+	}
+
+	// 2) Validate the request
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil || !request.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be owner, admin or member"})
+		return
+	}
+
+	member, err := o.DB.FindMembership(membership.OrganizationID, memberID)
+	if err != nil {
+		o.Logger.Error("Failed to find membership", "organizationID", membership.OrganizationID, "userID", memberID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if member == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	// 3) Change the role, keeping at least one owner
+	tx, err := o.DB.Beginx()
+	if err != nil {
+		o.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	defer tx.Rollback()
+
+	if err := o.keepOwner(tx, member, request.Role); err != nil {
+		o.respondMembershipError(c, err, "Failed to update role")
+		return
+	}
+
+	if _, err := o.DB.UpdateMembershipRole(tx, member.OrganizationID, member.UserID, request.Role); err != nil {
+		o.Logger.Error("Failed to update role", "organizationID", member.OrganizationID, "userID", member.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		o.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	o.audit(c, user, member.OrganizationID, "role "+string(request.Role), member.UserID)
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
+
+// RemoveMemberHandler removes a member. Anyone can leave, admins can remove members and owners can remove anyone,
+// the last owner cannot leave.
+func (o *OrgController) RemoveMemberHandler(c *gin.Context) {
+	// 1) Get the user and the member to remove
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	membership, ok := o.currentMembership(c, user)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	member := membership
+	if memberID != user.ID {
+		if !membership.Role.CanManageMembers() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only owners and admins can remove members"})
+			return
+		}
+
+		member, err = o.DB.FindMembership(membership.OrganizationID, memberID)
+		if err != nil {
+			o.Logger.Error("Failed to find membership", "organizationID", membership.OrganizationID, "userID", memberID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+			return
+		}
+
+		if member == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return
+		}
+
+		// 2) Admins can only remove members
+		if member.Role != models.OrganizationRoleMember && membership.Role != models.OrganizationRoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can remove owners and admins"})
+			return
+		}
+	}
+
+	// 3) Remove the member, keeping at least one owner
+	tx, err := o.DB.Beginx()
+	if err != nil {
+		o.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	defer tx.Rollback()
+
+	if err := o.keepOwner(tx, member, ""); err != nil {
+		o.respondMembershipError(c, err, "Failed to remove member")
+		return
+	}
+
+	if _, err := o.DB.DeleteMembership(tx, member.OrganizationID, member.UserID); err != nil {
+		o.Logger.Error("Failed to remove member", "organizationID", member.OrganizationID, "userID", member.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		o.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	o.audit(c, user, member.OrganizationID, "remove", member.UserID)
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// keepOwner returns errLastOwner when the member is the organization's only owner and would stop being one.
+// An empty role means the member is being removed.
+func (o *OrgController) keepOwner(tx *sqlx.Tx, member *models.Membership, role models.OrganizationRole) error {
+	if member.Role != models.OrganizationRoleOwner || role == models.OrganizationRoleOwner {
+		return nil
+	}
+
+	owners, err := o.DB.CountOrganizationOwners(tx, member.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return errLastOwner
+	}
+	return nil
+}
+
+// respondMembershipError responds with a conflict for errLastOwner and a server error otherwise
+func (o *OrgController) respondMembershipError(c *gin.Context, err error, failure string) {
+	if errors.Is(err, errLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"error": "An organization must keep at least one owner"})
+		return
+	}
+
+	o.Logger.Error(failure, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+}
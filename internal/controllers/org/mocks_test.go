@@ -0,0 +1,117 @@
+package org_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// MockOrganizationRepository is a mock implementation of the OrganizationRepository interface.
+type MockOrganizationRepository struct {
+	FindUserByIDFunc              func(id int) (*models.User, error)
+	FindOrganizationByIDFunc      func(id int) (*models.Organization, error)
+	FindOrganizationsByUserIDFunc func(userID int) ([]models.UserOrganization, error)
+	CreateOrganizationFunc        func(ext sqlx.Ext, organization *models.Organization) error
+	FindMembershipFunc            func(organizationID int, userID int) (*models.Membership, error)
+	FindOrganizationMembersFunc   func(organizationID int) ([]models.OrganizationMember, error)
+	CreateMembershipFunc          func(ext sqlx.Ext, membership *models.Membership) (bool, error)
+	UpdateMembershipRoleFunc      func(ext sqlx.Ext, organizationID int, userID int, role models.OrganizationRole) (bool, error)
+	DeleteMembershipFunc          func(ext sqlx.Ext, organizationID int, userID int) (bool, error)
+	CountOrganizationOwnersFunc   func(ext sqlx.Ext, organizationID int) (int, error)
+	SetActiveOrganizationFunc     func(ext sqlx.Ext, userID int, organizationID *int) error
+	BeginxFunc                    func() (*sqlx.Tx, error)
+}
+
+func (m *MockOrganizationRepository) FindUserByID(id int) (*models.User, error) {
+	return m.FindUserByIDFunc(id)
+}
+
+func (m *MockOrganizationRepository) FindOrganizationByID(id int) (*models.Organization, error) {
+	return m.FindOrganizationByIDFunc(id)
+}
+
+func (m *MockOrganizationRepository) FindOrganizationsByUserID(userID int) ([]models.UserOrganization, error) {
+	return m.FindOrganizationsByUserIDFunc(userID)
+}
+
+func (m *MockOrganizationRepository) CreateOrganization(ext sqlx.Ext, organization *models.Organization) error {
+	return m.CreateOrganizationFunc(ext, organization)
+}
+
+func (m *MockOrganizationRepository) FindMembership(organizationID int, userID int) (*models.Membership, error) {
+	return m.FindMembershipFunc(organizationID, userID)
+}
+
+func (m *MockOrganizationRepository) FindOrganizationMembers(organizationID int) ([]models.OrganizationMember, error) {
+	return m.FindOrganizationMembersFunc(organizationID)
+}
+
+func (m *MockOrganizationRepository) CreateMembership(ext sqlx.Ext, membership *models.Membership) (bool, error) {
+	return m.CreateMembershipFunc(ext, membership)
+}
+
+func (m *MockOrganizationRepository) UpdateMembershipRole(ext sqlx.Ext, organizationID int, userID int, role models.OrganizationRole) (bool, error) {
+	return m.UpdateMembershipRoleFunc(ext, organizationID, userID, role)
+}
+
+func (m *MockOrganizationRepository) DeleteMembership(ext sqlx.Ext, organizationID int, userID int) (bool, error) {
+	return m.DeleteMembershipFunc(ext, organizationID, userID)
+}
+
+func (m *MockOrganizationRepository) CountOrganizationOwners(ext sqlx.Ext, organizationID int) (int, error) {
+	return m.CountOrganizationOwnersFunc(ext, organizationID)
+}
+
+func (m *MockOrganizationRepository) SetActiveOrganization(ext sqlx.Ext, userID int, organizationID *int) error {
+	return m.SetActiveOrganizationFunc(ext, userID, organizationID)
+}
+
+func (m *MockOrganizationRepository) Beginx() (*sqlx.Tx, error) {
+	return m.BeginxFunc()
+}
+
+// MockInvitations keeps sent invitations by token instead of emailing them.
+type MockInvitations struct {
+	Sent  []models.OrganizationInvitation
+	Store map[string]models.OrganizationInvitation
+}
+
+func (m *MockInvitations) SendOrganizationInvitation(invitation models.OrganizationInvitation) error {
+	m.Sent = append(m.Sent, invitation)
+	m.Store["invite-"+invitation.Email] = invitation
+	return nil
+}
+
+func (m *MockInvitations) FindOrganizationInvitation(token string) (*models.OrganizationInvitation, error) {
+	invitation, ok := m.Store[token]
+	if !ok {
+		return nil, nil
+	}
+	return &invitation, nil
+}
+
+func (m *MockInvitations) ConsumeOrganizationInvitation(token string) (bool, error) {
+	_, ok := m.Store[token]
+	delete(m.Store, token)
+	return ok, nil
+}
+
+// MockTokens records the users access tokens were issued to.
+type MockTokens struct {
+	Issued []*models.User
+}
+
+func (m *MockTokens) IssueAccessToken(c *gin.Context, user *models.User) error {
+	m.Issued = append(m.Issued, user)
+	return nil
+}
+
+// MockAuditLogger collects recorded events.
+type MockAuditLogger struct {
+	Events []models.AuthEvent
+}
+
+func (m *MockAuditLogger) Record(event models.AuthEvent) {
+	m.Events = append(m.Events, event)
+}
@@ -0,0 +1,61 @@
+package org
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+type OrganizationRepository interface {
+	FindUserByID(id int) (*models.User, error)
+	FindOrganizationByID(id int) (*models.Organization, error)
+	FindOrganizationsByUserID(userID int) ([]models.UserOrganization, error)
+	CreateOrganization(ext sqlx.Ext, organization *models.Organization) error
+	FindMembership(organizationID int, userID int) (*models.Membership, error)
+	FindOrganizationMembers(organizationID int) ([]models.OrganizationMember, error)
+	CreateMembership(ext sqlx.Ext, membership *models.Membership) (bool, error)
+	UpdateMembershipRole(ext sqlx.Ext, organizationID int, userID int, role models.OrganizationRole) (bool, error)
+	DeleteMembership(ext sqlx.Ext, organizationID int, userID int) (bool, error)
+	CountOrganizationOwners(ext sqlx.Ext, organizationID int) (int, error)
+	SetActiveOrganization(ext sqlx.Ext, userID int, organizationID *int) error
+	Beginx() (*sqlx.Tx, error)
+}
+
+// InvitationSender emails invitations and looks up the tokens they carry, the auth controller sends them like reset password links
+type InvitationSender interface {
+	SendOrganizationInvitation(invitation models.OrganizationInvitation) error
+	FindOrganizationInvitation(token string) (*models.OrganizationInvitation, error)
+	ConsumeOrganizationInvitation(token string) (bool, error)
+}
+
+// AccessTokenIssuer replaces the caller's access token so it carries their new active organization
+type AccessTokenIssuer interface {
+	IssueAccessToken(c *gin.Context, user *models.User) error
+}
+
+// AuditLogger records security events, it must not fail the request when the event cannot be stored
+type AuditLogger interface {
+	Record(event models.AuthEvent)
+}
+
+// OrgController lets users share data in organizations, e.g. a household sharing bank statements
+type OrgController struct {
+	Logger      *slog.Logger
+	DB          OrganizationRepository
+	Invitations InvitationSender
+	Tokens      AccessTokenIssuer
+	Audit       AuditLogger
+}
+
+func NewOrgController(logger *slog.Logger, db OrganizationRepository, invitations InvitationSender, tokens AccessTokenIssuer, auditLogger AuditLogger) *OrgController {
+	return &OrgController{
+		Logger:      logger,
+		DB:          db,
+		Invitations: invitations,
+		Tokens:      tokens,
+		Audit:       auditLogger,
+	}
+}
@@ -0,0 +1,265 @@
+package org_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/controllers/org"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// newSQLMockBeginx returns a Beginx that starts a transaction on a fresh sqlmock connection for every call.
+func newSQLMockBeginx(t *testing.T) func() (*sqlx.Tx, error) {
+	return func() (*sqlx.Tx, error) {
+		mockSQL, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { mockSQL.Close() })
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		return sqlx.NewDb(mockSQL, "sqlmock").Beginx()
+	}
+}
+
+// newMemoryRepository wires the mock repository to in memory organizations, memberships and users.
+func newMemoryRepository(t *testing.T, users map[int]*models.User) *MockOrganizationRepository {
+	organizations := map[int]*models.Organization{}
+	memberships := map[int]map[int]models.OrganizationRole{}
+
+	return &MockOrganizationRepository{
+		FindUserByIDFunc: func(id int) (*models.User, error) { return users[id], nil },
+		FindOrganizationByIDFunc: func(id int) (*models.Organization, error) {
+			return organizations[id], nil
+		},
+		FindOrganizationsByUserIDFunc: func(userID int) ([]models.UserOrganization, error) {
+			list := []models.UserOrganization{}
+			for id, members := range memberships {
+				if role, ok := members[userID]; ok {
+					list = append(list, models.UserOrganization{Organization: *organizations[id], Role: role})
+				}
+			}
+			return list, nil
+		},
+		CreateOrganizationFunc: func(ext sqlx.Ext, organization *models.Organization) error {
+			organization.ID = len(organizations) + 1
+			organizations[organization.ID] = organization
+			memberships[organization.ID] = map[int]models.OrganizationRole{}
+			return nil
+		},
+		FindMembershipFunc: func(organizationID int, userID int) (*models.Membership, error) {
+			role, ok := memberships[organizationID][userID]
+			if !ok {
+				return nil, nil
+			}
+			return &models.Membership{OrganizationID: organizationID, UserID: userID, Role: role}, nil
+		},
+		FindOrganizationMembersFunc: func(organizationID int) ([]models.OrganizationMember, error) {
+			list := []models.OrganizationMember{}
+			for userID, role := range memberships[organizationID] {
+				list = append(list, models.OrganizationMember{UserID: userID, Email: users[userID].Email, Role: role})
+			}
+			return list, nil
+		},
+		CreateMembershipFunc: func(ext sqlx.Ext, membership *models.Membership) (bool, error) {
+			if _, ok := memberships[membership.OrganizationID][membership.UserID]; ok {
+				return false, nil
+			}
+			memberships[membership.OrganizationID][membership.UserID] = membership.Role
+			return true, nil
+		},
+		UpdateMembershipRoleFunc: func(ext sqlx.Ext, organizationID int, userID int, role models.OrganizationRole) (bool, error) {
+			memberships[organizationID][userID] = role
+			return true, nil
+		},
+		DeleteMembershipFunc: func(ext sqlx.Ext, organizationID int, userID int) (bool, error) {
+			delete(memberships[organizationID], userID)
+			return true, nil
+		},
+		CountOrganizationOwnersFunc: func(ext sqlx.Ext, organizationID int) (int, error) {
+			owners := 0
+			for _, role := range memberships[organizationID] {
+				if role == models.OrganizationRoleOwner {
+					owners++
+				}
+			}
+			return owners, nil
+		},
+		SetActiveOrganizationFunc: func(ext sqlx.Ext, userID int, organizationID *int) error {
+			users[userID].ActiveOrganizationID = organizationID
+			return nil
+		},
+		BeginxFunc: newSQLMockBeginx(t),
+	}
+}
+
+// executeOrgHandler runs a handler as the given user with the path parameters set.
+func executeOrgHandler(handler gin.HandlerFunc, user *models.User, params gin.Params, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("user", user)
+
+	handler(c)
+	return w
+}
+
+func TestOrgController_Memberships(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	alice := &models.User{ID: 1, Email: "alice@example.com"}
+	bob := &models.User{ID: 2, Email: "bob@example.com"}
+	carol := &models.User{ID: 3, Email: "carol@example.com"}
+	users := map[int]*models.User{1: alice, 2: bob, 3: carol}
+
+	invitations := &MockInvitations{Store: map[string]models.OrganizationInvitation{}}
+	tokens := &MockTokens{}
+	auditLogger := &MockAuditLogger{}
+	controller := org.NewOrgController(slog.New(slog.NewTextHandler(io.Discard, nil)), newMemoryRepository(t, users), invitations, tokens, auditLogger)
+
+	orgParam := gin.Params{{Key: "id", Value: "1"}}
+	memberParam := func(userID string) gin.Params {
+		return gin.Params{{Key: "id", Value: "1"}, {Key: "userId", Value: userID}}
+	}
+
+	// 1) The creator owns the organization
+	w := executeOrgHandler(controller.CreateOrganizationHandler, alice, nil, `{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeOrgHandler(controller.CreateOrganizationHandler, alice, nil, `{"name":"Smith household"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"owner"`)
+
+	// 2) Non members cannot see the organization
+	w = executeOrgHandler(controller.ListMembersHandler, carol, orgParam, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = executeOrgHandler(controller.InviteMemberHandler, carol, orgParam, `{"email":"carol@example.com"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 3) Owners invite by email, owners are only made by changing roles
+	w = executeOrgHandler(controller.InviteMemberHandler, alice, orgParam, `{"email":"bob@example.com","role":"owner"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeOrgHandler(controller.InviteMemberHandler, alice, orgParam, `{"email":"Bob@Example.com"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Len(t, invitations.Sent, 1)
+	assert.Equal(t, models.OrganizationInvitation{
+		OrganizationID:   1,
+		OrganizationName: "Smith household",
+		Email:            "bob@example.com",
+		Role:             models.OrganizationRoleMember,
+		InvitedBy:        alice.ID,
+	}, invitations.Sent[0])
+
+	// 4) Only the invited email can accept, and only once
+	accept := `{"token":"invite-bob@example.com"}`
+	w = executeOrgHandler(controller.AcceptInvitationHandler, carol, nil, accept)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeOrgHandler(controller.AcceptInvitationHandler, bob, nil, accept)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = executeOrgHandler(controller.AcceptInvitationHandler, bob, nil, accept)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = executeOrgHandler(controller.ListMembersHandler, bob, orgParam, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "alice@example.com")
+	assert.Contains(t, w.Body.String(), "bob@example.com")
+
+	// 5) Members cannot invite, change roles or remove others
+	w = executeOrgHandler(controller.InviteMemberHandler, bob, orgParam, `{"email":"carol@example.com"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeOrgHandler(controller.UpdateMemberRoleHandler, bob, memberParam("2"), `{"role":"owner"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = executeOrgHandler(controller.RemoveMemberHandler, bob, memberParam("1"), "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 6) The last owner cannot leave or step down
+	w = executeOrgHandler(controller.RemoveMemberHandler, alice, memberParam("1"), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = executeOrgHandler(controller.UpdateMemberRoleHandler, alice, memberParam("1"), `{"role":"member"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = executeOrgHandler(controller.UpdateMemberRoleHandler, alice, memberParam("2"), `{"role":"superuser"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 7) Once there is another owner, the first can leave
+	w = executeOrgHandler(controller.UpdateMemberRoleHandler, alice, memberParam("2"), `{"role":"owner"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = executeOrgHandler(controller.RemoveMemberHandler, alice, memberParam("1"), "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = executeOrgHandler(controller.ListMembersHandler, alice, orgParam, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 8) Every membership change is audited with the member as the target
+	var details []string
+	for _, event := range auditLogger.Events {
+		assert.Equal(t, models.AuthEventMembershipChanged, event.Type)
+		details = append(details, event.Detail)
+	}
+	assert.Equal(t, []string{"org 1 create", "org 1 join member", "org 1 role owner", "org 1 remove"}, details)
+	assert.Equal(t, alice.ID, *auditLogger.Events[3].TargetUserID)
+}
+
+func TestOrgController_SwitchOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	alice := &models.User{ID: 1, Email: "alice@example.com"}
+	bob := &models.User{ID: 2, Email: "bob@example.com"}
+	users := map[int]*models.User{1: alice, 2: bob}
+
+	tokens := &MockTokens{}
+	controller := org.NewOrgController(slog.New(slog.NewTextHandler(io.Discard, nil)), newMemoryRepository(t, users), nil, tokens, &MockAuditLogger{})
+
+	w := executeOrgHandler(controller.CreateOrganizationHandler, alice, nil, `{"name":"Smith household"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// 1) Only members can switch to an organization
+	w = executeOrgHandler(controller.SwitchOrganizationHandler, bob, gin.Params{{Key: "id", Value: "1"}}, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, tokens.Issued)
+
+	w = executeOrgHandler(controller.SwitchOrganizationHandler, alice, gin.Params{{Key: "id", Value: "abc"}}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 2) Switching stores the choice and reissues the access token with it
+	w = executeOrgHandler(controller.SwitchOrganizationHandler, alice, gin.Params{{Key: "id", Value: "1"}}, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, tokens.Issued, 1)
+	require.NotNil(t, tokens.Issued[0].ActiveOrganizationID)
+	assert.Equal(t, 1, *tokens.Issued[0].ActiveOrganizationID)
+
+	// 3) The list shows the active organization
+	w = executeOrgHandler(controller.ListOrganizationsHandler, users[1], nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var listed struct {
+		Organizations        []models.UserOrganization `json:"organizations"`
+		ActiveOrganizationID *int                      `json:"activeOrganizationId"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Organizations, 1)
+	assert.Equal(t, "Smith household", listed.Organizations[0].Name)
+	assert.Equal(t, models.OrganizationRoleOwner, listed.Organizations[0].Role)
+	require.NotNil(t, listed.ActiveOrganizationID)
+	assert.Equal(t, 1, *listed.ActiveOrganizationID)
+}
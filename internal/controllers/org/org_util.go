@@ -0,0 +1,86 @@
+package org
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// currentUser returns the signed in user, personal access tokens and API keys cannot manage organizations
+func currentUser(c *gin.Context) (*models.User, bool) {
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+	return user, true
+}
+
+// currentMembership returns the user's membership of the organization in the :id path parameter.
+// Users that are not members get a 404 so they cannot tell which organizations exist.
+func (o *OrgController) currentMembership(c *gin.Context, user *models.User) (*models.Membership, bool) {
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return nil, false
+	}
+
+	membership, err := o.DB.FindMembership(organizationID, user.ID)
+	if err != nil {
+		o.Logger.Error("Failed to find membership", "organizationID", organizationID, "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+		return nil, false
+	}
+
+	if membership == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return nil, false
+	}
+
+	return membership, true
+}
+
+// inTransaction runs fn in a transaction and responds with the failure message if it cannot be committed
+func (o *OrgController) inTransaction(c *gin.Context, failure string, fn func(tx *sqlx.Tx) error) bool {
+	tx, err := o.DB.Beginx()
+	if err != nil {
+		o.Logger.Error("Failed to start transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		o.Logger.Error(failure, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	if err := tx.Commit(); err != nil {
+		o.Logger.Error("Failed to commit transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return false
+	}
+
+	return true
+}
+
+// audit records a membership change, e.g. "org 3 role admin", with the affected member as the target
+func (o *OrgController) audit(c *gin.Context, user *models.User, organizationID int, action string, targetUserID int) {
+	o.Audit.Record(models.AuthEvent{
+		UserID:       &user.ID,
+		TargetUserID: &targetUserID,
+		Email:        user.Email,
+		Type:         models.AuthEventMembershipChanged,
+		Outcome:      models.AuthEventSuccess,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Detail:       fmt.Sprintf("org %d %s", organizationID, action),
+	})
+}
@@ -0,0 +1,103 @@
+package org
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// ListOrganizationsHandler returns the organizations the user belongs to and their role in each
+func (o *OrgController) ListOrganizationsHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	organizations, err := o.DB.FindOrganizationsByUserID(user.ID)
+	if err != nil {
+		o.Logger.Error("Failed to list organizations", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations, "activeOrganizationId": user.ActiveOrganizationID})
+}
+
+// CreateOrganizationHandler creates an organization with the user as its owner
+func (o *OrgController) CreateOrganizationHandler(c *gin.Context) {
+	// 1) Get the user and validate the request
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var request CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		o.Logger.Error("Invalid create organization request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	// 2) Store the organization and make the user its owner
+	organization := &models.Organization{Name: request.Name, CreatedBy: &user.ID}
+	membership := &models.Membership{UserID: user.ID, Role: models.OrganizationRoleOwner}
+
+	if !o.inTransaction(c, "Failed to create organization", func(tx *sqlx.Tx) error {
+		if err := o.DB.CreateOrganization(tx, organization); err != nil {
+			return err
+		}
+
+		membership.OrganizationID = organization.ID
+		_, err := o.DB.CreateMembership(tx, membership)
+		return err
+	}) {
+		return
+	}
+
+	o.audit(c, user, organization.ID, "create", user.ID)
+	c.JSON(http.StatusCreated, gin.H{"organization": organization, "role": membership.Role})
+}
+
+// SwitchOrganizationHandler makes the organization the user's active organization and reissues their access token with it
+func (o *OrgController) SwitchOrganizationHandler(c *gin.Context) {
+	// 1) The user must be a member
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	membership, ok := o.currentMembership(c, user)
+	if !ok {
+		return
+	}
+
+	// 2) Store the choice so refreshed tokens keep it
+	if !o.inTransaction(c, "Failed to switch organization", func(tx *sqlx.Tx) error {
+		return o.DB.SetActiveOrganization(tx, user.ID, &membership.OrganizationID)
+	}) {
+		return
+	}
+
+	// 3) Issue an access token carrying the new organization
+	updated, err := o.DB.FindUserByID(user.ID)
+	if err != nil || updated == nil {
+		o.Logger.Error("Failed to find user", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+
+	if err := o.Tokens.IssueAccessToken(c, updated); err != nil {
+		o.Logger.Error("Failed to issue access token", "userID", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization switched", "membership": membership})
+}
@@ -0,0 +1,29 @@
+package wellknown
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+type WellKnownController struct {
+	Logger *slog.Logger
+	Keys   *signing.KeyManager
+}
+
+func NewWellKnownController(logger *slog.Logger, keys *signing.KeyManager) *WellKnownController {
+	return &WellKnownController{
+		Logger: logger,
+		Keys:   keys,
+	}
+}
+
+// JWKSHandler publishes the public keys that our tokens can be verified with.
+func (w *WellKnownController) JWKSHandler(c *gin.Context) {
+	// Let other services cache the key set, rotations keep the old key published for longer than this
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, w.Keys.JWKS())
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// UpdateUserStatus moves the user to a new status and records the change in their status history.
+// changedBy is the admin making the change, or nil if the system is.
+func (db *UserDB) UpdateUserStatus(ext sqlx.Ext, user *models.User, status models.AccountStatus, reason string, changedBy *int) error {
+	query := `UPDATE users
+              SET status = $1, status_reason = $2, status_changed_at = NOW()
+              WHERE id = $3
+              RETURNING status_changed_at`
+
+	var changedAt time.Time
+	if err := sqlx.Get(ext, &changedAt, query, status, reason, user.ID); err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	historyQuery := `INSERT INTO account_status_history (user_id, from_status, to_status, reason, changed_by)
+                     VALUES ($1, $2, $3, $4, $5)`
+
+	var fromStatus *models.AccountStatus
+	if user.Status != "" {
+		fromStatus = &user.Status
+	}
+
+	if _, err := ext.Exec(historyQuery, user.ID, fromStatus, status, reason, changedBy); err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+
+	user.Status = status
+	user.StatusReason = &reason
+	user.StatusChangedAt = &changedAt
+	return nil
+}
+
+// FindAccountStatusHistory returns the user's status changes, newest first
+func (db *UserDB) FindAccountStatusHistory(userID int) ([]models.AccountStatusChange, error) {
+	query := `SELECT * FROM account_status_history WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+
+	changes := []models.AccountStatusChange{}
+	if err := db.Select(&changes, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find status history: %w", err)
+	}
+	return changes, nil
+}
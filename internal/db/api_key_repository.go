@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// FindAPIKeyByHash returns the key with the hash, including revoked and expired keys, or nil if there is none
+func (db *UserDB) FindAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	query := `SELECT * FROM api_keys WHERE key_hash = $1`
+
+	var key models.APIKey
+	if err := db.Get(&key, query, keyHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find api key: %v", err)
+	}
+
+	return &key, nil
+}
+
+func (db *UserDB) ListAPIKeys() ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	if err := db.Select(&keys, `SELECT * FROM api_keys ORDER BY created_at DESC, id DESC`); err != nil {
+		return nil, fmt.Errorf("could not list api keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (db *UserDB) CreateAPIKey(ext sqlx.Ext, key *models.APIKey) error {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, organization_id, expires_at, created_by)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.OrganizationID, key.ExpiresAt, key.CreatedBy)
+	if err := row.Scan(&key.ID, &key.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey stops a key from authenticating and reports whether an active key was revoked
+func (db *UserDB) RevokeAPIKey(ext sqlx.Ext, id int) (bool, error) {
+	result, err := ext.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// TouchAPIKey records that the key was just used
+func (db *UserDB) TouchAPIKey(id int) error {
+	if _, err := db.Exec(`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// CreateAuthEvent appends an event to the audit log
+func (db *UserDB) CreateAuthEvent(event *models.AuthEvent) error {
+	query := `INSERT INTO auth_events (user_id, target_user_id, email, event_type, outcome, ip, user_agent, detail)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              RETURNING id, created_at`
+
+	err := db.QueryRowx(
+		query,
+		event.UserID,
+		event.TargetUserID,
+		event.Email,
+		event.Type,
+		event.Outcome,
+		event.IP,
+		event.UserAgent,
+		event.Detail,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert auth event: %w", err)
+	}
+	return nil
+}
+
+// FindAuthEvents returns a page of events matching the filter, newest first, and the total number of matches
+func (db *UserDB) FindAuthEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+	// 1) Build the where clause from the filters that are set
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		where("user_id = $%d", *filter.UserID)
+	}
+	if filter.Email != "" {
+		where("email = $%d", filter.Email)
+	}
+	if filter.Type != nil {
+		where("event_type = $%d", *filter.Type)
+	}
+	if filter.Outcome != nil {
+		where("outcome = $%d", *filter.Outcome)
+	}
+	if filter.IP != "" {
+		where("ip = $%d", filter.IP)
+	}
+	if filter.CreatedAfter != nil {
+		where("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		where("created_at < $%d", *filter.CreatedBefore)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// 2) Count every match so the caller can paginate
+	var total int
+	if err := db.Get(&total, "SELECT COUNT(*) FROM auth_events "+whereClause, args...); err != nil {
+		return nil, 0, fmt.Errorf("could not count auth events: %w", err)
+	}
+
+	// 3) Fetch the requested page
+	query := fmt.Sprintf("SELECT * FROM auth_events %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", whereClause, len(args)+1, len(args)+2)
+
+	events := []models.AuthEvent{}
+	if err := db.Select(&events, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("could not find auth events: %w", err)
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func (db *UserDB) FindCSVProfilesByUserID(userID int) ([]models.CSVProfile, error) {
+	query := `SELECT * FROM bank_csv_profiles WHERE user_id = $1 ORDER BY name, id`
+
+	profiles := []models.CSVProfile{}
+	if err := db.Select(&profiles, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find csv profiles: %v", err)
+	}
+
+	return profiles, nil
+}
+
+// FindCSVProfile returns the user's profile, or nil if it does not exist or belongs to someone else
+func (db *UserDB) FindCSVProfile(userID int, id int) (*models.CSVProfile, error) {
+	query := `SELECT * FROM bank_csv_profiles WHERE user_id = $1 AND id = $2`
+
+	var profile models.CSVProfile
+	if err := db.Get(&profile, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find csv profile: %v", err)
+	}
+
+	return &profile, nil
+}
+
+func (db *UserDB) CreateCSVProfile(ext sqlx.Ext, profile *models.CSVProfile) error {
+	query := `INSERT INTO bank_csv_profiles (user_id, name, delimiter, encoding, header_row, date_column, date_formats,
+                                             description_column, amount_column, debit_column, credit_column, decimal_separator)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, profile.UserID, profile.Name, profile.Delimiter, profile.Encoding, profile.HeaderRow, profile.DateColumn, profile.DateFormats,
+		profile.DescriptionColumn, profile.AmountColumn, profile.DebitColumn, profile.CreditColumn, profile.DecimalSeparator)
+	if err := row.Scan(&profile.ID, &profile.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert csv profile: %w", err)
+	}
+	return nil
+}
+
+// DeleteCSVProfile deletes one of the user's profiles and reports whether one was deleted
+func (db *UserDB) DeleteCSVProfile(ext sqlx.Ext, userID int, id int) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM bank_csv_profiles WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete csv profile: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete csv profile: %w", err)
+	}
+	return rows == 1, nil
+}
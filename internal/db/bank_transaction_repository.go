@@ -0,0 +1,156 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// Transactions are shared by the members of an organization, every query takes the organization ID and the user ID
+// only records who uploaded a transaction
+
+// transactionBatchSize is how many transactions one INSERT stores, a 50,000 row statement takes 50 round trips
+const transactionBatchSize = 1000
+
+// timestampLayout writes dates the way PostgreSQL reads a TIMESTAMP, the transactions' dates are in UTC
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// CreateTransactions inserts the transactions in batches and returns the ones that were inserted, with their IDs.
+// A transaction with a FITID already stored for the account in the organization is skipped, so statements can be imported again.
+// Pass a transaction to store a statement completely or not at all.
+func (db *UserDB) CreateTransactions(ext sqlx.Ext, transactions []models.Transaction) ([]models.Transaction, error) {
+	inserted := make([]models.Transaction, 0, len(transactions))
+	for start := 0; start < len(transactions); start += transactionBatchSize {
+		batch, err := insertTransactionBatch(ext, transactions[start:min(start+transactionBatchSize, len(transactions))])
+		if err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, batch...)
+	}
+	return inserted, nil
+}
+
+// insertTransactionBatch inserts the transactions with one statement, each column is sent as an array.
+// Every row takes its ID from the sequence before the insert and keeps its position in the batch, so the IDs that
+// come back are matched to the transactions by position, whatever order the rows were stored in.
+func insertTransactionBatch(ext sqlx.Ext, batch []models.Transaction) ([]models.Transaction, error) {
+	var userIDs, organizationIDs, amounts []int64
+	var dates, valueDates, currencies, descriptions, counterparties, remittances, accounts, fitIDs []string
+	for _, transaction := range batch {
+		valueDate := ""
+		if transaction.ValueDate != nil {
+			valueDate = transaction.ValueDate.Format(timestampLayout)
+		}
+
+		userIDs = append(userIDs, int64(transaction.UserId))
+		organizationIDs = append(organizationIDs, int64(transaction.OrganizationId))
+		dates = append(dates, transaction.Date.Format(timestampLayout))
+		valueDates = append(valueDates, valueDate)
+		amounts = append(amounts, int64(transaction.AmountCents))
+		currencies = append(currencies, transaction.Currency)
+		descriptions = append(descriptions, transaction.Description)
+		counterparties = append(counterparties, transaction.Counterparty)
+		remittances = append(remittances, transaction.RemittanceInfo)
+		accounts = append(accounts, transaction.Account)
+		fitIDs = append(fitIDs, transaction.FitId)
+	}
+
+	query := `WITH v AS MATERIALIZED (
+                  SELECT nextval(pg_get_serial_sequence('bank_transactions', 'transaction_id')) AS transaction_id, u.*
+                  FROM (SELECT *
+                        FROM unnest($1::int8[], $2::int8[], $3::text[], $4::text[], $5::int8[], $6::text[], $7::text[],
+                                    $8::text[], $9::text[], $10::text[], $11::text[])
+                             WITH ORDINALITY AS t(user_id, organization_id, date, value_date, amount_cents, currency,
+                                                  description, counterparty, remittance_info, account, fit_id, position)
+                        ORDER BY t.position) u
+              ), inserted AS (
+                  INSERT INTO bank_transactions (transaction_id, user_id, organization_id, date, value_date, amount_cents,
+                                                 currency, description, counterparty, remittance_info, account, fit_id)
+                  SELECT v.transaction_id, v.user_id, v.organization_id, v.date::timestamp, NULLIF(v.value_date, '')::timestamp,
+                         v.amount_cents, v.currency, v.description, v.counterparty, v.remittance_info, v.account, v.fit_id
+                  FROM v
+                  ORDER BY v.position
+                  ON CONFLICT (organization_id, account, fit_id) WHERE fit_id <> '' DO NOTHING
+                  RETURNING transaction_id
+              )
+              SELECT v.position, v.transaction_id
+              FROM inserted JOIN v ON v.transaction_id = inserted.transaction_id
+              ORDER BY v.position`
+
+	var rows []struct {
+		Position      int `db:"position"`
+		TransactionId int `db:"transaction_id"`
+	}
+	if err := sqlx.Select(ext, &rows, query, pq.Array(userIDs), pq.Array(organizationIDs), pq.Array(dates), pq.Array(valueDates),
+		pq.Array(amounts), pq.Array(currencies), pq.Array(descriptions), pq.Array(counterparties), pq.Array(remittances),
+		pq.Array(accounts), pq.Array(fitIDs)); err != nil {
+		return nil, fmt.Errorf("failed to insert transactions: %w", err)
+	}
+
+	// Positions count from 1, a transaction skipped for its FITID has no row
+	inserted := make([]models.Transaction, 0, len(rows))
+	for _, row := range rows {
+		if row.Position < 1 || row.Position > len(batch) {
+			return nil, fmt.Errorf("failed to insert transactions: position %d is outside the batch", row.Position)
+		}
+
+		transaction := batch[row.Position-1]
+		transaction.TransactionId = row.TransactionId
+		inserted = append(inserted, transaction)
+	}
+	return inserted, nil
+}
+
+// FindTransactionsByOrganization returns the transactions every member of the organization uploaded
+func (db *UserDB) FindTransactionsByOrganization(organizationID int) ([]models.Transaction, error) {
+	query := `SELECT transaction_id, user_id, organization_id, date, value_date, amount_cents, currency, description,
+                     counterparty, remittance_info, account, fit_id
+              FROM bank_transactions
+              WHERE organization_id = $1
+              ORDER BY date DESC, transaction_id DESC`
+
+	transactions := []models.Transaction{}
+	if err := db.Select(&transactions, query, organizationID); err != nil {
+		return nil, fmt.Errorf("could not find transactions: %v", err)
+	}
+
+	return transactions, nil
+}
+
+// FindTransaction returns the organization's transaction, or nil if it does not exist or belongs to another organization
+func (db *UserDB) FindTransaction(organizationID int, transactionID int) (*models.Transaction, error) {
+	query := `SELECT transaction_id, user_id, organization_id, date, value_date, amount_cents, currency, description,
+                     counterparty, remittance_info, account, fit_id
+              FROM bank_transactions
+              WHERE organization_id = $1 AND transaction_id = $2`
+
+	var transaction models.Transaction
+	if err := db.Get(&transaction, query, organizationID, transactionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find transaction: %v", err)
+	}
+
+	return &transaction, nil
+}
+
+// DeleteTransaction deletes the organization's transaction and reports whether one was deleted
+func (db *UserDB) DeleteTransaction(ext sqlx.Ext, organizationID int, transactionID int) (bool, error) {
+	query := `DELETE FROM bank_transactions WHERE organization_id = $1 AND transaction_id = $2`
+
+	result, err := ext.Exec(query, organizationID, transactionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete transaction: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete transaction: %w", err)
+	}
+	return rows == 1, nil
+}
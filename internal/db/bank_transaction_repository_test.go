@@ -0,0 +1,46 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/db"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestUserDB_CreateTransactions(t *testing.T) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockSQL.Close()
+	userDB := &db.UserDB{DB: sqlx.NewDb(mockSQL, "sqlmock")}
+
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	transactions := []models.Transaction{
+		{UserId: 5, OrganizationId: 7, Date: date, AmountCents: 3_000_000_000, Description: "Property sale", Account: "NL01", FitId: "A"},
+		{UserId: 5, OrganizationId: 7, Date: date, AmountCents: -1200, Description: "Already stored", Account: "NL01", FitId: "B"},
+		{UserId: 5, OrganizationId: 7, Date: date, AmountCents: -2350, Description: "Groceries"},
+	}
+
+	// The IDs come back with the position of their row in the batch, the second transaction's FITID was already stored.
+	// The IDs do not follow the positions, only the positions say which transaction a row is.
+	mock.ExpectQuery(`unnest\(\$1::int8\[\], \$2::int8\[\], \$3::text\[\], \$4::text\[\], \$5::int8\[\].*WITH ORDINALITY`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "transaction_id"}).AddRow(1, 42).AddRow(3, 17))
+
+	inserted, err := userDB.CreateTransactions(userDB, transactions)
+	require.NoError(t, err)
+
+	require.Len(t, inserted, 2)
+	assert.Equal(t, 42, inserted[0].TransactionId)
+	assert.Equal(t, "Property sale", inserted[0].Description)
+	assert.Equal(t, 3_000_000_000, inserted[0].AmountCents)
+	assert.Equal(t, 17, inserted[1].TransactionId)
+	assert.Equal(t, "Groceries", inserted[1].Description)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
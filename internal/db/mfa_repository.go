@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (db *UserDB) UpdateTOTP(ext sqlx.Ext, userID int, encryptedSecret *string, enabled bool) error {
+	query := `UPDATE users 
+              SET totp_secret = $1, 
+                  totp_enabled = $2
+              WHERE id = $3`
+
+	_, err := ext.Exec(query, encryptedSecret, enabled, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update totp: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes deletes any existing recovery codes for the user and stores the new hashes
+func (db *UserDB) ReplaceRecoveryCodes(ext sqlx.Ext, userID int, codeHashes []string) error {
+	if _, err := ext.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, codeHash := range codeHashes {
+		if _, err := ext.Exec(query, userID, codeHash); err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used and reports whether one matched
+func (db *UserDB) ConsumeRecoveryCode(ext sqlx.Ext, userID int, codeHash string) (bool, error) {
+	query := `UPDATE mfa_recovery_codes 
+              SET used_at = NOW() 
+              WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := ext.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+	return rows == 1, nil
+}
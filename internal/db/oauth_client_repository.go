@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func (db *UserDB) FindOAuthClient(clientID string) (*models.OAuthClient, error) {
+	query := `SELECT * FROM oauth_clients WHERE client_id = $1`
+
+	var client models.OAuthClient
+	if err := db.Get(&client, query, clientID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find oauth client: %v", err)
+	}
+
+	return &client, nil
+}
+
+func (db *UserDB) ListOAuthClients() ([]models.OAuthClient, error) {
+	clients := []models.OAuthClient{}
+	if err := db.Select(&clients, `SELECT * FROM oauth_clients ORDER BY name, id`); err != nil {
+		return nil, fmt.Errorf("could not list oauth clients: %v", err)
+	}
+
+	return clients, nil
+}
+
+func (db *UserDB) CreateOAuthClient(ext sqlx.Ext, client *models.OAuthClient) error {
+	query := `INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, created_by)
+              VALUES ($1, $2, $3, $4, $5)
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, client.ClientID, client.ClientSecretHash, client.Name, client.RedirectURIs, client.CreatedBy)
+	if err := row.Scan(&client.ID, &client.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert oauth client: %w", err)
+	}
+	return nil
+}
+
+// DeleteOAuthClient removes a client application and reports whether one was deleted
+func (db *UserDB) DeleteOAuthClient(ext sqlx.Ext, clientID string) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM oauth_clients WHERE client_id = $1`, clientID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete oauth client: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete oauth client: %w", err)
+	}
+	return rows == 1, nil
+}
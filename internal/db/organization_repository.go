@@ -0,0 +1,177 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func (db *UserDB) FindOrganizationByID(id int) (*models.Organization, error) {
+	query := `SELECT * FROM organizations WHERE id = $1`
+
+	var organization models.Organization
+	if err := db.Get(&organization, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find organization: %v", err)
+	}
+
+	return &organization, nil
+}
+
+// FindOrganizationsByUserID returns the organizations the user is a member of, with their role in each
+func (db *UserDB) FindOrganizationsByUserID(userID int) ([]models.UserOrganization, error) {
+	query := `SELECT organizations.*, memberships.role
+              FROM memberships
+              JOIN organizations ON organizations.id = memberships.organization_id
+              WHERE memberships.user_id = $1
+              ORDER BY organizations.name, organizations.id`
+
+	organizations := []models.UserOrganization{}
+	if err := db.Select(&organizations, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find organizations: %v", err)
+	}
+
+	return organizations, nil
+}
+
+func (db *UserDB) CreateOrganization(ext sqlx.Ext, organization *models.Organization) error {
+	query := `INSERT INTO organizations (name, created_by)
+              VALUES ($1, $2)
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, organization.Name, organization.CreatedBy)
+	if err := row.Scan(&organization.ID, &organization.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert organization: %w", err)
+	}
+	return nil
+}
+
+// CreatePersonalOrganization makes the user the owner of a new organization of their own and makes it their active one
+func (db *UserDB) CreatePersonalOrganization(ext sqlx.Ext, user *models.User) error {
+	organization := &models.Organization{Name: models.PersonalOrganizationName, CreatedBy: &user.ID}
+	if err := db.CreateOrganization(ext, organization); err != nil {
+		return err
+	}
+
+	membership := &models.Membership{OrganizationID: organization.ID, UserID: user.ID, Role: models.OrganizationRoleOwner}
+	if _, err := db.CreateMembership(ext, membership); err != nil {
+		return err
+	}
+
+	if err := db.SetActiveOrganization(ext, user.ID, &organization.ID); err != nil {
+		return err
+	}
+
+	user.ActiveOrganizationID = &organization.ID
+	return nil
+}
+
+// FindMembership returns the user's membership of the organization, or nil if they are not a member
+func (db *UserDB) FindMembership(organizationID int, userID int) (*models.Membership, error) {
+	query := `SELECT * FROM memberships WHERE organization_id = $1 AND user_id = $2`
+
+	var membership models.Membership
+	if err := db.Get(&membership, query, organizationID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find membership: %v", err)
+	}
+
+	return &membership, nil
+}
+
+func (db *UserDB) FindOrganizationMembers(organizationID int) ([]models.OrganizationMember, error) {
+	query := `SELECT users.id AS user_id, users.email, users.first_name, users.last_name, memberships.role, memberships.created_at
+              FROM memberships
+              JOIN users ON users.id = memberships.user_id
+              WHERE memberships.organization_id = $1
+              ORDER BY memberships.created_at, users.id`
+
+	members := []models.OrganizationMember{}
+	if err := db.Select(&members, query, organizationID); err != nil {
+		return nil, fmt.Errorf("could not find organization members: %v", err)
+	}
+
+	return members, nil
+}
+
+// CreateMembership adds the user to the organization and reports whether they were added, existing members are left as they are
+func (db *UserDB) CreateMembership(ext sqlx.Ext, membership *models.Membership) (bool, error) {
+	query := `INSERT INTO memberships (organization_id, user_id, role)
+              VALUES ($1, $2, $3)
+              ON CONFLICT (organization_id, user_id) DO NOTHING
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, membership.OrganizationID, membership.UserID, membership.Role)
+	if err := row.Scan(&membership.ID, &membership.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to insert membership: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateMembershipRole changes a member's role and reports whether the user was a member
+func (db *UserDB) UpdateMembershipRole(ext sqlx.Ext, organizationID int, userID int, role models.OrganizationRole) (bool, error) {
+	result, err := ext.Exec(`UPDATE memberships SET role = $1 WHERE organization_id = $2 AND user_id = $3`, role, organizationID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update membership: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update membership: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// DeleteMembership removes the user from the organization and reports whether they were a member.
+// If it was the user's active organization they fall back to the one they joined first, usually their personal one.
+func (db *UserDB) DeleteMembership(ext sqlx.Ext, organizationID int, userID int) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM memberships WHERE organization_id = $1 AND user_id = $2`, organizationID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	if rows == 1 {
+		query := `UPDATE users
+                  SET active_organization_id = (SELECT organization_id FROM memberships
+                                                WHERE user_id = $1 ORDER BY created_at, id LIMIT 1)
+                  WHERE id = $1 AND active_organization_id = $2`
+		if _, err := ext.Exec(query, userID, organizationID); err != nil {
+			return false, fmt.Errorf("failed to clear active organization: %w", err)
+		}
+	}
+	return rows == 1, nil
+}
+
+// CountOrganizationOwners counts the owners of the organization, the rows are locked so concurrent changes are serialised
+func (db *UserDB) CountOrganizationOwners(ext sqlx.Ext, organizationID int) (int, error) {
+	query := `SELECT user_id FROM memberships WHERE organization_id = $1 AND role = 'owner' FOR UPDATE`
+
+	var userIDs []int
+	if err := sqlx.Select(ext, &userIDs, query, organizationID); err != nil {
+		return 0, fmt.Errorf("failed to count organization owners: %w", err)
+	}
+	return len(userIDs), nil
+}
+
+// SetActiveOrganization changes the organization the user works in, nil clears it
+func (db *UserDB) SetActiveOrganization(ext sqlx.Ext, userID int, organizationID *int) error {
+	if _, err := ext.Exec(`UPDATE users SET active_organization_id = $1 WHERE id = $2`, organizationID, userID); err != nil {
+		return fmt.Errorf("failed to set active organization: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// FindPersonalAccessTokenByHash returns the token with the hash, including expired tokens, or nil if there is none
+func (db *UserDB) FindPersonalAccessTokenByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `SELECT * FROM personal_access_tokens WHERE token_hash = $1`
+
+	var token models.PersonalAccessToken
+	if err := db.Get(&token, query, tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find personal access token: %v", err)
+	}
+
+	return &token, nil
+}
+
+func (db *UserDB) FindPersonalAccessTokensByUserID(userID int) ([]models.PersonalAccessToken, error) {
+	query := `SELECT * FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+
+	tokens := []models.PersonalAccessToken{}
+	if err := db.Select(&tokens, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find personal access tokens: %v", err)
+	}
+
+	return tokens, nil
+}
+
+func (db *UserDB) CreatePersonalAccessToken(ext sqlx.Ext, token *models.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6)
+              RETURNING id, created_at`
+
+	row := ext.QueryRowx(query, token.UserID, token.Name, token.Prefix, token.TokenHash, token.Scopes, token.ExpiresAt)
+	if err := row.Scan(&token.ID, &token.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert personal access token: %w", err)
+	}
+	return nil
+}
+
+// DeletePersonalAccessToken revokes one of the user's tokens and reports whether one was deleted
+func (db *UserDB) DeletePersonalAccessToken(ext sqlx.Ext, userID int, id int) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// TouchPersonalAccessToken records when and from where the token was just used
+func (db *UserDB) TouchPersonalAccessToken(id int, ip string) error {
+	if _, err := db.Exec(`UPDATE personal_access_tokens SET last_used_at = NOW(), last_used_ip = $2 WHERE id = $1`, id, ip); err != nil {
+		return fmt.Errorf("failed to update personal access token: %w", err)
+	}
+	return nil
+}
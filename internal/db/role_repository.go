@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// FindRolesByUserID returns the names of the roles granted to the user
+func (db *UserDB) FindRolesByUserID(userID int) ([]string, error) {
+	query := `SELECT roles.name FROM roles
+              JOIN user_roles ON user_roles.role_id = roles.id
+              WHERE user_roles.user_id = $1
+              ORDER BY roles.name`
+
+	roles := []string{}
+	if err := db.Select(&roles, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find roles: %w", err)
+	}
+	return roles, nil
+}
+
+// FindPermissionsByUserID returns the names of every permission the user has through any of their roles
+func (db *UserDB) FindPermissionsByUserID(userID int) ([]string, error) {
+	query := `SELECT DISTINCT permissions.name FROM permissions
+              JOIN role_permissions ON role_permissions.permission_id = permissions.id
+              JOIN user_roles ON user_roles.role_id = role_permissions.role_id
+              WHERE user_roles.user_id = $1
+              ORDER BY permissions.name`
+
+	permissions := []string{}
+	if err := db.Select(&permissions, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// FindRoles returns every role with the names of its permissions
+func (db *UserDB) FindRoles() ([]models.Role, error) {
+	roles := []models.Role{}
+	if err := db.Select(&roles, `SELECT * FROM roles ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("could not find roles: %w", err)
+	}
+
+	query := `SELECT permissions.name FROM permissions
+              JOIN role_permissions ON role_permissions.permission_id = permissions.id
+              WHERE role_permissions.role_id = $1
+              ORDER BY permissions.name`
+
+	for i := range roles {
+		roles[i].Permissions = []string{}
+		if err := db.Select(&roles[i].Permissions, query, roles[i].ID); err != nil {
+			return nil, fmt.Errorf("could not find role permissions: %w", err)
+		}
+	}
+	return roles, nil
+}
+
+func (db *UserDB) FindRoleByName(name string) (*models.Role, error) {
+	query := `SELECT * FROM roles WHERE name=$1`
+
+	var role models.Role
+	err := db.Get(&role, query, name)
+
+	if err != nil {
+		// handle case where there are no rows
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		// handle case where another error occurs
+		return nil, fmt.Errorf("could not find role: %w", err)
+	}
+
+	return &role, nil
+}
+
+// GrantRole gives the user the role and reports whether they did not already have it
+func (db *UserDB) GrantRole(ext sqlx.Ext, userID int, roleID int, grantedBy int) (bool, error) {
+	query := `INSERT INTO user_roles (user_id, role_id, granted_by)
+              VALUES ($1, $2, $3)
+              ON CONFLICT (user_id, role_id) DO NOTHING`
+
+	result, err := ext.Exec(query, userID, roleID, grantedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to grant role: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// RevokeRole removes the role from the user and reports whether they had it
+func (db *UserDB) RevokeRole(ext sqlx.Ext, userID int, roleID int) (bool, error) {
+	query := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`
+
+	result, err := ext.Exec(query, userID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke role: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// LockUsersWithRole locks the role's user_roles rows with SELECT ... FOR UPDATE and counts them. Run it in the
+// transaction that revokes the role: a concurrent revoke waits for the lock and then counts the rows that are left.
+func (db *UserDB) LockUsersWithRole(ext sqlx.Ext, roleID int) (int, error) {
+	query := `SELECT user_id FROM user_roles WHERE role_id = $1 FOR UPDATE`
+
+	var userIDs []int
+	if err := sqlx.Select(ext, &userIDs, query, roleID); err != nil {
+		return 0, fmt.Errorf("failed to lock users with role: %w", err)
+	}
+	return len(userIDs), nil
+}
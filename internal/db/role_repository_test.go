@@ -0,0 +1,35 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/db"
+)
+
+func TestUserDB_LockUsersWithRole(t *testing.T) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockSQL.Close()
+	userDB := &db.UserDB{DB: sqlx.NewDb(mockSQL, "sqlmock")}
+
+	// The rows are locked rather than counted with an aggregate, so a concurrent revoke waits and counts what is left
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT user_id FROM user_roles WHERE role_id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	tx, err := userDB.Beginx()
+	require.NoError(t, err)
+	admins, err := userDB.LockUsersWithRole(tx, 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(t, 2, admins)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
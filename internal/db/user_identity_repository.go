@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// FindIdentity returns the identity a provider subject is linked to, or nil if it is not linked
+func (db *UserDB) FindIdentity(provider string, providerUserID string) (*models.UserIdentity, error) {
+	query := `SELECT * FROM user_identities WHERE provider = $1 AND provider_user_id = $2`
+
+	var identity models.UserIdentity
+	if err := db.Get(&identity, query, provider, providerUserID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find identity: %v", err)
+	}
+
+	return &identity, nil
+}
+
+func (db *UserDB) FindIdentitiesByUserID(userID int) ([]models.UserIdentity, error) {
+	query := `SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at, id`
+
+	identities := []models.UserIdentity{}
+	if err := db.Select(&identities, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find identities: %v", err)
+	}
+
+	return identities, nil
+}
+
+// CreateIdentity links an identity to the user. An identity created before subjects were stored is claimed
+// by the first subject that signs in with it, an identity that already has a subject is left untouched.
+func (db *UserDB) CreateIdentity(ext sqlx.Ext, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, provider_user_id, email, last_used_at)
+              VALUES ($1, $2, $3, $4, NOW())
+              ON CONFLICT (user_id, provider) DO UPDATE
+              SET provider_user_id = EXCLUDED.provider_user_id,
+                  email = EXCLUDED.email,
+                  last_used_at = NOW()
+              WHERE user_identities.provider_user_id IS NULL`
+
+	_, err := ext.Exec(query, identity.UserID, identity.Provider, identity.ProviderUserID, identity.Email)
+	if err != nil {
+		return fmt.Errorf("failed to insert identity: %w", err)
+	}
+	return nil
+}
+
+// TouchIdentity records that the identity was just used to sign in
+func (db *UserDB) TouchIdentity(ext sqlx.Ext, id int) error {
+	if _, err := ext.Exec(`UPDATE user_identities SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+// UpdateIdentityEmail changes the email address stored on the user's identity for a provider
+func (db *UserDB) UpdateIdentityEmail(ext sqlx.Ext, userID int, provider string, email string) error {
+	query := `UPDATE user_identities SET email = $1 WHERE user_id = $2 AND provider = $3`
+	if _, err := ext.Exec(query, email, userID, provider); err != nil {
+		return fmt.Errorf("failed to update identity email: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdentity unlinks the user's identity for a provider and reports whether one was deleted
+func (db *UserDB) DeleteIdentity(ext sqlx.Ext, userID int, provider string) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete identity: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete identity: %w", err)
+	}
+	return rows == 1, nil
+}
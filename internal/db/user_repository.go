@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 
@@ -31,26 +32,75 @@ func (db *UserDB) FindUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+func (db *UserDB) FindUserByID(id int) (*models.User, error) {
+	query := `SELECT * FROM users WHERE id=$1`
+
+	var user models.User
+	err := db.Get(&user, query, id)
+
+	if err != nil {
+		// handle case where there are no rows
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		// handle case where another error occurs
+		return nil, fmt.Errorf("could not find user: %v", err)
+	}
+
+	return &user, nil
+}
+
 func (db *UserDB) CreateUser(ext sqlx.Ext, user *models.User) error {
 	if user.Provider == nil && user.PasswordHash == nil {
 		return fmt.Errorf("password_hash is required for email/password users")
 	}
 
-	query := `INSERT INTO users (email, first_name, last_name, provider, password_hash)
-              VALUES ($1, $2, $3, $4, $5)`
+	// New accounts are active once their email is verified
+	if user.Status == "" {
+		user.Status = models.AccountStatusPendingVerification
+		if user.Verified {
+			user.Status = models.AccountStatusActive
+		}
+	}
 
-	_, err := ext.Exec(
+	query := `INSERT INTO users (email, first_name, last_name, provider, password_hash, verified, status)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              RETURNING id`
+
+	err := sqlx.Get(
+		ext,
+		&user.ID,
 		query,
 		user.Email,
 		user.FirstName,
 		user.LastName,
 		user.Provider,
 		user.PasswordHash,
+		user.Verified,
+		user.Status,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
-	return nil
+
+	// Every new user starts out as a member
+	memberQuery := `INSERT INTO user_roles (user_id, role_id)
+                    SELECT $1, roles.id FROM roles
+                    WHERE roles.name = 'member'`
+
+	if _, err := ext.Exec(memberQuery, user.ID); err != nil {
+		return fmt.Errorf("failed to grant member role: %w", err)
+	}
+
+	// Users who sign up with a password hold a password identity, provider identities are linked by the caller
+	if user.PasswordHash != nil {
+		if err := db.CreateIdentity(ext, &models.UserIdentity{UserID: user.ID, Provider: models.IdentityProviderPassword, Email: &user.Email}); err != nil {
+			return err
+		}
+	}
+
+	// Bank data is kept in an organization, so every user starts out with one of their own
+	return db.CreatePersonalOrganization(ext, user)
 }
 
 func (db *UserDB) UpdateUser(ext sqlx.Ext, user *models.User) error {
@@ -60,9 +110,8 @@ func (db *UserDB) UpdateUser(ext sqlx.Ext, user *models.User) error {
                   last_name = $3, 
                   provider = $4, 
                   password_hash = $5, 
-                  is_active = $6,
-                  verified = $7
-              WHERE id = $8`
+                  verified = $6
+              WHERE id = $7`
 
 	_, err := ext.Exec(
 		query,
@@ -71,7 +120,6 @@ func (db *UserDB) UpdateUser(ext sqlx.Ext, user *models.User) error {
 		user.LastName,
 		user.Provider,
 		user.PasswordHash,
-		user.IsActive,
 		user.Verified,
 		user.ID,
 	)
@@ -84,3 +132,73 @@ func (db *UserDB) UpdateUser(ext sqlx.Ext, user *models.User) error {
 func (db *UserDB) Beginx() (*sqlx.Tx, error) {
 	return db.DB.Beginx()
 }
+
+// ListUsers returns a page of users matching the filter and the total number of matches
+func (db *UserDB) ListUsers(filter models.UserFilter) ([]models.User, int, error) {
+	// 1) Build the where clause from the filters that are set
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Verified != nil {
+		where("verified = $%d", *filter.Verified)
+	}
+	if filter.Status != nil {
+		where("status = $%d", *filter.Status)
+	}
+	if filter.Provider != nil {
+		if *filter.Provider == "password" {
+			conditions = append(conditions, "provider IS NULL")
+		} else {
+			where("provider = $%d", *filter.Provider)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		where("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		where("created_at < $%d", *filter.CreatedBefore)
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(email ILIKE $%[1]d OR first_name ILIKE $%[1]d OR last_name ILIKE $%[1]d)", len(args)))
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// 2) Count every match so the caller can paginate
+	var total int
+	if err := db.Get(&total, "SELECT COUNT(*) FROM users "+whereClause, args...); err != nil {
+		return nil, 0, fmt.Errorf("could not count users: %w", err)
+	}
+
+	// 3) Fetch the requested page
+	query := fmt.Sprintf("SELECT * FROM users %s ORDER BY id LIMIT $%d OFFSET $%d", whereClause, len(args)+1, len(args)+2)
+
+	users := []models.User{}
+	if err := db.Select(&users, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("could not list users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// DeleteUser permanently deletes the user, related rows are removed by the foreign key cascades
+func (db *UserDB) DeleteUser(ext sqlx.Ext, id int) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user: %w", err)
+	}
+	return rows == 1, nil
+}
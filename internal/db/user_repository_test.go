@@ -0,0 +1,50 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/db"
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func TestUserDB_CreateUser(t *testing.T) {
+	mockSQL, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockSQL.Close()
+	userDB := &db.UserDB{DB: sqlx.NewDb(mockSQL, "sqlmock")}
+
+	passwordHash := "hash"
+	user := &models.User{Email: "new@example.com", FirstName: "New", LastName: "User", PasswordHash: &passwordHash}
+
+	mock.ExpectQuery(`INSERT INTO users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	// The role is granted to the id the insert returned, not looked up again by email
+	mock.ExpectExec(`INSERT INTO user_roles \(user_id, role_id\)\s+SELECT \$1, roles.id FROM roles`).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO user_identities`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Every user owns a personal organization from the start, so bank routes work before they join another one
+	mock.ExpectQuery(`INSERT INTO organizations \(name, created_by\)`).
+		WithArgs(models.PersonalOrganizationName, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, time.Now()))
+	mock.ExpectQuery(`INSERT INTO memberships \(organization_id, user_id, role\)`).
+		WithArgs(7, 42, models.OrganizationRoleOwner).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	mock.ExpectExec(`UPDATE users SET active_organization_id = \$1 WHERE id = \$2`).
+		WithArgs(7, 42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, userDB.CreateUser(userDB.DB, user))
+
+	assert.Equal(t, 42, user.ID)
+	require.NotNil(t, user.ActiveOrganizationID)
+	assert.Equal(t, 7, *user.ActiveOrganizationID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+func (db *UserDB) FindWebAuthnCredentialsByUserID(userID int) ([]models.WebAuthnCredential, error) {
+	query := `SELECT * FROM webauthn_credentials WHERE user_id=$1 ORDER BY created_at`
+
+	credentials := []models.WebAuthnCredential{}
+	if err := db.Select(&credentials, query, userID); err != nil {
+		return nil, fmt.Errorf("could not find webauthn credentials: %v", err)
+	}
+
+	return credentials, nil
+}
+
+func (db *UserDB) CreateWebAuthnCredential(ext sqlx.Ext, credential *models.WebAuthnCredential) error {
+	query := `INSERT INTO webauthn_credentials (user_id, name, credential_id, public_key, attestation_type, transports, aaguid, sign_count, user_present, user_verified, backup_eligible, backup_state)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err := ext.Exec(
+		query,
+		credential.UserID,
+		credential.Name,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.Transports,
+		credential.AAGUID,
+		credential.SignCount,
+		credential.UserPresent,
+		credential.UserVerified,
+		credential.BackupEligible,
+		credential.BackupState,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webauthn credential: %w", err)
+	}
+	return nil
+}
+
+// UpdateWebAuthnCredentialUsage stores the new signature counter and backup state after a successful login
+func (db *UserDB) UpdateWebAuthnCredentialUsage(ext sqlx.Ext, credential *models.WebAuthnCredential) error {
+	query := `UPDATE webauthn_credentials 
+              SET sign_count = $1, 
+                  backup_state = $2, 
+                  last_used_at = NOW()
+              WHERE credential_id = $3`
+
+	_, err := ext.Exec(query, credential.SignCount, credential.BackupState, credential.CredentialID)
+	if err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredential deletes a credential owned by the user and reports whether one was deleted
+func (db *UserDB) DeleteWebAuthnCredential(ext sqlx.Ext, userID int, id int) (bool, error) {
+	result, err := ext.Exec(`DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+	return rows == 1, nil
+}
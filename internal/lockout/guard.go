@@ -0,0 +1,284 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/config"
+)
+
+// maxLockDuration caps progressive lockouts so an account is never locked for more than a day
+const maxLockDuration = 24 * time.Hour
+
+// busyRetryAfter is the wait when the key's attempts are used up by attempts that are still being checked
+const busyRetryAfter = time.Second
+
+// incrementScript counts an attempt and starts the window in the same step, so a counter is never left without an
+// expiry. A counter that lost its expiry is given one again on the next attempt.
+const incrementScript = `
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`
+
+// releaseScript gives back an attempt that did not fail, unless the counter was reset or expired in the meantime
+const releaseScript = `
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0`
+
+// Cache is the subset of the redis client needed to count failed attempts
+type Cache interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+}
+
+// Policy controls how failed attempts against a single key are throttled
+type Policy struct {
+	// MaxAttempts is the number of failures within Window that locks the key
+	MaxAttempts int64
+	// Window is how long failures are counted for, it starts at the first failure
+	Window time.Duration
+	// BaseDelay is the wait after the second failure, it doubles with every failure until the lockout. Zero disables delays
+	BaseDelay time.Duration
+	// LockDuration is the first lockout, it doubles with every further lockout within a day
+	LockDuration time.Duration
+}
+
+// Reservation is an attempt counted against a key before it is checked
+type Reservation struct {
+	Key    string
+	Policy Policy
+	// Attempts is the number of attempts in the window including this one, failed ones and ones still being checked
+	Attempts int64
+}
+
+// Result describes the state of a key after a failed attempt
+type Result struct {
+	Failures int64
+	// RetryAfter is how long the caller has to wait before the next attempt, zero if it may retry immediately
+	RetryAfter time.Duration
+	// LockedOut is set when this failure reached MaxAttempts and started a lockout
+	LockedOut bool
+}
+
+// Guard tracks failed attempts in redis and locks keys that see too many of them
+type Guard struct {
+	Cache   Cache
+	Account Policy
+	IP      Policy
+	OTP     Policy
+}
+
+// NewGuard initializes a Guard from the lockout configuration
+func NewGuard(cache Cache, cfg config.LockoutConfig) (*Guard, error) {
+	maxAttempts, err := strconv.ParseInt(cfg.MaxAttempts, 10, 64)
+	if err != nil || maxAttempts < 1 {
+		return nil, errors.New("LOCKOUT_MAX_ATTEMPTS must be a positive number")
+	}
+
+	ipMaxAttempts, err := strconv.ParseInt(cfg.IPMaxAttempts, 10, 64)
+	if err != nil || ipMaxAttempts < 1 {
+		return nil, errors.New("LOCKOUT_IP_MAX_ATTEMPTS must be a positive number")
+	}
+
+	otpMaxAttempts, err := strconv.ParseInt(cfg.OTPMaxAttempts, 10, 64)
+	if err != nil || otpMaxAttempts < 1 {
+		return nil, errors.New("LOCKOUT_OTP_MAX_ATTEMPTS must be a positive number")
+	}
+
+	window, err := time.ParseDuration(cfg.Window)
+	if err != nil || window <= 0 {
+		return nil, errors.New("LOCKOUT_WINDOW must be a positive duration")
+	}
+
+	duration, err := time.ParseDuration(cfg.Duration)
+	if err != nil || duration <= 0 {
+		return nil, errors.New("LOCKOUT_DURATION must be a positive duration")
+	}
+
+	baseDelay, err := time.ParseDuration(cfg.BaseDelay)
+	if err != nil || baseDelay < 0 {
+		return nil, errors.New("LOCKOUT_BASE_DELAY must be a duration")
+	}
+
+	return &Guard{
+		Cache:   cache,
+		Account: Policy{MaxAttempts: maxAttempts, Window: window, BaseDelay: baseDelay, LockDuration: duration},
+		// Many users can share an IP address, so it only gets a hard limit and no delays
+		IP:  Policy{MaxAttempts: ipMaxAttempts, Window: window, LockDuration: duration},
+		OTP: Policy{MaxAttempts: otpMaxAttempts, Window: window, BaseDelay: baseDelay, LockDuration: duration},
+	}, nil
+}
+
+// AccountKey identifies the failed attempts against an email address for an action such as "login"
+func AccountKey(action string, email string) string {
+	return fmt.Sprintf("%s:email:%s", action, strings.ToLower(strings.TrimSpace(email)))
+}
+
+// IPKey identifies the failed attempts from a client IP address for an action such as "login"
+func IPKey(action string, ip string) string {
+	return fmt.Sprintf("%s:ip:%s", action, ip)
+}
+
+func failuresKey(key string) string {
+	return fmt.Sprintf("lockout:%s:failures", key)
+}
+
+func lockedUntilKey(key string) string {
+	return fmt.Sprintf("lockout:%s:locked_until", key)
+}
+
+func lockoutsKey(key string) string {
+	return fmt.Sprintf("lockout:%s:lockouts", key)
+}
+
+// RetryAfter returns how long until every one of the keys accepts another attempt
+func (g *Guard) RetryAfter(ctx context.Context, keys ...string) (time.Duration, error) {
+	var longest time.Duration
+
+	for _, key := range keys {
+		value, err := g.Cache.Get(ctx, lockedUntilKey(key)).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return 0, fmt.Errorf("failed to get lockout: %w", err)
+		}
+
+		lockedUntil, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if remaining := time.Until(time.Unix(lockedUntil, 0)); remaining > longest {
+			longest = remaining
+		}
+	}
+
+	return longest, nil
+}
+
+// Reserve counts an attempt against the key before it is checked, so concurrent attempts cannot all get past the
+// limit. It returns how long the caller has to wait instead when the key is locked or its attempts are used up.
+// The attempt must then be recorded with RecordFailure, or given back with Release or Reset when it succeeds.
+func (g *Guard) Reserve(ctx context.Context, key string, policy Policy) (Reservation, time.Duration, error) {
+	// 1) A locked key takes no attempts
+	retryAfter, err := g.RetryAfter(ctx, key)
+	if err != nil {
+		return Reservation{}, 0, err
+	}
+	if retryAfter > 0 {
+		return Reservation{}, retryAfter, nil
+	}
+
+	// 2) Count the attempt, the window starts with the first one
+	attempts, err := g.increment(ctx, failuresKey(key), policy.Window)
+	if err != nil {
+		return Reservation{}, 0, fmt.Errorf("failed to count attempt: %w", err)
+	}
+
+	reservation := Reservation{Key: key, Policy: policy, Attempts: attempts}
+
+	// 3) Attempts beyond the limit are turned away, the ones before them decide whether the key is locked
+	if attempts > policy.MaxAttempts {
+		if err := g.Release(ctx, reservation); err != nil {
+			return Reservation{}, 0, err
+		}
+		return Reservation{}, busyRetryAfter, nil
+	}
+
+	return reservation, 0, nil
+}
+
+// RecordFailure marks a reserved attempt as failed and locks the key when the policy says so
+func (g *Guard) RecordFailure(ctx context.Context, reservation Reservation) (Result, error) {
+	key, policy := reservation.Key, reservation.Policy
+	result := Result{Failures: reservation.Attempts}
+
+	// 1) Too many failures locks the key, every lockout within a day doubles the next one
+	if reservation.Attempts >= policy.MaxAttempts {
+		lockouts, err := g.increment(ctx, lockoutsKey(key), maxLockDuration)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to count lockout: %w", err)
+		}
+
+		result.RetryAfter = backoff(policy.LockDuration, lockouts-1, maxLockDuration)
+		result.LockedOut = true
+
+		lockedUntil := time.Now().Add(result.RetryAfter).Unix()
+		if err := g.Cache.Set(ctx, lockedUntilKey(key), lockedUntil, result.RetryAfter).Err(); err != nil {
+			return Result{}, fmt.Errorf("failed to store lockout: %w", err)
+		}
+
+		// The lock itself blocks further attempts, so the next window starts from zero once it expires
+		if err := g.Cache.Del(ctx, failuresKey(key)).Err(); err != nil {
+			return Result{}, fmt.Errorf("failed to reset failed attempts: %w", err)
+		}
+		return result, nil
+	}
+
+	// 2) Otherwise slow the caller down a little more with every failure, the first one is free for typos
+	if policy.BaseDelay > 0 && reservation.Attempts > 1 {
+		result.RetryAfter = backoff(policy.BaseDelay, reservation.Attempts-2, policy.LockDuration)
+
+		// A delay never shortens a lock another attempt has set in the meantime
+		lockedUntil := time.Now().Add(result.RetryAfter).Unix()
+		err := g.Cache.SetArgs(ctx, lockedUntilKey(key), lockedUntil, redis.SetArgs{Mode: "NX", TTL: result.RetryAfter}).Err()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return Result{}, fmt.Errorf("failed to store lockout: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Release gives back a reserved attempt that did not fail
+func (g *Guard) Release(ctx context.Context, reservation Reservation) error {
+	if reservation.Key == "" {
+		return nil
+	}
+
+	if err := g.Cache.Eval(ctx, releaseScript, []string{failuresKey(reservation.Key)}).Err(); err != nil {
+		return fmt.Errorf("failed to release attempt: %w", err)
+	}
+	return nil
+}
+
+// increment adds one to the counter and starts its window atomically
+func (g *Guard) increment(ctx context.Context, counterKey string, window time.Duration) (int64, error) {
+	return g.Cache.Eval(ctx, incrementScript, []string{counterKey}, window.Milliseconds()).Int64()
+}
+
+// Reset clears the failed attempts for the key after a successful attempt
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	if err := g.Cache.Del(ctx, failuresKey(key), lockedUntilKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to reset failed attempts: %w", err)
+	}
+	return nil
+}
+
+// backoff doubles base the given number of times without exceeding limit
+func backoff(base time.Duration, doublings int64, limit time.Duration) time.Duration {
+	delay := base
+	for i := int64(0); i < doublings && delay < limit; i++ {
+		delay *= 2
+	}
+
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
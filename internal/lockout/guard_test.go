@@ -0,0 +1,210 @@
+package lockout_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/lockout"
+)
+
+// memoryCache is a map backed Cache, expirations are ignored.
+type memoryCache map[string]string
+
+func (m memoryCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := m[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (m memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	m[key] = fmt.Sprint(value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m memoryCache) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := m[key]; ok {
+			delete(m, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
+
+func (m memoryCache) SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd {
+	if _, ok := m[key]; ok && a.Mode == "NX" {
+		return redis.NewStatusResult("", redis.Nil)
+	}
+	m[key] = fmt.Sprint(value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+// Eval runs the guard's scripts: the release script decrements a positive counter, the increment script increments it
+func (m memoryCache) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	count, _ := strconv.ParseInt(m[keys[0]], 10, 64)
+	switch {
+	case strings.Contains(script, "DECR") && count > 0:
+		count--
+	case strings.Contains(script, "DECR"):
+		return redis.NewCmdResult(int64(0), nil)
+	default:
+		count++
+	}
+	m[keys[0]] = strconv.FormatInt(count, 10)
+	return redis.NewCmdResult(count, nil)
+}
+
+// fail reserves an attempt and records it as failed
+func fail(t *testing.T, guard *lockout.Guard, key string, policy lockout.Policy) lockout.Result {
+	t.Helper()
+	reservation, retryAfter, err := guard.Reserve(context.Background(), key, policy)
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+
+	result, err := guard.RecordFailure(context.Background(), reservation)
+	require.NoError(t, err)
+	return result
+}
+
+func TestGuard_ProgressiveDelaysAndLockout(t *testing.T) {
+	ctx := context.Background()
+	cache := memoryCache{}
+	guard, err := lockout.NewGuard(cache, config.LockoutConfig{
+		MaxAttempts: "4", IPMaxAttempts: "20", OTPMaxAttempts: "5", Window: "15m", Duration: "10m", BaseDelay: "1s",
+	})
+	require.NoError(t, err)
+
+	key := lockout.AccountKey("login", " Test@Example.com ")
+	assert.Equal(t, "login:email:test@example.com", key)
+
+	// 1) The first failure is free, then the delay doubles until the lockout
+	expected := []time.Duration{0, time.Second, 2 * time.Second, 10 * time.Minute}
+	for i, delay := range expected {
+		// Wait out the delay of the previous failure
+		delete(cache, "lockout:login:email:test@example.com:locked_until")
+		result := fail(t, guard, key, guard.Account)
+		assert.Equal(t, int64(i+1), result.Failures)
+		assert.Equal(t, delay, result.RetryAfter)
+		assert.Equal(t, i == len(expected)-1, result.LockedOut)
+	}
+
+	retryAfter, err := guard.RetryAfter(ctx, key, lockout.IPKey("login", "127.0.0.1"))
+	require.NoError(t, err)
+	assert.InDelta(t, (10 * time.Minute).Seconds(), retryAfter.Seconds(), 2)
+
+	// 2) Failures start from zero once the lock expires and the next lockout is twice as long
+	delete(cache, "lockout:login:email:test@example.com:locked_until")
+	for i := 0; i < 4; i++ {
+		delete(cache, "lockout:login:email:test@example.com:locked_until")
+		result := fail(t, guard, key, guard.Account)
+		if i == 3 {
+			assert.True(t, result.LockedOut)
+			assert.Equal(t, 20*time.Minute, result.RetryAfter)
+		}
+	}
+
+	// 3) A successful attempt clears the lock
+	require.NoError(t, guard.Reset(ctx, key))
+	retryAfter, err = guard.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+func TestGuard_IPPolicyHasNoDelays(t *testing.T) {
+	guard, err := lockout.NewGuard(memoryCache{}, config.LockoutConfig{
+		MaxAttempts: "5", IPMaxAttempts: "3", OTPMaxAttempts: "5", Window: "15m", Duration: "15m", BaseDelay: "1s",
+	})
+	require.NoError(t, err)
+
+	key := lockout.IPKey("login", "10.0.0.1")
+	for i := 0; i < 2; i++ {
+		result := fail(t, guard, key, guard.IP)
+		assert.Zero(t, result.RetryAfter)
+	}
+
+	result := fail(t, guard, key, guard.IP)
+	assert.True(t, result.LockedOut)
+	assert.Equal(t, 15*time.Minute, result.RetryAfter)
+}
+
+func TestGuard_ReservesAttemptsBeforeTheyAreChecked(t *testing.T) {
+	ctx := context.Background()
+	cache := memoryCache{}
+	guard, err := lockout.NewGuard(cache, config.LockoutConfig{
+		MaxAttempts: "3", IPMaxAttempts: "20", OTPMaxAttempts: "5", Window: "15m", Duration: "15m", BaseDelay: "0s",
+	})
+	require.NoError(t, err)
+	key := lockout.AccountKey("login", "test@example.com")
+
+	// 1) Concurrent attempts each take one of the key's attempts, the ones over the limit are turned away unchecked
+	var reservations []lockout.Reservation
+	for i := 0; i < 3; i++ {
+		reservation, retryAfter, err := guard.Reserve(ctx, key, guard.Account)
+		require.NoError(t, err)
+		require.Zero(t, retryAfter)
+		reservations = append(reservations, reservation)
+	}
+
+	_, retryAfter, err := guard.Reserve(ctx, key, guard.Account)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, retryAfter)
+	assert.Equal(t, "3", cache["lockout:login:email:test@example.com:failures"])
+
+	// 2) An attempt that did not fail gives its place back
+	require.NoError(t, guard.Release(ctx, reservations[0]))
+	assert.Equal(t, "2", cache["lockout:login:email:test@example.com:failures"])
+
+	// 3) The concurrent attempt that took the last place locks the key when it fails
+	result, err := guard.RecordFailure(ctx, reservations[1])
+	require.NoError(t, err)
+	assert.False(t, result.LockedOut)
+
+	result, err = guard.RecordFailure(ctx, reservations[2])
+	require.NoError(t, err)
+	assert.True(t, result.LockedOut)
+
+	_, retryAfter, err = guard.Reserve(ctx, key, guard.Account)
+	require.NoError(t, err)
+	assert.InDelta(t, (15 * time.Minute).Seconds(), retryAfter.Seconds(), 2)
+
+	// 4) Attempts still being checked when the key was locked give nothing back once it has been reset
+	require.NoError(t, guard.Release(ctx, reservations[0]))
+	assert.NotContains(t, cache, "lockout:login:email:test@example.com:failures")
+}
+
+func TestNewGuard_Errors(t *testing.T) {
+	valid := config.LockoutConfig{MaxAttempts: "5", IPMaxAttempts: "20", OTPMaxAttempts: "5", Window: "15m", Duration: "15m", BaseDelay: "1s"}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *config.LockoutConfig)
+	}{
+		{name: "Zero max attempts", modify: func(cfg *config.LockoutConfig) { cfg.MaxAttempts = "0" }},
+		{name: "Invalid IP max attempts", modify: func(cfg *config.LockoutConfig) { cfg.IPMaxAttempts = "many" }},
+		{name: "Invalid OTP max attempts", modify: func(cfg *config.LockoutConfig) { cfg.OTPMaxAttempts = "" }},
+		{name: "Invalid window", modify: func(cfg *config.LockoutConfig) { cfg.Window = "soon" }},
+		{name: "Zero duration", modify: func(cfg *config.LockoutConfig) { cfg.Duration = "0s" }},
+		{name: "Negative delay", modify: func(cfg *config.LockoutConfig) { cfg.BaseDelay = "-1s" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			_, err := lockout.NewGuard(memoryCache{}, cfg)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,23 +1,64 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/signing"
 )
 
-// AuthMiddleware is the middleware that checks for the presence and validity of the JWT token
-func (m *Middleware) AuthMiddleware(secretKey string) gin.HandlerFunc {
+// touchInterval limits how often an API key's or personal access token's last use is written while it is in use
+const touchInterval = time.Minute
+
+// AuthMiddleware is the middleware that checks for the presence and validity of the JWT token.
+// Services can authenticate with "Authorization: ApiKey <key>" and scripts with "Authorization: Bearer pat_...",
+// the caller is set in the context as a principal. Only session tokens set the user, so personal access tokens
+// cannot manage the account.
+func (m *Middleware) AuthMiddleware(keys *signing.KeyManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1) Extract token from cookie or header
+		// 1) Services send an API key instead of a token
+		if apiKey, found := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey "); found {
+			key, err := m.authenticateAPIKey(apiKey)
+			if err != nil {
+				m.Logger.Error("Invalid API key", "error", err)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+				c.Abort()
+				return
+			}
+
+			c.Set("principal", &models.Principal{Type: models.PrincipalService, APIKey: key})
+			c.Next()
+			return
+		}
+
+		// 2) Scripts send a personal access token as a bearer token
+		bearer, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if strings.HasPrefix(bearer, models.PersonalAccessTokenPrefix) {
+			principal, err := m.authenticatePersonalAccessToken(bearer, c.ClientIP())
+			if err != nil {
+				m.Logger.Error("Invalid personal access token", "error", err)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+
+			c.Set("principal", principal)
+			c.Next()
+			return
+		}
+
+		// 3) Extract token from cookie or header
 		token, err := c.Cookie("auth_token") // Try getting it from cookies
 		if err != nil {
-			token = c.GetHeader("Authorization") // Try getting from Authorization header
+			token = bearer // Try getting from Authorization header, with or without the Bearer scheme
 			if token == "" {
 				m.Logger.Error("Missing token", "error", err)
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
@@ -26,8 +67,8 @@ func (m *Middleware) AuthMiddleware(secretKey string) gin.HandlerFunc {
 			}
 		}
 
-		// 2) Decode the JWT and validate it
-		user, err := m.decodeJWT(token, secretKey)
+		// 4) Decode the JWT and validate it
+		user, err := m.decodeJWT(token, keys)
 		if err != nil {
 			m.Logger.Error("Invalid or expired token", "error", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
@@ -35,23 +76,134 @@ func (m *Middleware) AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
-		// 3) Set the user in the context for downstream handlers
+		// 5) Set the user in the context for downstream handlers
 		c.Set("user", user)
+		c.Set("principal", &models.Principal{Type: models.PrincipalUser, User: user})
+		c.Next()
+	}
+}
+
+// CurrentPrincipal returns the caller set by AuthMiddleware
+func CurrentPrincipal(c *gin.Context) (*models.Principal, bool) {
+	if value, ok := c.Get("principal"); ok {
+		if principal, ok := value.(*models.Principal); ok && principal != nil {
+			return principal, true
+		}
+	}
+
+	// Handlers tested without the middleware only set the user
+	if value, ok := c.Get("user"); ok {
+		if user, ok := value.(*models.User); ok && user != nil {
+			return &models.Principal{Type: models.PrincipalUser, User: user}, true
+		}
+	}
+	return nil, false
+}
+
+// authenticateAPIKey finds an API key by its hash and checks it is neither revoked nor expired
+func (m *Middleware) authenticateAPIKey(apiKey string) (*models.APIKey, error) {
+	if apiKey == "" || m.Credentials == nil {
+		return nil, fmt.Errorf("missing api key")
+	}
+
+	key, err := m.Credentials.FindAPIKeyByHash(models.HashAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	now := time.Now()
+	if key == nil || !key.Usable(now) {
+		return nil, fmt.Errorf("api key is unknown, revoked or expired")
+	}
+
+	// Recording every request would mean a write per call, a minute is precise enough for spotting unused keys
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		if err := m.Credentials.TouchAPIKey(key.ID); err != nil {
+			m.Logger.Error("Failed to record API key use", "apiKeyID", key.ID, "error", err)
+		}
+	}
+
+	return key, nil
+}
+
+// authenticatePersonalAccessToken finds a token by its hash and returns its user, limited to the token's scopes.
+// The user's status and permissions are read from the database on every request, so suspensions and revoked roles
+// apply to tokens straight away.
+func (m *Middleware) authenticatePersonalAccessToken(bearer string, ip string) (*models.Principal, error) {
+	if m.Credentials == nil {
+		return nil, fmt.Errorf("personal access tokens are not enabled")
+	}
+
+	// 1) Find the token
+	token, err := m.Credentials.FindPersonalAccessTokenByHash(models.HashPersonalAccessToken(bearer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find personal access token: %w", err)
+	}
+
+	now := time.Now()
+	if token == nil || !token.Usable(now) {
+		return nil, fmt.Errorf("personal access token is unknown or expired")
+	}
+
+	// 2) The user must still be able to sign in
+	user, err := m.Credentials.FindUserByID(token.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if user == nil || !user.Status.CanSignIn() {
+		return nil, fmt.Errorf("user %d cannot sign in", token.UserID)
+	}
+
+	// 3) The token only grants the scopes the user still holds, and never any roles
+	permissions, err := m.Credentials.FindPermissionsByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find permissions: %w", err)
+	}
+
+	user.Roles = nil
+	user.Permissions = nil
+	for _, scope := range token.Scopes {
+		if slices.Contains(permissions, scope) {
+			user.Permissions = append(user.Permissions, scope)
+		}
+	}
+
+	// 4) Record the use, at most once a minute unless the token moves to another IP
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= touchInterval || token.LastUsedIP == nil || *token.LastUsedIP != ip {
+		if err := m.Credentials.TouchPersonalAccessToken(token.ID, ip); err != nil {
+			m.Logger.Error("Failed to record personal access token use", "tokenID", token.ID, "error", err)
+		}
+	}
+
+	return &models.Principal{Type: models.PrincipalUser, User: user, Token: token}, nil
+}
+
+// OptionalAuthMiddleware sets the user in the context when a valid token is present, requests without one continue anonymously
+func (m *Middleware) OptionalAuthMiddleware(keys *signing.KeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1) Extract token from cookie or header
+		token, err := c.Cookie("auth_token")
+		if err != nil {
+			token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		// 2) Only set the user if the token is valid
+		if token != "" {
+			if user, err := m.decodeJWT(token, keys); err == nil {
+				c.Set("user", user)
+				c.Set("principal", &models.Principal{Type: models.PrincipalUser, User: user})
+			}
+		}
 		c.Next()
 	}
 }
 
 // decodeJWT extracts user information from the JWT token
-func (m *Middleware) decodeJWT(tokenString string, secretKey string) (*models.User, error) {
+func (m *Middleware) decodeJWT(tokenString string, keys *signing.KeyManager) (*models.User, error) {
 	// 1) Parse the token with claims
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// 2) Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			m.Logger.Error("Unexpected signing method")
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secretKey), nil // Return the secret key
-	})
+	// 2) The key manager picks the verification key by kid and rejects any other signing method
+	token, err := jwt.Parse(tokenString, keys.Keyfunc, jwt.WithValidMethods(keys.Algorithms()))
 
 	// 3) Check for parsing errors
 	if err != nil {
@@ -79,7 +231,17 @@ func (m *Middleware) decodeJWT(tokenString string, secretKey string) (*models.Us
 			return nil, fmt.Errorf("invalid email type in token")
 		}
 
-		// 7) Check if the token is expired
+		// 7) Extract roles and permissions, tokens issued before roles existed have none
+		user.Roles = stringClaims(claims, "roles")
+		user.Permissions = stringClaims(claims, "permissions")
+
+		// 8) Extract the active organization, tokens issued outside an organization have none
+		if orgID, ok := claims["org_id"].(float64); ok {
+			organizationID := int(orgID)
+			user.ActiveOrganizationID = &organizationID
+		}
+
+		// 9) Check if the token is expired
 		if exp, ok := claims["exp"].(float64); ok {
 			if int64(exp) < time.Now().Unix() {
 				m.Logger.Error("Token has expired")
@@ -87,9 +249,49 @@ func (m *Middleware) decodeJWT(tokenString string, secretKey string) (*models.Us
 			}
 		}
 
+		// 10) Check that the token has not been revoked
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			m.Logger.Error("Invalid jti in token")
+			return nil, fmt.Errorf("invalid jti type in token")
+		}
+
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil {
+			m.Logger.Error("Invalid iat in token")
+			return nil, fmt.Errorf("invalid iat type in token")
+		}
+
+		// Tokens carry their issue time in milliseconds as well, older tokens only have whole seconds
+		issuedAtTime := issuedAt.Time
+		if issuedAtMilli, ok := claims["iat_ms"].(float64); ok {
+			issuedAtTime = time.UnixMilli(int64(issuedAtMilli))
+		}
+
+		if err := m.Sessions.CheckToken(context.Background(), jti, user.ID, issuedAtTime); err != nil {
+			m.Logger.Error("Token rejected by revocation check", "jti", jti, "userID", user.ID, "error", err)
+			return nil, fmt.Errorf("token rejected: %w", err)
+		}
+
 		return user, nil
 	}
 
 	m.Logger.Error("Invalid token")
 	return nil, fmt.Errorf("invalid token")
 }
+
+// stringClaims returns a list of strings claim, JSON decoding turns it into a slice of interfaces
+func stringClaims(claims jwt.MapClaims, name string) []string {
+	values, ok := claims[name].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	strings := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strings = append(strings, s)
+		}
+	}
+	return strings
+}
@@ -0,0 +1,223 @@
+package middleware_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/session"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// memoryCredentials is a credential store that records which API keys and tokens were touched.
+type memoryCredentials struct {
+	keys          map[string]*models.APIKey
+	tokens        map[string]*models.PersonalAccessToken
+	users         map[int]*models.User
+	permissions   map[int][]string
+	touchedKeys   []int
+	touchedTokens []string
+}
+
+func (m *memoryCredentials) FindAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	return m.keys[keyHash], nil
+}
+
+func (m *memoryCredentials) TouchAPIKey(id int) error {
+	m.touchedKeys = append(m.touchedKeys, id)
+	for _, key := range m.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memoryCredentials) FindPersonalAccessTokenByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	return m.tokens[tokenHash], nil
+}
+
+func (m *memoryCredentials) TouchPersonalAccessToken(id int, ip string) error {
+	m.touchedTokens = append(m.touchedTokens, fmt.Sprintf("%d@%s", id, ip))
+	for _, token := range m.tokens {
+		if token.ID == id {
+			now := time.Now()
+			token.LastUsedAt = &now
+			token.LastUsedIP = &ip
+		}
+	}
+	return nil
+}
+
+func (m *memoryCredentials) FindUserByID(id int) (*models.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (m *memoryCredentials) FindPermissionsByUserID(userID int) ([]string, error) {
+	return m.permissions[userID], nil
+}
+
+func TestAuthMiddleware_APIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := signing.LoadKeyManager(config.JWTConfig{Token: "test-secret"})
+	require.NoError(t, err)
+
+	jwtService := &auth.JWTService{Keys: keys, JwtExpiry: "1m", Roles: staticRoles{
+		1: {{"admin", "member"}, {"bank:upload"}},
+	}}
+
+	past := time.Now().Add(-time.Hour)
+	recently := time.Now().Add(-time.Second)
+	credentials := &memoryCredentials{keys: map[string]*models.APIKey{
+		models.HashAPIKey("ak_upload"):  {ID: 1, Scopes: []string{"bank:upload"}},
+		models.HashAPIKey("ak_stock"):   {ID: 2, Scopes: []string{"stock:read"}, LastUsedAt: &recently},
+		models.HashAPIKey("ak_expired"): {ID: 3, Scopes: []string{"bank:upload"}, ExpiresAt: &past},
+		models.HashAPIKey("ak_revoked"): {ID: 4, Scopes: []string{"bank:upload"}, RevokedAt: &past},
+	}}
+
+	m := middleware.NewMiddlewareSetup(slog.New(slog.NewTextHandler(io.Discard, nil)), &session.RevocationStore{Cache: emptyCache{}}, nil, credentials, nil)
+
+	var principal *models.Principal
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		principal, _ = middleware.CurrentPrincipal(c)
+		c.Status(http.StatusOK)
+	}
+	router.GET("/upload", m.AuthMiddleware(keys), m.RequirePermission("bank:upload"), ok)
+	router.GET("/admin", m.AuthMiddleware(keys), m.RequireRole("admin"), ok)
+
+	request := func(path string, authorization string) int {
+		principal = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 1) A key with the scope is let through as a service
+	assert.Equal(t, http.StatusOK, request("/upload", "ApiKey ak_upload"))
+	require.NotNil(t, principal)
+	assert.Equal(t, models.PrincipalService, principal.Type)
+	assert.Nil(t, principal.User)
+	assert.Equal(t, "api_key:1", principal.Subject())
+
+	// 2) Scopes are checked like permissions, and services never hold roles
+	assert.Equal(t, http.StatusForbidden, request("/upload", "ApiKey ak_stock"))
+	assert.Equal(t, http.StatusForbidden, request("/admin", "ApiKey ak_upload"))
+
+	// 3) Unknown, expired and revoked keys are rejected
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "ApiKey ak_unknown"))
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "ApiKey ak_expired"))
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "ApiKey ak_revoked"))
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "ApiKey "))
+
+	// 4) Last use is recorded at most once a minute
+	assert.Equal(t, []int{1}, credentials.touchedKeys)
+
+	// 5) Users are still authenticated with their token
+	token, err := jwtService.GenerateJWT(&models.User{ID: 1, Email: "test@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request("/admin", token))
+	require.NotNil(t, principal)
+	assert.Equal(t, models.PrincipalUser, principal.Type)
+	assert.Equal(t, 1, principal.User.ID)
+}
+
+func TestAuthMiddleware_PersonalAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := signing.LoadKeyManager(config.JWTConfig{Token: "test-secret"})
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	credentials := &memoryCredentials{
+		tokens: map[string]*models.PersonalAccessToken{
+			models.HashPersonalAccessToken("pat_admin"):     {ID: 1, UserID: 1, Scopes: []string{"bank:upload", "roles:manage"}},
+			models.HashPersonalAccessToken("pat_expired"):   {ID: 2, UserID: 1, Scopes: []string{"bank:upload"}, ExpiresAt: &past},
+			models.HashPersonalAccessToken("pat_suspended"): {ID: 3, UserID: 2, Scopes: []string{"bank:upload"}},
+			models.HashPersonalAccessToken("pat_demoted"):   {ID: 4, UserID: 3, Scopes: []string{"bank:upload"}},
+		},
+		users: map[int]*models.User{
+			1: {ID: 1, Email: "admin@example.com", Status: models.AccountStatusActive},
+			2: {ID: 2, Email: "suspended@example.com", Status: models.AccountStatusSuspended},
+			3: {ID: 3, Email: "demoted@example.com", Status: models.AccountStatusActive},
+		},
+		permissions: map[int][]string{
+			1: {"bank:upload", "roles:manage", "users:write"},
+			2: {"bank:upload"},
+		},
+	}
+
+	m := middleware.NewMiddlewareSetup(slog.New(slog.NewTextHandler(io.Discard, nil)), &session.RevocationStore{Cache: emptyCache{}}, nil, credentials, nil)
+
+	var principal *models.Principal
+	var sessionUser bool
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		principal, _ = middleware.CurrentPrincipal(c)
+		_, sessionUser = c.Get("user")
+		c.Status(http.StatusOK)
+	}
+	router.GET("/upload", m.AuthMiddleware(keys), m.RequirePermission("bank:upload"), ok)
+	router.GET("/users", m.AuthMiddleware(keys), m.RequirePermission("users:write"), ok)
+	router.GET("/admin", m.AuthMiddleware(keys), m.RequireRole("admin"), ok)
+
+	request := func(path string, token string) int {
+		principal = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 1) A token acts as its user, but without setting the session user
+	assert.Equal(t, http.StatusOK, request("/upload", "pat_admin"))
+	require.NotNil(t, principal)
+	assert.Equal(t, models.PrincipalUser, principal.Type)
+	assert.Equal(t, 1, principal.User.ID)
+	assert.Equal(t, 1, principal.Token.ID)
+	assert.False(t, sessionUser, "personal access tokens must not be able to manage the account")
+
+	// 2) Permissions are limited to the token's scopes, and tokens never carry roles
+	assert.Equal(t, http.StatusForbidden, request("/users", "pat_admin"))
+	assert.Equal(t, http.StatusForbidden, request("/admin", "pat_admin"))
+
+	// 3) Scopes the user has since lost no longer apply
+	assert.Equal(t, http.StatusForbidden, request("/upload", "pat_demoted"))
+
+	// 4) Expired tokens, unknown tokens and suspended users are rejected
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "pat_expired"))
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "pat_unknown"))
+	assert.Equal(t, http.StatusUnauthorized, request("/upload", "pat_suspended"))
+
+	// 5) The first use and each use from a new IP are recorded
+	assert.Equal(t, []string{"1@192.0.2.1", "4@192.0.2.1"}, credentials.touchedTokens)
+
+	// 6) Session tokens can also be sent with the Bearer scheme
+	jwtService := &auth.JWTService{Keys: keys, JwtExpiry: "1m", Roles: staticRoles{1: {{"admin"}, {"users:write"}}}}
+	token, err := jwtService.GenerateJWT(&models.User{ID: 1, Email: "admin@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request("/users", token))
+	assert.True(t, sessionUser)
+}
@@ -2,16 +2,43 @@ package middleware
 
 import (
 	"log/slog"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/ratelimit"
+	"github.com/jalil32/go-auth-module/internal/session"
 )
 
+// CredentialStore looks up the API keys services authenticate with and the personal access tokens users script with
+type CredentialStore interface {
+	FindAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	TouchAPIKey(id int) error
+	FindPersonalAccessTokenByHash(tokenHash string) (*models.PersonalAccessToken, error)
+	TouchPersonalAccessToken(id int, ip string) error
+	FindUserByID(id int) (*models.User, error)
+	FindPermissionsByUserID(userID int) ([]string, error)
+}
+
+// OrganizationStore looks up whether a user is a member of an organization
+type OrganizationStore interface {
+	FindMembership(organizationID int, userID int) (*models.Membership, error)
+}
+
 type Middleware struct {
-	Logger *slog.Logger
+	Logger        *slog.Logger
+	Sessions      *session.RevocationStore
+	RateLimiter   *ratelimit.Limiter
+	Credentials   CredentialStore
+	Organizations OrganizationStore
 }
 
 // NewAuthController initializes a new AuthController
-func NewMiddlewareSetup(logger *slog.Logger) *Middleware {
+func NewMiddlewareSetup(logger *slog.Logger, sessions *session.RevocationStore, rateLimiter *ratelimit.Limiter, credentials CredentialStore, organizations OrganizationStore) *Middleware {
 
 	return &Middleware{
-		Logger: logger,
+		Logger:        logger,
+		Sessions:      sessions,
+		RateLimiter:   rateLimiter,
+		Credentials:   credentials,
+		Organizations: organizations,
 	}
 }
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// organizationHeader lets a caller pick an organization for one request instead of their active organization
+const organizationHeader = "X-Organization-ID"
+
+// RequireOrganization resolves the organization the caller works in and sets their membership in the context,
+// it must run after AuthMiddleware. Users work in their active organization, the org_id claim, unless the request
+// names another one in the X-Organization-ID header. Membership is read from the database on every request, so
+// removed members lose access straight away. API keys act as members of the organization they are bound to, for as
+// long as the user who created them can sign in and is a member of it too. When roles are given the member must hold one of them.
+func (m *Middleware) RequireOrganization(roles ...models.OrganizationRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1) Get the caller set by AuthMiddleware
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			m.Logger.Error("Principal missing from context", "required", "organization")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		// 2) Pick the organization, the header wins over the active organization
+		organizationID := principal.OrganizationID()
+		if header := c.GetHeader(organizationHeader); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+				c.Abort()
+				return
+			}
+			organizationID = &id
+		}
+
+		if organizationID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No active organization"})
+			c.Abort()
+			return
+		}
+
+		// 3) Check the caller belongs to the organization
+		membership, err := m.findMembership(principal, *organizationID)
+		if err != nil {
+			m.Logger.Error("Failed to find membership", "principal", principal.Subject(), "organizationID", *organizationID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong..."})
+			c.Abort()
+			return
+		}
+
+		if membership == nil || (len(roles) > 0 && !slices.Contains(roles, membership.Role)) {
+			m.Logger.Info("Access denied", "principal", principal.Subject(), "organizationID", *organizationID, "path", c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			c.Abort()
+			return
+		}
+
+		// 4) Set the membership in the context for downstream handlers
+		c.Set("organization", membership)
+		c.Next()
+	}
+}
+
+// CurrentOrganization returns the membership set by RequireOrganization
+func CurrentOrganization(c *gin.Context) (*models.Membership, bool) {
+	value, ok := c.Get("organization")
+	if !ok {
+		return nil, false
+	}
+
+	membership, ok := value.(*models.Membership)
+	return membership, ok && membership != nil
+}
+
+// findMembership returns the caller's membership of the organization, or nil if they do not belong to it
+func (m *Middleware) findMembership(principal *models.Principal, organizationID int) (*models.Membership, error) {
+	if principal.Type == models.PrincipalService {
+		key := principal.APIKey
+		if key == nil || key.OrganizationID == nil || *key.OrganizationID != organizationID {
+			return nil, nil
+		}
+
+		// A key works with its creator's data, so it loses access with them
+		if key.CreatedBy != nil {
+			creator, err := m.findActiveMember(*key.CreatedBy, organizationID)
+			if err != nil || creator == nil {
+				return nil, err
+			}
+		}
+		return &models.Membership{OrganizationID: organizationID, Role: models.OrganizationRoleMember}, nil
+	}
+
+	if principal.User == nil || m.Organizations == nil {
+		return nil, nil
+	}
+	return m.Organizations.FindMembership(organizationID, principal.User.ID)
+}
+
+// findActiveMember returns the user's membership of the organization, or nil if they cannot sign in or do not belong to it
+func (m *Middleware) findActiveMember(userID int, organizationID int) (*models.Membership, error) {
+	if m.Credentials == nil || m.Organizations == nil {
+		return nil, nil
+	}
+
+	user, err := m.Credentials.FindUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if user == nil || !user.Status.CanSignIn() {
+		return nil, nil
+	}
+	return m.Organizations.FindMembership(organizationID, userID)
+}
@@ -0,0 +1,123 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/session"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// memoryMemberships holds each user's role per organization ID.
+type memoryMemberships map[int]map[int]models.OrganizationRole
+
+func (m memoryMemberships) FindMembership(organizationID int, userID int) (*models.Membership, error) {
+	role, ok := m[organizationID][userID]
+	if !ok {
+		return nil, nil
+	}
+	return &models.Membership{OrganizationID: organizationID, UserID: userID, Role: role}, nil
+}
+
+func TestRequireOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := signing.LoadKeyManager(config.JWTConfig{Token: "test-secret"})
+	require.NoError(t, err)
+
+	jwtService := &auth.JWTService{Keys: keys, JwtExpiry: "1m"}
+
+	household, business := 7, 8
+	member, outsider, suspended := 2, 3, 4
+	credentials := &memoryCredentials{
+		keys: map[string]*models.APIKey{
+			models.HashAPIKey("ak_household"): {ID: 1, Scopes: []string{"bank:upload"}, OrganizationID: &household, CreatedBy: &member},
+			models.HashAPIKey("ak_unbound"):   {ID: 2, Scopes: []string{"bank:upload"}},
+			models.HashAPIKey("ak_outsider"):  {ID: 3, Scopes: []string{"bank:upload"}, OrganizationID: &household, CreatedBy: &outsider},
+			models.HashAPIKey("ak_suspended"): {ID: 4, Scopes: []string{"bank:upload"}, OrganizationID: &household, CreatedBy: &suspended},
+		},
+		users: map[int]*models.User{
+			member:    {ID: member, Status: models.AccountStatusActive},
+			outsider:  {ID: outsider, Status: models.AccountStatusActive},
+			suspended: {ID: suspended, Status: models.AccountStatusSuspended},
+		},
+	}
+	memberships := memoryMemberships{
+		household: {1: models.OrganizationRoleOwner, member: models.OrganizationRoleMember, suspended: models.OrganizationRoleMember},
+	}
+
+	m := middleware.NewMiddlewareSetup(slog.New(slog.NewTextHandler(io.Discard, nil)), &session.RevocationStore{Cache: emptyCache{}}, nil, credentials, memberships)
+
+	var membership *models.Membership
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		membership, _ = middleware.CurrentOrganization(c)
+		c.Status(http.StatusOK)
+	}
+	router.GET("/bank", m.AuthMiddleware(keys), m.RequireOrganization(), ok)
+	router.GET("/settings", m.AuthMiddleware(keys), m.RequireOrganization(models.OrganizationRoleOwner), ok)
+
+	token := func(userID int, organizationID *int) string {
+		token, err := jwtService.GenerateJWT(&models.User{ID: userID, Email: "test@example.com", ActiveOrganizationID: organizationID})
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	request := func(path string, authorization string, organizationHeader string) int {
+		membership = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", authorization)
+		if organizationHeader != "" {
+			req.Header.Set("X-Organization-ID", organizationHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 1) The active organization comes from the org_id claim
+	assert.Equal(t, http.StatusOK, request("/bank", token(2, &household), ""))
+	require.NotNil(t, membership)
+	assert.Equal(t, household, membership.OrganizationID)
+	assert.Equal(t, models.OrganizationRoleMember, membership.Role)
+
+	// 2) Users without an active organization must name one
+	assert.Equal(t, http.StatusBadRequest, request("/bank", token(2, nil), ""))
+	assert.Equal(t, http.StatusOK, request("/bank", token(2, nil), "7"))
+	assert.Equal(t, http.StatusBadRequest, request("/bank", token(2, nil), "household"))
+
+	// 3) Membership is checked on every request, a stale claim or another organization is refused
+	assert.Equal(t, http.StatusForbidden, request("/bank", token(3, &household), ""))
+	assert.Equal(t, http.StatusForbidden, request("/bank", token(2, &household), "8"))
+	assert.Equal(t, http.StatusForbidden, request("/bank", token(2, &business), ""))
+
+	// 4) Routes can require a role in the organization
+	assert.Equal(t, http.StatusForbidden, request("/settings", token(2, &household), ""))
+	assert.Equal(t, http.StatusOK, request("/settings", token(1, &household), ""))
+
+	// 5) API keys act as members of the organization they are bound to
+	assert.Equal(t, http.StatusOK, request("/bank", "ApiKey ak_household", ""))
+	require.NotNil(t, membership)
+	assert.Equal(t, household, membership.OrganizationID)
+	assert.Equal(t, http.StatusForbidden, request("/bank", "ApiKey ak_household", "8"))
+	assert.Equal(t, http.StatusBadRequest, request("/bank", "ApiKey ak_unbound", ""))
+	assert.Equal(t, http.StatusForbidden, request("/settings", "ApiKey ak_household", ""))
+
+	// 6) Keys lose access when their creator is not a member or cannot sign in
+	assert.Equal(t, http.StatusForbidden, request("/bank", "ApiKey ak_outsider", ""))
+	assert.Equal(t, http.StatusForbidden, request("/bank", "ApiKey ak_suspended", ""))
+
+	// 7) Unauthenticated requests never reach the organization check
+	assert.Equal(t, http.StatusUnauthorized, request("/bank", "", "7"))
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/ratelimit"
+)
+
+// RateLimit limits how often a caller can hit the routes it is attached to. Callers are identified by the
+// authenticated user, then the API key, then the client IP, so it should run after AuthMiddleware where there is one.
+func (m *Middleware) RateLimit(policy ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1) Count the request against the caller
+		decision, err := m.RateLimiter.Allow(context.Background(), rateLimitKey(c), policy)
+		if err != nil {
+			m.Logger.Error("Rate limit store unavailable, counting in memory", "policy", policy.Name, "error", err)
+		}
+
+		// 2) Tell the caller where they stand
+		resetSeconds := strconv.Itoa(int(math.Ceil(decision.Reset.Seconds())))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window/time.Second)))
+		c.Header("RateLimit-Limit", strconv.FormatInt(decision.Limit, 10))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+		c.Header("RateLimit-Reset", resetSeconds)
+
+		// 3) Reject the request if the caller is over the limit
+		if !decision.Allowed {
+			m.Logger.Info("Rate limit exceeded", "policy", policy.Name, "path", c.FullPath())
+			c.Header("Retry-After", resetSeconds)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the caller, API keys are hashed so they are never stored in redis
+func rateLimitKey(c *gin.Context) string {
+	if principal, ok := CurrentPrincipal(c); ok {
+		return principal.Subject()
+	}
+
+	if apiKey, found := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey "); found && apiKey != "" {
+		return fmt.Sprintf("api_key:%s", models.HashAPIKey(apiKey))
+	}
+
+	return fmt.Sprintf("ip:%s", c.ClientIP())
+}
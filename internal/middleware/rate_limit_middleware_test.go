@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// A nil cache makes the limiter count in memory
+	limiter := ratelimit.NewLimiter(nil)
+	limiter.Now = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC) }
+	m := middleware.NewMiddlewareSetup(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, limiter, nil, nil)
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Window: time.Minute}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("X-Test-User") != "" {
+			c.Set("user", &models.User{ID: 1})
+		}
+	})
+	router.GET("/limited", m.RateLimit(policy), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1) Allowed requests carry the rate limit headers
+	w := request(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+	// 2) Going over the limit is rejected with Retry-After
+	request(nil)
+	w = request(nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, w.Header().Get("RateLimit-Reset"), w.Header().Get("Retry-After"))
+
+	// 3) Authenticated users and API keys get their own budgets even from the same IP
+	assert.Equal(t, http.StatusOK, request(map[string]string{"X-Test-User": "1"}).Code)
+	assert.Equal(t, http.StatusOK, request(map[string]string{"Authorization": "ApiKey secret"}).Code)
+	assert.Equal(t, http.StatusOK, request(map[string]string{"Authorization": "ApiKey other"}).Code)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jalil32/go-auth-module/internal/models"
+)
+
+// RequireRole only lets users with at least one of the roles through, it must run after AuthMiddleware.
+// Services never hold roles, so routes guarded by a role are closed to API keys.
+func (m *Middleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return m.requireAny("role", roles, (*models.Principal).Roles)
+}
+
+// RequirePermission only lets callers with at least one of the permissions through, it must run after AuthMiddleware.
+// API keys are checked against their scopes.
+func (m *Middleware) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return m.requireAny("permission", permissions, (*models.Principal).Permissions)
+}
+
+// requireAny checks the caller in the context holds one of the required values
+func (m *Middleware) requireAny(kind string, required []string, held func(principal *models.Principal) []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1) Get the caller set by AuthMiddleware
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			m.Logger.Error("Principal missing from context", "required "+kind, required)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		// 2) Check the caller holds one of the required values
+		for _, value := range held(principal) {
+			if slices.Contains(required, value) {
+				c.Next()
+				return
+			}
+		}
+
+		m.Logger.Info("Access denied", "principal", principal.Subject(), "required "+kind, required, "path", c.FullPath())
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,84 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/controllers/auth"
+	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/models"
+	"github.com/jalil32/go-auth-module/internal/session"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// emptyCache is a session cache with nothing revoked.
+type emptyCache struct{}
+
+func (emptyCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (emptyCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return redis.NewStatusResult("OK", nil)
+}
+
+// staticRoles grants fixed roles and permissions per user ID.
+type staticRoles map[int][2][]string
+
+func (s staticRoles) FindRolesByUserID(userID int) ([]string, error) {
+	return s[userID][0], nil
+}
+
+func (s staticRoles) FindPermissionsByUserID(userID int) ([]string, error) {
+	return s[userID][1], nil
+}
+
+func TestRequireRoleAndPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := signing.LoadKeyManager(config.JWTConfig{Token: "test-secret"})
+	require.NoError(t, err)
+
+	jwtService := &auth.JWTService{Keys: keys, JwtExpiry: "1m", Roles: staticRoles{
+		1: {{"admin", "member"}, {"bank:upload", "roles:manage"}},
+		2: {{"member"}, {"bank:upload"}},
+		3: {{}, {}},
+	}}
+
+	m := middleware.NewMiddlewareSetup(slog.New(slog.NewTextHandler(io.Discard, nil)), &session.RevocationStore{Cache: emptyCache{}}, nil, nil, nil)
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/admin", m.AuthMiddleware(keys), m.RequireRole("admin"), ok)
+	router.GET("/upload", m.AuthMiddleware(keys), m.RequirePermission("bank:upload"), ok)
+	router.GET("/unauthenticated", m.RequireRole("admin"), ok)
+
+	request := func(path string, userID int) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if userID != 0 {
+			token, err := jwtService.GenerateJWT(&models.User{ID: userID, Email: "test@example.com"})
+			require.NoError(t, err)
+			req.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("/admin", 1))
+	assert.Equal(t, http.StatusForbidden, request("/admin", 2))
+	assert.Equal(t, http.StatusOK, request("/upload", 2))
+	assert.Equal(t, http.StatusForbidden, request("/upload", 3))
+	assert.Equal(t, http.StatusUnauthorized, request("/unauthenticated", 0))
+}
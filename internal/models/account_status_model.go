@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AccountStatus is where a user is in the account lifecycle
+type AccountStatus string
+
+const (
+	AccountStatusPendingVerification AccountStatus = "pending_verification" // Registered but the email is not verified yet
+	AccountStatusActive              AccountStatus = "active"               // Can sign in
+	AccountStatusSuspended           AccountStatus = "suspended"            // Blocked by an admin, can be reactivated
+	AccountStatusDeleted             AccountStatus = "deleted"              // Closed for good, kept for the record
+)
+
+// accountStatusTransitions lists the statuses each status can move to
+var accountStatusTransitions = map[AccountStatus][]AccountStatus{
+	AccountStatusPendingVerification: {AccountStatusActive, AccountStatusSuspended, AccountStatusDeleted},
+	AccountStatusActive:              {AccountStatusSuspended, AccountStatusDeleted},
+	AccountStatusSuspended:           {AccountStatusActive, AccountStatusPendingVerification, AccountStatusDeleted},
+}
+
+// Valid reports whether the status is one of the known statuses
+func (s AccountStatus) Valid() bool {
+	switch s {
+	case AccountStatusPendingVerification, AccountStatusActive, AccountStatusSuspended, AccountStatusDeleted:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an account can move from this status to next
+func (s AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	for _, allowed := range accountStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSignIn reports whether tokens may be issued to an account with this status
+func (s AccountStatus) CanSignIn() bool {
+	return s == AccountStatusActive
+}
+
+// AccountStatusChange is one entry in a user's status history
+type AccountStatusChange struct {
+	ID         int            `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"userId"`
+	FromStatus *AccountStatus `db:"from_status" json:"fromStatus"`
+	ToStatus   AccountStatus  `db:"to_status" json:"toStatus"`
+	Reason     string         `db:"reason" json:"reason"`
+	ChangedBy  *int           `db:"changed_by" json:"changedBy"` // Admin who made the change, nil if the system did
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt"`
+}
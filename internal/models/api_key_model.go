@@ -0,0 +1,39 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKey lets a service call the API without a user, it is sent as "Authorization: ApiKey <key>".
+// A key bound to an organization acts in it as a member.
+type APIKey struct {
+	ID             int            `db:"id" json:"id"`
+	Name           string         `db:"name" json:"name"`
+	Prefix         string         `db:"prefix" json:"prefix"`
+	KeyHash        string         `db:"key_hash" json:"-"`
+	Scopes         pq.StringArray `db:"scopes" json:"scopes"`
+	OrganizationID *int           `db:"organization_id" json:"organizationId"`
+	ExpiresAt      *time.Time     `db:"expires_at" json:"expiresAt"`
+	LastUsedAt     *time.Time     `db:"last_used_at" json:"lastUsedAt"`
+	CreatedBy      *int           `db:"created_by" json:"createdBy"`
+	RevokedAt      *time.Time     `db:"revoked_at" json:"revokedAt"`
+	CreatedAt      time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// Usable reports whether the key can still authenticate requests
+func (k *APIKey) Usable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HashAPIKey hashes an API key for storage and lookup, keys are random so a fast hash is enough
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// AuthEventType is the kind of security event recorded in the audit log
+type AuthEventType string
+
+const (
+	AuthEventRegister               AuthEventType = "register"
+	AuthEventLogin                  AuthEventType = "login"
+	AuthEventLogout                 AuthEventType = "logout"
+	AuthEventLogoutAll              AuthEventType = "logout_all"
+	AuthEventOTPSent                AuthEventType = "otp_sent"
+	AuthEventOTPVerified            AuthEventType = "otp_verified"
+	AuthEventPasswordResetRequested AuthEventType = "password_reset_requested"
+	AuthEventPasswordResetCompleted AuthEventType = "password_reset_completed"
+	AuthEventPasswordChanged        AuthEventType = "password_changed"
+	AuthEventEmailChangeRequested   AuthEventType = "email_change_requested"
+	AuthEventEmailChanged           AuthEventType = "email_changed"
+	AuthEventEmailChangeReverted    AuthEventType = "email_change_reverted"
+	AuthEventOAuthLogin             AuthEventType = "oauth_login"
+	AuthEventIdentityLinked         AuthEventType = "identity_linked"
+	AuthEventIdentityUnlinked       AuthEventType = "identity_unlinked"
+	AuthEventTokenCreated           AuthEventType = "token_created"
+	AuthEventTokenRevoked           AuthEventType = "token_revoked"
+	AuthEventMembershipChanged      AuthEventType = "membership_changed"
+	AuthEventAdminAction            AuthEventType = "admin_action"
+)
+
+// AuthEventOutcome is whether the event succeeded
+type AuthEventOutcome string
+
+const (
+	AuthEventSuccess AuthEventOutcome = "success"
+	AuthEventFailure AuthEventOutcome = "failure"
+)
+
+type AuthEvent struct {
+	ID           int64            `db:"id" json:"id"`
+	UserID       *int             `db:"user_id" json:"userId"`
+	TargetUserID *int             `db:"target_user_id" json:"targetUserId,omitempty"`
+	Email        string           `db:"email" json:"email"`
+	Type         AuthEventType    `db:"event_type" json:"type"`
+	Outcome      AuthEventOutcome `db:"outcome" json:"outcome"`
+	IP           string           `db:"ip" json:"ip"`
+	UserAgent    string           `db:"user_agent" json:"userAgent"`
+	Detail       string           `db:"detail" json:"detail"`
+	CreatedAt    time.Time        `db:"created_at" json:"createdAt"`
+}
+
+// AuthEventFilter narrows down an audit log query, nil and empty fields are not filtered on
+type AuthEventFilter struct {
+	UserID        *int
+	Email         string
+	Type          *AuthEventType
+	Outcome       *AuthEventOutcome
+	IP            string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Limit         int
+	Offset        int
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CSVProfile describes how a bank lays out its CSV statements, users save one per bank they import from.
+// Columns are matched by their header, amounts come from one signed column or separate debit and credit columns.
+type CSVProfile struct {
+	ID                int            `db:"id" json:"id"`
+	UserID            int            `db:"user_id" json:"-"`
+	Name              string         `db:"name" json:"name"`
+	Delimiter         string         `db:"delimiter" json:"delimiter"`      // Empty detects it from the file
+	Encoding          string         `db:"encoding" json:"encoding"`        // Empty detects it from the BOM and content
+	HeaderRow         int            `db:"header_row" json:"headerRow"`     // Line of the header, rows above it are skipped
+	DateColumn        string         `db:"date_column" json:"dateColumn"`   // Header of the booking date column
+	DateFormats       pq.StringArray `db:"date_formats" json:"dateFormats"` // Tried in order, e.g. DD/MM/YYYY or YYYY-MM-DD
+	DescriptionColumn string         `db:"description_column" json:"descriptionColumn"`
+	AmountColumn      string         `db:"amount_column" json:"amountColumn"`         // Signed amounts, empty when debit and credit are split
+	DebitColumn       string         `db:"debit_column" json:"debitColumn"`           // Money out, stored as negative amounts
+	CreditColumn      string         `db:"credit_column" json:"creditColumn"`         // Money in
+	DecimalSeparator  string         `db:"decimal_separator" json:"decimalSeparator"` // "." or ",", the other one separates thousands
+	CreatedAt         time.Time      `db:"created_at" json:"createdAt"`
+}
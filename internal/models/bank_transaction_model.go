@@ -3,9 +3,16 @@ package models
 import "time"
 
 type Transaction struct {
-	TransactionId int       // Primary key: Auto-incremented in the database
-	UserId        int       // Foreign key to the user who made the transaction
-	Date          time.Time // Date of transaction
-	AmountCents   int       // Transaction amount in cents
-	Description   string    // Transaction description: Default is "No description"
+	TransactionId  int        `db:"transaction_id"`  // Primary key: Auto-incremented in the database
+	UserId         int        `db:"user_id"`         // Foreign key to the user who uploaded the transaction, only they can see it
+	OrganizationId int        `db:"organization_id"` // Foreign key to the organization the transaction belongs to
+	Date           time.Time  `db:"date"`            // Date of transaction, the booking date when the statement has a value date as well
+	ValueDate      *time.Time `db:"value_date"`      // Date the money is credited or debited, nil when the statement does not say
+	AmountCents    int        `db:"amount_cents"`    // Transaction amount in cents
+	Currency       string     `db:"currency"`        // ISO 4217 code, empty when the statement does not say
+	Description    string     `db:"description"`     // Transaction description: Default is "No description"
+	Counterparty   string     `db:"counterparty"`    // Who paid or was paid, e.g. the payee or the debtor of a transfer
+	RemittanceInfo string     `db:"remittance_info"` // What the payment was for, e.g. an invoice number
+	Account        string     `db:"account"`         // Bank account the statement is for, when the file names it
+	FitId          string     `db:"fit_id"`          // Bank's ID for the transaction (OFX FITID), re-imports skip IDs already stored for the account
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// OAuthClient is an application that signs its users in through our OpenID Connect provider
+type OAuthClient struct {
+	ID               int            `db:"id" json:"id"`
+	ClientID         string         `db:"client_id" json:"clientId"`
+	ClientSecretHash *string        `db:"client_secret_hash" json:"-"`
+	Name             string         `db:"name" json:"name"`
+	RedirectURIs     pq.StringArray `db:"redirect_uris" json:"redirectUris"`
+	CreatedBy        *int           `db:"created_by" json:"createdBy"`
+	CreatedAt        time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// Public reports whether the client cannot keep a secret, like a single page or mobile app
+func (c *OAuthClient) Public() bool {
+	return c.ClientSecretHash == nil
+}
+
+// AllowsRedirect reports whether the redirect URI was registered, URIs must match exactly
+func (c *OAuthClient) AllowsRedirect(redirectURI string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySecret compares a client secret with the stored hash in constant time
+func (c *OAuthClient) VerifySecret(secret string) bool {
+	if c.ClientSecretHash == nil || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashClientSecret(secret)), []byte(*c.ClientSecretHash)) == 1
+}
+
+// HashClientSecret hashes a client secret for storage, secrets are random so a fast hash is enough
+func HashClientSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
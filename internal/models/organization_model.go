@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// OrganizationRole is what a member may do in an organization
+type OrganizationRole string
+
+const (
+	OrganizationRoleOwner  OrganizationRole = "owner"  // Manages members and roles, an organization always keeps one
+	OrganizationRoleAdmin  OrganizationRole = "admin"  // Invites and removes members
+	OrganizationRoleMember OrganizationRole = "member" // Works with the organization's data
+)
+
+// Valid reports whether the role is one of the known roles
+func (r OrganizationRole) Valid() bool {
+	switch r {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanManageMembers reports whether the role may invite and remove members
+func (r OrganizationRole) CanManageMembers() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// CanManageData reports whether the role may change data other members added, such as their bank transactions
+func (r OrganizationRole) CanManageData() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// PersonalOrganizationName names the organization every user gets at sign up, it holds their data until they join another
+const PersonalOrganizationName = "Personal"
+
+// Organization groups users that share data, e.g. a household sharing bank statements
+type Organization struct {
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedBy *int      `db:"created_by" json:"createdBy"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Membership gives a user a role in an organization
+type Membership struct {
+	ID             int              `db:"id" json:"id"`
+	OrganizationID int              `db:"organization_id" json:"organizationId"`
+	UserID         int              `db:"user_id" json:"userId"`
+	Role           OrganizationRole `db:"role" json:"role"`
+	CreatedAt      time.Time        `db:"created_at" json:"createdAt"`
+}
+
+// UserOrganization is an organization the user belongs to, with their role in it
+type UserOrganization struct {
+	Organization
+	Role OrganizationRole `db:"role" json:"role"`
+}
+
+// OrganizationMember is a member as listed to the rest of the organization
+type OrganizationMember struct {
+	UserID    int              `db:"user_id" json:"userId"`
+	Email     string           `db:"email" json:"email"`
+	FirstName string           `db:"first_name" json:"firstName"`
+	LastName  string           `db:"last_name" json:"lastName"`
+	Role      OrganizationRole `db:"role" json:"role"`
+	CreatedAt time.Time        `db:"created_at" json:"joinedAt"`
+}
+
+// OrganizationInvitation is stored with the emailed token until the invitee accepts it
+type OrganizationInvitation struct {
+	OrganizationID   int              `json:"organizationId"`
+	OrganizationName string           `json:"organizationName"`
+	Email            string           `json:"email"`
+	Role             OrganizationRole `json:"role"`
+	InvitedBy        int              `json:"invitedBy"`
+}
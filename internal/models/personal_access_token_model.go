@@ -0,0 +1,37 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, it tells them apart from session tokens
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken lets a user call the API from scripts, it is sent as "Authorization: Bearer <token>"
+type PersonalAccessToken struct {
+	ID         int            `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"userId"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"prefix" json:"prefix"`
+	TokenHash  string         `db:"token_hash" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expiresAt"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"lastUsedAt"`
+	LastUsedIP *string        `db:"last_used_ip" json:"lastUsedIp"`
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// Usable reports whether the token has not expired yet, deleted tokens are not found at all
+func (t *PersonalAccessToken) Usable(now time.Time) bool {
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// HashPersonalAccessToken hashes a token for storage and lookup, tokens are random so a fast hash is enough
+func HashPersonalAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
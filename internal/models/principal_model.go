@@ -0,0 +1,63 @@
+package models
+
+import "fmt"
+
+// PrincipalType says who is calling the API
+type PrincipalType string
+
+const (
+	PrincipalUser    PrincipalType = "user"    // A person signed in with a session token
+	PrincipalService PrincipalType = "service" // A service authenticated with an API key
+)
+
+// Principal is the authenticated caller, set in the context by the auth middleware.
+// Token is set when a user calls with a personal access token, their permissions are then limited to its scopes.
+type Principal struct {
+	Type   PrincipalType        `json:"type"`
+	User   *User                `json:"user,omitempty"`
+	APIKey *APIKey              `json:"apiKey,omitempty"`
+	Token  *PersonalAccessToken `json:"token,omitempty"`
+}
+
+// Roles returns the caller's roles, services never hold roles
+func (p *Principal) Roles() []string {
+	if p.Type == PrincipalUser && p.User != nil {
+		return p.User.Roles
+	}
+	return nil
+}
+
+// Permissions returns the user's permissions or the API key's scopes
+func (p *Principal) Permissions() []string {
+	switch {
+	case p.Type == PrincipalUser && p.User != nil:
+		return p.User.Permissions
+	case p.Type == PrincipalService && p.APIKey != nil:
+		return p.APIKey.Scopes
+	default:
+		return nil
+	}
+}
+
+// OrganizationID returns the user's active organization or the organization the API key is bound to
+func (p *Principal) OrganizationID() *int {
+	switch {
+	case p.Type == PrincipalUser && p.User != nil:
+		return p.User.ActiveOrganizationID
+	case p.Type == PrincipalService && p.APIKey != nil:
+		return p.APIKey.OrganizationID
+	default:
+		return nil
+	}
+}
+
+// Subject identifies the caller in logs and rate limits, e.g. "user:1" or "api_key:3"
+func (p *Principal) Subject() string {
+	if p.Type == PrincipalService && p.APIKey != nil {
+		return fmt.Sprintf("api_key:%d", p.APIKey.ID)
+	}
+	if p.User != nil {
+		return fmt.Sprintf("user:%d", p.User.ID)
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Role struct {
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Permissions []string  `db:"-" json:"permissions"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserFilter narrows down a user listing, nil fields are not filtered on
+type UserFilter struct {
+	Verified *bool
+	Status   *AccountStatus
+	// Provider is the OAuth provider name, or "password" for users without one
+	Provider      *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Search matches part of the email, first name or last name
+	Search string
+	Limit  int
+	Offset int
+}
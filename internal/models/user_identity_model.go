@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdentityProviderPassword is the provider of the identity held by users who sign in with a password
+const IdentityProviderPassword = "password"
+
+// UserIdentity is one way a user can sign in, a user can hold one identity per provider
+type UserIdentity struct {
+	ID             int        `db:"id" json:"id"`
+	UserID         int        `db:"user_id" json:"userId"`
+	Provider       string     `db:"provider" json:"provider"`
+	ProviderUserID *string    `db:"provider_user_id" json:"-"`
+	Email          *string    `db:"email" json:"email"`
+	CreatedAt      time.Time  `db:"created_at" json:"createdAt"`
+	LastUsedAt     *time.Time `db:"last_used_at" json:"lastUsedAt"`
+}
@@ -3,14 +3,21 @@ package models
 import "time"
 
 type User struct {
-	ID           int       `db:"id" json:"id"`
-	Email        string    `db:"email" json:"email"`
-	PasswordHash *string   `db:"password_hash" json:"passwordHash"`
-	FirstName    string    `db:"first_name" json:"firstName"`
-	LastName     string    `db:"last_name" json:"lastName"`
-	Provider     *string   `db:"provider" json:"provider"`
-	IsActive     string    `db:"is_active" json:"isActive"`
-	Verified     bool      `db:"verified" json:"verified"`
-	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+	ID                   int           `db:"id" json:"id"`
+	Email                string        `db:"email" json:"email"`
+	PasswordHash         *string       `db:"password_hash" json:"-"`
+	FirstName            string        `db:"first_name" json:"firstName"`
+	LastName             string        `db:"last_name" json:"lastName"`
+	Provider             *string       `db:"provider" json:"provider"`
+	Status               AccountStatus `db:"status" json:"status"`
+	StatusReason         *string       `db:"status_reason" json:"statusReason"`
+	StatusChangedAt      *time.Time    `db:"status_changed_at" json:"statusChangedAt"`
+	Verified             bool          `db:"verified" json:"verified"`
+	TOTPSecret           *string       `db:"totp_secret" json:"-"`
+	TOTPEnabled          bool          `db:"totp_enabled" json:"totpEnabled"`
+	ActiveOrganizationID *int          `db:"active_organization_id" json:"activeOrganizationId"`
+	Roles                []string      `db:"-" json:"roles,omitempty"`
+	Permissions          []string      `db:"-" json:"permissions,omitempty"`
+	CreatedAt            time.Time     `db:"created_at" json:"createdAt"`
+	UpdatedAt            time.Time     `db:"updated_at" json:"updatedAt"`
 }
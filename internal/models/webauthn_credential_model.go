@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type WebAuthnCredential struct {
+	ID              int        `db:"id" json:"id"`
+	UserID          int        `db:"user_id" json:"userId"`
+	Name            string     `db:"name" json:"name"`
+	CredentialID    []byte     `db:"credential_id" json:"credentialId"`
+	PublicKey       []byte     `db:"public_key" json:"-"`
+	AttestationType string     `db:"attestation_type" json:"attestationType"`
+	Transports      string     `db:"transports" json:"transports"`
+	AAGUID          []byte     `db:"aaguid" json:"aaguid"`
+	SignCount       int64      `db:"sign_count" json:"-"`
+	UserPresent     bool       `db:"user_present" json:"-"`
+	UserVerified    bool       `db:"user_verified" json:"-"`
+	BackupEligible  bool       `db:"backup_eligible" json:"backupEligible"`
+	BackupState     bool       `db:"backup_state" json:"backupState"`
+	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
+	LastUsedAt      *time.Time `db:"last_used_at" json:"lastUsedAt"`
+}
@@ -0,0 +1,194 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/openidConnect"
+
+	"github.com/jalil32/go-auth-module/config"
+)
+
+// Provider types that can be configured with OAUTH_<NAME>_TYPE
+const (
+	TypeGoogle    = "google"
+	TypeGitHub    = "github"
+	TypeMicrosoft = "microsoft"
+	TypeGitLab    = "gitlab"
+	TypeOIDC      = "oidc"
+)
+
+// providerName is used in URLs, so names are limited to lower case letters, digits and dashes
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// reservedNames clash with other routes under /api/auth
+var reservedNames = map[string]bool{
+	"providers": true, "identities": true, "me": true, "mfa": true, "webauthn": true, "magic-link": true, "password": true,
+}
+
+// Provider is an enabled sign in provider, listed for the frontend to draw its buttons
+type Provider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Type        string `json:"type"`
+}
+
+// providerType describes how to build a provider and whether it verifies the emails it reports
+type providerType struct {
+	displayName   string
+	build         func(cfg config.OAuthProviderConfig) (goth.Provider, error)
+	emailVerified func(user goth.User) bool
+}
+
+var providerTypes = map[string]providerType{
+	TypeGoogle: {
+		displayName: "Google",
+		build: func(cfg config.OAuthProviderConfig) (goth.Provider, error) {
+			return google.New(cfg.ClientID, cfg.ClientSecret, cfg.CallbackURL, scopes(cfg, "email", "profile")...), nil
+		},
+		emailVerified: claimVerified("verified_email", "email_verified"),
+	},
+	TypeGitHub: {
+		displayName: "GitHub",
+		build: func(cfg config.OAuthProviderConfig) (goth.Provider, error) {
+			return github.New(cfg.ClientID, cfg.ClientSecret, cfg.CallbackURL, scopes(cfg, "read:user", "user:email")...), nil
+		},
+		// GitHub only shows verified addresses on profiles, and goth falls back to the verified primary address
+		emailVerified: func(user goth.User) bool { return user.Email != "" },
+	},
+	TypeMicrosoft: {
+		displayName: "Microsoft",
+		build: func(cfg config.OAuthProviderConfig) (goth.Provider, error) {
+			return microsoftonline.New(cfg.ClientID, cfg.ClientSecret, cfg.CallbackURL, cfg.Scopes...), nil
+		},
+		// Entra ID tenants can set any email on their users, so accounts are only ever linked explicitly
+		emailVerified: func(user goth.User) bool { return false },
+	},
+	TypeGitLab: {
+		displayName: "GitLab",
+		build: func(cfg config.OAuthProviderConfig) (goth.Provider, error) {
+			return gitlab.New(cfg.ClientID, cfg.ClientSecret, cfg.CallbackURL, scopes(cfg, "read_user")...), nil
+		},
+		emailVerified: func(user goth.User) bool {
+			confirmedAt, _ := user.RawData["confirmed_at"].(string)
+			return confirmedAt != ""
+		},
+	},
+	TypeOIDC: {
+		build: func(cfg config.OAuthProviderConfig) (goth.Provider, error) {
+			if cfg.DiscoveryURL == "" {
+				return nil, errors.New("discovery URL is required")
+			}
+			return openidConnect.New(cfg.ClientID, cfg.ClientSecret, cfg.CallbackURL, cfg.DiscoveryURL, scopes(cfg, "email", "profile")...)
+		},
+		emailVerified: claimVerified("email_verified"),
+	},
+}
+
+// Registry holds the sign in providers enabled in configuration
+type Registry struct {
+	providers []Provider
+	types     map[string]providerType
+	goth      []goth.Provider
+}
+
+// NewRegistry builds every configured provider, OpenID Connect providers fetch their discovery document here
+func NewRegistry(cfg config.OAuthConfig) (*Registry, error) {
+	registry := &Registry{types: map[string]providerType{}}
+
+	for _, providerCfg := range cfg.Providers {
+		// 1) Validate the provider settings
+		if !providerName.MatchString(providerCfg.Name) || reservedNames[providerCfg.Name] {
+			return nil, fmt.Errorf("invalid OAuth provider name %q", providerCfg.Name)
+		}
+		if _, exists := registry.types[providerCfg.Name]; exists {
+			return nil, fmt.Errorf("OAuth provider %q is configured twice", providerCfg.Name)
+		}
+
+		pt, ok := providerTypes[providerCfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("OAuth provider %q has unknown type %q", providerCfg.Name, providerCfg.Type)
+		}
+
+		if providerCfg.ClientID == "" || providerCfg.ClientSecret == "" || providerCfg.CallbackURL == "" {
+			return nil, fmt.Errorf("OAuth provider %q needs a client ID, client secret and callback URL", providerCfg.Name)
+		}
+
+		// 2) Build the goth provider, named so gothic finds it by the name in the URL
+		provider, err := pt.build(providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure OAuth provider %q: %w", providerCfg.Name, err)
+		}
+		provider.SetName(providerCfg.Name)
+
+		displayName := providerCfg.DisplayName
+		if displayName == "" {
+			displayName = pt.displayName
+		}
+		if displayName == "" {
+			displayName = providerCfg.Name
+		}
+
+		registry.providers = append(registry.providers, Provider{Name: providerCfg.Name, DisplayName: displayName, Type: providerCfg.Type})
+		registry.types[providerCfg.Name] = pt
+		registry.goth = append(registry.goth, provider)
+	}
+
+	return registry, nil
+}
+
+// Use registers the providers with goth so gothic can begin and complete their flows
+func (r *Registry) Use() {
+	goth.UseProviders(r.goth...)
+}
+
+// Providers returns the enabled providers in the order they were configured
+func (r *Registry) Providers() []Provider {
+	providers := make([]Provider, len(r.providers))
+	copy(providers, r.providers)
+	return providers
+}
+
+// Enabled reports whether users can sign in with the named provider
+func (r *Registry) Enabled(name string) bool {
+	_, ok := r.types[name]
+	return ok
+}
+
+// EmailVerified reports whether the provider has verified that the user owns the email it reported
+func (r *Registry) EmailVerified(user goth.User) bool {
+	pt, ok := r.types[user.Provider]
+	if !ok || user.Email == "" {
+		return false
+	}
+	return pt.emailVerified(user)
+}
+
+// scopes returns the configured scopes, or the defaults when none are configured
+func scopes(cfg config.OAuthProviderConfig, defaults ...string) []string {
+	if len(cfg.Scopes) > 0 {
+		return cfg.Scopes
+	}
+	return defaults
+}
+
+// claimVerified checks boolean email verification claims, some providers send them as strings
+func claimVerified(claims ...string) func(user goth.User) bool {
+	return func(user goth.User) bool {
+		for _, claim := range claims {
+			switch verified := user.RawData[claim].(type) {
+			case bool:
+				return verified
+			case string:
+				return verified == "true"
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/oauth"
+)
+
+func provider(name string, providerType string) config.OAuthProviderConfig {
+	return config.OAuthProviderConfig{
+		Name:         name,
+		Type:         providerType,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		CallbackURL:  "http://localhost:3000/api/auth/" + name + "/callback",
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	// 1) An OpenID Connect provider is set up from its discovery document
+	discovery := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://sso.example.com",
+			"authorization_endpoint": "https://sso.example.com/authorize",
+			"token_endpoint":         "https://sso.example.com/token",
+			"userinfo_endpoint":      "https://sso.example.com/userinfo",
+		})
+	}))
+	defer discovery.Close()
+
+	okta := provider("okta", oauth.TypeOIDC)
+	okta.DisplayName = "Company SSO"
+	okta.DiscoveryURL = discovery.URL
+
+	registry, err := oauth.NewRegistry(config.OAuthConfig{Providers: []config.OAuthProviderConfig{
+		provider("google", oauth.TypeGoogle),
+		provider("github", oauth.TypeGitHub),
+		provider("microsoft", oauth.TypeMicrosoft),
+		provider("gitlab", oauth.TypeGitLab),
+		okta,
+	}})
+	require.NoError(t, err)
+
+	// 2) Providers are listed in the configured order with their display names
+	assert.Equal(t, []oauth.Provider{
+		{Name: "google", DisplayName: "Google", Type: oauth.TypeGoogle},
+		{Name: "github", DisplayName: "GitHub", Type: oauth.TypeGitHub},
+		{Name: "microsoft", DisplayName: "Microsoft", Type: oauth.TypeMicrosoft},
+		{Name: "gitlab", DisplayName: "GitLab", Type: oauth.TypeGitLab},
+		{Name: "okta", DisplayName: "Company SSO", Type: oauth.TypeOIDC},
+	}, registry.Providers())
+
+	assert.True(t, registry.Enabled("okta"))
+	assert.False(t, registry.Enabled("facebook"))
+
+	// 3) Goth finds the providers by their configured name
+	registry.Use()
+	gothProvider, err := goth.GetProvider("okta")
+	require.NoError(t, err)
+	assert.Equal(t, "okta", gothProvider.Name())
+}
+
+func TestNewRegistry_InvalidConfig(t *testing.T) {
+	noSecret := provider("google", oauth.TypeGoogle)
+	noSecret.ClientSecret = ""
+
+	for name, providers := range map[string][]config.OAuthProviderConfig{
+		"unknown type":          {provider("facebook", "facebook")},
+		"missing secret":        {noSecret},
+		"missing discovery":     {provider("okta", oauth.TypeOIDC)},
+		"duplicate name":        {provider("google", oauth.TypeGoogle), provider("google", oauth.TypeGoogle)},
+		"reserved name":         {provider("providers", oauth.TypeGoogle)},
+		"name not url friendly": {provider("My SSO", oauth.TypeOIDC)},
+	} {
+		_, err := oauth.NewRegistry(config.OAuthConfig{Providers: providers})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRegistry_EmailVerified(t *testing.T) {
+	registry, err := oauth.NewRegistry(config.OAuthConfig{Providers: []config.OAuthProviderConfig{
+		provider("google", oauth.TypeGoogle),
+		provider("github", oauth.TypeGitHub),
+		provider("microsoft", oauth.TypeMicrosoft),
+		provider("gitlab", oauth.TypeGitLab),
+	}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		user     goth.User
+		verified bool
+	}{
+		{"google verified", goth.User{Provider: "google", Email: "a@example.com", RawData: map[string]interface{}{"verified_email": true}}, true},
+		{"google unverified", goth.User{Provider: "google", Email: "a@example.com", RawData: map[string]interface{}{"verified_email": false}}, false},
+		{"github", goth.User{Provider: "github", Email: "a@example.com"}, true},
+		{"github without email", goth.User{Provider: "github"}, false},
+		{"microsoft is never trusted", goth.User{Provider: "microsoft", Email: "a@example.com", RawData: map[string]interface{}{"email_verified": true}}, false},
+		{"gitlab confirmed", goth.User{Provider: "gitlab", Email: "a@example.com", RawData: map[string]interface{}{"confirmed_at": "2026-01-01T00:00:00Z"}}, true},
+		{"gitlab unconfirmed", goth.User{Provider: "gitlab", Email: "a@example.com", RawData: map[string]interface{}{}}, false},
+		{"unknown provider", goth.User{Provider: "facebook", Email: "a@example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.verified, registry.EmailVerified(tt.user))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is the subset of the redis client needed to count requests
+type Cache interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+}
+
+// Policy is the number of requests a caller may make within a window
+type Policy struct {
+	// Name keeps the counters of different route groups apart
+	Name   string
+	Limit  int64
+	Window time.Duration
+}
+
+// Decision is the outcome of counting a request against a policy
+type Decision struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// Reset is how long until the current window ends
+	Reset time.Duration
+}
+
+// Limiter counts requests with a sliding window. The current and previous fixed windows are kept in redis
+// and the previous window is weighted by how much of it still overlaps the sliding window.
+type Limiter struct {
+	Cache    Cache
+	fallback *memoryCounter
+	// Now returns the current time, it can be replaced in tests
+	Now func() time.Time
+}
+
+// NewLimiter initializes a Limiter that counts in redis and falls back to process memory when redis is unavailable
+func NewLimiter(cache Cache) *Limiter {
+	return &Limiter{
+		Cache:    cache,
+		fallback: newMemoryCounter(),
+		Now:      time.Now,
+	}
+}
+
+func windowKey(policy Policy, key string, windowStart time.Time) string {
+	return fmt.Sprintf("rate_limit:%s:%s:%d", policy.Name, key, windowStart.Unix())
+}
+
+// Allow counts a request for the key and decides whether it is within the policy.
+// If redis fails the request is counted in memory instead and the redis error is returned alongside the decision.
+func (l *Limiter) Allow(ctx context.Context, key string, policy Policy) (Decision, error) {
+	now := l.Now()
+	windowStart := now.Truncate(policy.Window)
+	currentKey := windowKey(policy, key, windowStart)
+	previousKey := windowKey(policy, key, windowStart.Add(-policy.Window))
+
+	current, previous, err := l.countRedis(ctx, currentKey, previousKey, policy.Window)
+	if err != nil {
+		current, previous = l.fallback.count(currentKey, previousKey, now, policy.Window)
+	}
+
+	return decide(policy, now.Sub(windowStart), current, previous), err
+}
+
+// countRedis increments the current window and reads the previous one
+func (l *Limiter) countRedis(ctx context.Context, currentKey string, previousKey string, window time.Duration) (int64, int64, error) {
+	if l.Cache == nil {
+		return 0, 0, errors.New("no redis client configured")
+	}
+
+	current, err := l.Cache.Incr(ctx, currentKey).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count request: %w", err)
+	}
+
+	// The window is read as the previous one for a full window after it ends
+	if current == 1 {
+		if err := l.Cache.Expire(ctx, currentKey, 2*window).Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to set rate limit expiry: %w", err)
+		}
+	}
+
+	previous, err := l.Cache.Get(ctx, previousKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, fmt.Errorf("failed to get previous window: %w", err)
+	}
+
+	return current, previous, nil
+}
+
+// decide estimates the requests in the sliding window ending now
+func decide(policy Policy, elapsed time.Duration, current int64, previous int64) Decision {
+	overlap := 1 - float64(elapsed)/float64(policy.Window)
+	estimate := int64(math.Ceil(float64(previous)*overlap)) + current
+
+	remaining := policy.Limit - estimate
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Decision{
+		Allowed:   estimate <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: remaining,
+		Reset:     policy.Window - elapsed,
+	}
+}
+
+// memoryCounter keeps per process window counts for when redis is unavailable
+type memoryCounter struct {
+	mu        sync.Mutex
+	counts    map[string]memoryCount
+	lastSweep time.Time
+}
+
+type memoryCount struct {
+	value     int64
+	expiresAt time.Time
+}
+
+func newMemoryCounter() *memoryCounter {
+	return &memoryCounter{counts: map[string]memoryCount{}}
+}
+
+func (m *memoryCounter) count(currentKey string, previousKey string, now time.Time, window time.Duration) (int64, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop expired windows at most once a minute so the map does not grow forever
+	if now.Sub(m.lastSweep) > time.Minute {
+		for key, entry := range m.counts {
+			if now.After(entry.expiresAt) {
+				delete(m.counts, key)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	current := m.counts[currentKey]
+	if current.value == 0 {
+		current.expiresAt = now.Add(2 * window)
+	}
+	current.value++
+	m.counts[currentKey] = current
+
+	return current.value, m.counts[previousKey].value
+}
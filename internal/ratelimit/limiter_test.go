@@ -0,0 +1,114 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/ratelimit"
+)
+
+// memoryCache is a map backed Cache, expirations are ignored.
+type memoryCache map[string]string
+
+func (m memoryCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := m[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (m memoryCache) Incr(ctx context.Context, key string) *redis.IntCmd {
+	count, _ := strconv.ParseInt(m[key], 10, 64)
+	count++
+	m[key] = strconv.FormatInt(count, 10)
+	return redis.NewIntResult(count, nil)
+}
+
+func (m memoryCache) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return redis.NewBoolResult(true, nil)
+}
+
+// brokenCache fails every command, like redis being down.
+type brokenCache struct{}
+
+func (brokenCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	return redis.NewStringResult("", errors.New("connection refused"))
+}
+
+func (brokenCache) Incr(ctx context.Context, key string) *redis.IntCmd {
+	return redis.NewIntResult(0, errors.New("connection refused"))
+}
+
+func (brokenCache) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return redis.NewBoolResult(false, errors.New("connection refused"))
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: "test", Limit: 4, Window: time.Minute}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewLimiter(memoryCache{})
+	limiter.Now = func() time.Time { return now }
+
+	// 1) Requests are allowed up to the limit
+	for i := int64(1); i <= 4; i++ {
+		decision, err := limiter.Allow(ctx, "ip:127.0.0.1", policy)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 4-i, decision.Remaining)
+		assert.Equal(t, time.Minute, decision.Reset)
+	}
+
+	decision, err := limiter.Allow(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	// 2) Other callers and policies are counted separately
+	decision, err = limiter.Allow(ctx, "ip:10.0.0.1", policy)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// 3) Half way through the next window half of the previous window still counts, 5 * 0.5 rounded up is 3
+	now = now.Add(90 * time.Second)
+	decision, err = limiter.Allow(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, int64(0), decision.Remaining)
+	assert.Equal(t, 30*time.Second, decision.Reset)
+
+	decision, err = limiter.Allow(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	// 4) Once the previous window has slid out completely the caller starts fresh
+	now = now.Add(90 * time.Second)
+	decision, err = limiter.Allow(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, int64(3), decision.Remaining)
+}
+
+func TestLimiter_FallsBackToMemory(t *testing.T) {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Window: time.Minute}
+	limiter := ratelimit.NewLimiter(brokenCache{})
+	limiter.Now = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC) }
+
+	for i := 0; i < 2; i++ {
+		decision, err := limiter.Allow(ctx, "user:1", policy)
+		assert.Error(t, err, "the redis error is still reported so it can be logged")
+		assert.True(t, decision.Allowed)
+	}
+
+	decision, _ := limiter.Allow(ctx, "user:1", policy)
+	assert.False(t, decision.Allowed, "the limit is still enforced while redis is down")
+}
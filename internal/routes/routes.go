@@ -2,63 +2,196 @@ package routes
 
 import (
 	"log/slog"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/audit"
+	"github.com/jalil32/go-auth-module/internal/controllers/admin"
 	"github.com/jalil32/go-auth-module/internal/controllers/auth"
 	"github.com/jalil32/go-auth-module/internal/controllers/bank"
+	"github.com/jalil32/go-auth-module/internal/controllers/oidc"
+	"github.com/jalil32/go-auth-module/internal/controllers/org"
 	"github.com/jalil32/go-auth-module/internal/controllers/stock"
+	"github.com/jalil32/go-auth-module/internal/controllers/wellknown"
 	"github.com/jalil32/go-auth-module/internal/db"
 	"github.com/jalil32/go-auth-module/internal/middleware"
+	"github.com/jalil32/go-auth-module/internal/ratelimit"
+	"github.com/jalil32/go-auth-module/internal/session"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// Rate limit policies for each route group, callers are counted per user, API key or IP address
+var (
+	authRateLimit  = ratelimit.Policy{Name: "auth", Limit: 60, Window: time.Minute}
+	stockRateLimit = ratelimit.Policy{Name: "stock", Limit: 30, Window: time.Minute}
+	bankRateLimit  = ratelimit.Policy{Name: "bank", Limit: 10, Window: time.Minute}
+	adminRateLimit = ratelimit.Policy{Name: "admin", Limit: 60, Window: time.Minute}
+	oidcRateLimit  = ratelimit.Policy{Name: "oidc", Limit: 60, Window: time.Minute}
+	orgRateLimit   = ratelimit.Policy{Name: "orgs", Limit: 60, Window: time.Minute}
 )
 
 func Routes(router *gin.Engine, database *sqlx.DB, rdb *redis.Client, logger *slog.Logger, cfg *config.Config) error {
 	// Create user database
 	userDB := &db.UserDB{DB: database}
-	jwtService := &auth.JWTService{SecretKey: cfg.JWT.Token, JwtExpiry: cfg.JWT.Expiry}
+
+	// Load the JWT signing and verification keys
+	keyManager, err := signing.LoadKeyManager(cfg.JWT)
+	if err != nil {
+		logger.Error("Failed to load JWT signing keys", "error", err)
+		return err
+	}
+
+	jwtService := &auth.JWTService{Keys: keyManager, JwtExpiry: cfg.JWT.Expiry, Roles: userDB}
+
+	// Security events are stored in the auth_events table
+	auditLogger := audit.NewLogger(userDB, logger)
 
 	// Initialise Auth Controller instance
-	authController, err := auth.NewAuthController(userDB, rdb, logger, jwtService, cfg)
+	authController, err := auth.NewAuthController(userDB, rdb, logger, jwtService, auditLogger, cfg)
 
 	if err != nil {
 		logger.Error("Failed to initialise AuthController", "error", err)
 		return err
 	}
 
-	middleware := middleware.NewMiddlewareSetup(logger)
+	sessions := session.NewRevocationStore(rdb, cfg.JWT)
+
+	middleware := middleware.NewMiddlewareSetup(logger, sessions, ratelimit.NewLimiter(rdb), userDB, userDB)
 
 	// Initialise Stock Controller instance
 	stockController := stock.NewStockController(logger)
 
 	// Initialise Bank Controller instance
-	bankController := bank.NewBankController(logger, database)
+	bankController := bank.NewBankController(logger, userDB)
+
+	// Initialise Admin Controller instance
+	adminController := admin.NewAdminController(logger, userDB, sessions, authController, auditLogger)
+
+	// Initialise Org Controller instance, invitations are emailed by the auth controller
+	orgController := org.NewOrgController(logger, userDB, authController, authController, auditLogger)
+
+	// Initialise Well Known Controller instance
+	wellKnownController := wellknown.NewWellKnownController(logger, keyManager)
+
+	// Initialise OIDC Controller instance, ID tokens can only be verified by clients when the keys are asymmetric
+	var oidcController *oidc.OIDCController
+	if keyManager.Asymmetric() {
+		oidcController, err = oidc.NewOIDCController(logger, userDB, rdb, keyManager, cfg)
+		if err != nil {
+			logger.Error("Failed to initialise OIDCController", "error", err)
+			return err
+		}
+	} else {
+		logger.Warn("OpenID Connect provider disabled, it needs asymmetric JWT_SIGNING_KEYS")
+	}
 
 	// Register controllers to routes
 	api := router.Group("/api")
 	{
-		auth := api.Group("/auth")
+		auth := api.Group("/auth", middleware.RateLimit(authRateLimit))
 		{
 			auth.POST("/register", authController.Register)
 			auth.POST("/login", authController.Login)
 			auth.POST("/logout", authController.Logout)
+			auth.POST("/refresh", authController.RefreshHandler)
+			auth.POST("/logout-all", middleware.AuthMiddleware(keyManager), authController.LogoutAllHandler)
+			auth.GET("/me/activity", middleware.AuthMiddleware(keyManager), authController.ActivityHandler)
+			auth.POST("/me/password", middleware.AuthMiddleware(keyManager), authController.ChangePasswordHandler)
+			auth.POST("/me/email", middleware.AuthMiddleware(keyManager), authController.ChangeEmailHandler)
+			auth.GET("/me/tokens", middleware.AuthMiddleware(keyManager), authController.ListPersonalAccessTokensHandler)
+			auth.POST("/me/tokens", middleware.AuthMiddleware(keyManager), authController.CreatePersonalAccessTokenHandler)
+			auth.DELETE("/me/tokens/:id", middleware.AuthMiddleware(keyManager), authController.DeletePersonalAccessTokenHandler)
+			auth.GET("/identities", middleware.AuthMiddleware(keyManager), authController.ListIdentitiesHandler)
+			auth.POST("/identities/password", middleware.AuthMiddleware(keyManager), authController.AddPasswordHandler)
+			auth.GET("/identities/:provider/link", middleware.AuthMiddleware(keyManager), authController.LinkProviderHandler)
+			auth.DELETE("/identities/:provider", middleware.AuthMiddleware(keyManager), authController.UnlinkIdentityHandler)
+			auth.GET("/providers", authController.ProvidersHandler)
 			auth.GET("/:provider", authController.SignInWithProvider)
 			auth.GET("/:provider/callback", authController.CallbackHandler)
 			auth.POST("/verify", authController.VerifyOTPHandler)
 			auth.POST("/forgot-password", authController.ForgotPasswordHandler)
 			auth.POST("/reset-password", authController.ResetPasswordHandler)
+			auth.POST("/email/confirm", authController.ConfirmEmailChangeHandler)
+			auth.POST("/email/undo", authController.UndoEmailChangeHandler)
+			auth.POST("/magic-link", authController.MagicLinkHandler)
+			auth.GET("/magic-link/consume", authController.ConsumeMagicLinkHandler)
+
+			mfa := auth.Group("/mfa")
+			{
+				mfa.POST("/verify", authController.VerifyMFAHandler)
+				mfa.POST("/totp/enroll", middleware.AuthMiddleware(keyManager), authController.EnrollTOTPHandler)
+				mfa.POST("/totp/confirm", middleware.AuthMiddleware(keyManager), authController.ConfirmTOTPHandler)
+				mfa.POST("/totp/disable", middleware.AuthMiddleware(keyManager), authController.DisableTOTPHandler)
+			}
+
+			webAuthn := auth.Group("/webauthn")
+			{
+				webAuthn.POST("/login/begin", authController.BeginWebAuthnLoginHandler)
+				webAuthn.POST("/login/finish", authController.FinishWebAuthnLoginHandler)
+				webAuthn.POST("/register/begin", middleware.AuthMiddleware(keyManager), authController.BeginWebAuthnRegistrationHandler)
+				webAuthn.POST("/register/finish", middleware.AuthMiddleware(keyManager), authController.FinishWebAuthnRegistrationHandler)
+				webAuthn.GET("/credentials", middleware.AuthMiddleware(keyManager), authController.ListWebAuthnCredentialsHandler)
+				webAuthn.DELETE("/credentials/:id", middleware.AuthMiddleware(keyManager), authController.DeleteWebAuthnCredentialHandler)
+			}
 		}
 
-		stock := api.Group("/stock")
+		stock := api.Group("/stock", middleware.RateLimit(stockRateLimit))
 		{
 			stock.GET(":symbol", stockController.GetStockQuoteHandler)
 		}
 
-		bank := api.Group("/bank")
+		bank := api.Group("/bank", middleware.AuthMiddleware(keyManager), middleware.RateLimit(bankRateLimit), middleware.RequireOrganization())
+		{
+			bank.POST("/upload", middleware.RequirePermission("bank:upload"), bankController.UploadBankStatement)
+			bank.POST("/upload/preview", middleware.RequirePermission("bank:upload"), bankController.PreviewBankStatementHandler)
+			bank.GET("/profiles", middleware.RequirePermission("bank:read", "bank:upload"), bankController.ListCSVProfilesHandler)
+			bank.POST("/profiles", middleware.RequirePermission("bank:upload"), bankController.CreateCSVProfileHandler)
+			bank.DELETE("/profiles/:id", middleware.RequirePermission("bank:upload"), bankController.DeleteCSVProfileHandler)
+			bank.GET("/transactions", middleware.RequirePermission("bank:read"), bankController.ListTransactionsHandler)
+			bank.GET("/transactions/:id", middleware.RequirePermission("bank:read"), bankController.GetTransactionHandler)
+			bank.DELETE("/transactions/:id", middleware.RequirePermission("bank:upload"), bankController.DeleteTransactionHandler)
+		}
+
+		orgs := api.Group("/orgs", middleware.AuthMiddleware(keyManager), middleware.RateLimit(orgRateLimit))
 		{
-			bank.POST("/upload", bankController.UploadBankStatement)
+			orgs.GET("", orgController.ListOrganizationsHandler)
+			orgs.POST("", orgController.CreateOrganizationHandler)
+			orgs.POST("/invitations/accept", orgController.AcceptInvitationHandler)
+			orgs.POST("/:id/switch", orgController.SwitchOrganizationHandler)
+			orgs.GET("/:id/members", orgController.ListMembersHandler)
+			orgs.POST("/:id/invitations", orgController.InviteMemberHandler)
+			orgs.PATCH("/:id/members/:userId", orgController.UpdateMemberRoleHandler)
+			orgs.DELETE("/:id/members/:userId", orgController.RemoveMemberHandler)
+		}
+
+		admin := api.Group("/admin", middleware.AuthMiddleware(keyManager), middleware.RequireRole("admin"), middleware.RateLimit(adminRateLimit))
+		{
+			admin.GET("/auth-events", middleware.RequirePermission("audit:read"), adminController.ListAuthEventsHandler)
+			admin.GET("/roles", middleware.RequirePermission("roles:manage"), adminController.ListRolesHandler)
+			admin.GET("/users/:id/roles", middleware.RequirePermission("roles:manage"), adminController.ListUserRolesHandler)
+			admin.POST("/users/:id/roles", middleware.RequirePermission("roles:manage"), adminController.GrantRoleHandler)
+			admin.DELETE("/users/:id/roles/:role", middleware.RequirePermission("roles:manage"), adminController.RevokeRoleHandler)
+
+			admin.GET("/users", middleware.RequirePermission("users:read"), adminController.ListUsersHandler)
+			admin.GET("/users/:id", middleware.RequirePermission("users:read"), adminController.GetUserHandler)
+			admin.GET("/users/:id/status-history", middleware.RequirePermission("users:read"), adminController.StatusHistoryHandler)
+			admin.POST("/users/:id/suspend", middleware.RequirePermission("users:write"), adminController.SuspendUserHandler)
+			admin.POST("/users/:id/reactivate", middleware.RequirePermission("users:write"), adminController.ReactivateUserHandler)
+			admin.POST("/users/:id/verify", middleware.RequirePermission("users:write"), adminController.VerifyUserHandler)
+			admin.POST("/users/:id/password-reset", middleware.RequirePermission("users:write"), adminController.ResetUserPasswordHandler)
+			admin.DELETE("/users/:id", middleware.RequirePermission("users:write"), adminController.DeleteUserHandler)
+
+			admin.GET("/oauth-clients", middleware.RequirePermission("clients:manage"), adminController.ListOAuthClientsHandler)
+			admin.POST("/oauth-clients", middleware.RequirePermission("clients:manage"), adminController.CreateOAuthClientHandler)
+			admin.DELETE("/oauth-clients/:clientId", middleware.RequirePermission("clients:manage"), adminController.DeleteOAuthClientHandler)
+
+			admin.GET("/api-keys", middleware.RequirePermission("api_keys:manage"), adminController.ListAPIKeysHandler)
+			admin.POST("/api-keys", middleware.RequirePermission("api_keys:manage"), adminController.CreateAPIKeyHandler)
+			admin.DELETE("/api-keys/:keyId", middleware.RequirePermission("api_keys:manage"), adminController.RevokeAPIKeyHandler)
 		}
 
 		// test endpoint, remove after use
@@ -69,7 +202,21 @@ func Routes(router *gin.Engine, database *sqlx.DB, rdb *redis.Client, logger *sl
 		})
 	}
 
-	router.GET("/protected", middleware.AuthMiddleware(authController.JwtToken), func(c *gin.Context) {
+	router.GET("/.well-known/jwks.json", wellKnownController.JWKSHandler)
+
+	if oidcController != nil {
+		router.GET("/.well-known/openid-configuration", oidcController.DiscoveryHandler)
+
+		oauth2 := router.Group("/oauth2", middleware.RateLimit(oidcRateLimit))
+		{
+			oauth2.GET("/authorize", middleware.OptionalAuthMiddleware(keyManager), oidcController.AuthorizeHandler)
+			oauth2.POST("/token", oidcController.TokenHandler)
+			oauth2.GET("/userinfo", oidcController.UserInfoHandler)
+			oauth2.POST("/userinfo", oidcController.UserInfoHandler)
+		}
+	}
+
+	router.GET("/protected", middleware.AuthMiddleware(keyManager), func(c *gin.Context) {
 		// Protected route logic
 		user, _ := c.Get("user")
 		c.JSON(200, gin.H{
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jalil32/go-auth-module/config"
+)
+
+// defaultRetention is used when the configured token lifetimes cannot be parsed
+const defaultRetention = 24 * time.Hour
+
+// ErrTokenRevoked is returned when a token has been revoked individually or by a per-user cut off
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// Cache is the subset of the redis client needed to track revoked sessions
+type Cache interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+}
+
+// RevocationStore keeps a redis backed deny list of token IDs and a per-user "tokens issued before" timestamp
+type RevocationStore struct {
+	Cache Cache
+	// Retention is how long a per-user cut off is kept, it must outlive every token it could apply to
+	Retention time.Duration
+}
+
+// NewRevocationStore initializes a RevocationStore that retains cut offs for the longest configured token lifetime
+func NewRevocationStore(cache Cache, cfg config.JWTConfig) *RevocationStore {
+	retention := defaultRetention
+	for _, lifetime := range []string{cfg.Expiry, cfg.RefreshExpiry} {
+		if duration, err := time.ParseDuration(lifetime); err == nil && duration > retention {
+			retention = duration
+		}
+	}
+
+	return &RevocationStore{
+		Cache:     cache,
+		Retention: retention,
+	}
+}
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("revoked_token:%s", jti)
+}
+
+func tokensValidAfterKey(userID int) string {
+	return fmt.Sprintf("tokens_valid_after:%d", userID)
+}
+
+// RevokeToken adds a single token to the deny list until it would have expired anyway
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.Cache.Set(ctx, revokedTokenKey(jti), "1", ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserTokens rejects every token for the user that was issued before the given time. Tokens carry their issue
+// time in milliseconds, so tokens issued straight after the revoke, such as a new sign in, are valid.
+func (s *RevocationStore) RevokeUserTokens(ctx context.Context, userID int, before time.Time) error {
+	if err := s.Cache.Set(ctx, tokensValidAfterKey(userID), before.UnixMilli(), s.Retention).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// TokensValidAfter returns the per-user cut off, or the zero time if none has been set
+func (s *RevocationStore) TokensValidAfter(ctx context.Context, userID int) (time.Time, error) {
+	value, err := s.Cache.Get(ctx, tokensValidAfterKey(userID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get user token cut off: %w", err)
+	}
+
+	unixMilli, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid user token cut off: %w", err)
+	}
+
+	return time.UnixMilli(unixMilli), nil
+}
+
+// CheckToken returns ErrTokenRevoked if the token has been revoked or was issued before the user's cut off
+func (s *RevocationStore) CheckToken(ctx context.Context, jti string, userID int, issuedAt time.Time) error {
+	// 1) Check the deny list
+	_, err := s.Cache.Get(ctx, revokedTokenKey(jti)).Result()
+	if err == nil {
+		return ErrTokenRevoked
+	}
+	if !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	// 2) Check the per-user cut off
+	validAfter, err := s.TokensValidAfter(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if issuedAt.Before(validAfter) {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package session_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/session"
+)
+
+// memoryCache is a map backed Cache, expirations are ignored.
+type memoryCache map[string]string
+
+func (m memoryCache) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := m[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (m memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	m[key] = fmt.Sprint(value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func TestRevocationStore_CutOff(t *testing.T) {
+	ctx := context.Background()
+	store := &session.RevocationStore{Cache: memoryCache{}, Retention: time.Hour}
+
+	cutOff := time.Date(2026, 10, 17, 12, 0, 0, 700*int(time.Millisecond), time.UTC)
+	require.NoError(t, store.RevokeUserTokens(ctx, 7, cutOff))
+
+	// 1) Tokens issued before the cut off are revoked, to the millisecond
+	assert.ErrorIs(t, store.CheckToken(ctx, "a", 7, cutOff.Add(-time.Minute)), session.ErrTokenRevoked)
+	assert.ErrorIs(t, store.CheckToken(ctx, "b", 7, cutOff.Add(-time.Millisecond)), session.ErrTokenRevoked)
+
+	// 2) Tokens issued in the same second after the revoke, such as a new sign in or the tokens change password
+	// re-issues, are valid
+	assert.NoError(t, store.CheckToken(ctx, "c", 7, cutOff))
+	assert.NoError(t, store.CheckToken(ctx, "d", 7, cutOff.Add(time.Millisecond)))
+
+	// 3) Tokens that only carry whole seconds are revoked when the cut off falls later in their second
+	assert.ErrorIs(t, store.CheckToken(ctx, "e", 7, cutOff.Truncate(time.Second)), session.ErrTokenRevoked)
+
+	// 4) Other users and individually revoked tokens are unaffected by the cut off
+	assert.NoError(t, store.CheckToken(ctx, "f", 8, cutOff.Add(-time.Minute)))
+	require.NoError(t, store.RevokeToken(ctx, "d", time.Now().Add(time.Minute)))
+	assert.ErrorIs(t, store.CheckToken(ctx, "d", 7, cutOff.Add(time.Hour)), session.ErrTokenRevoked)
+}
@@ -0,0 +1,60 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JSONWebKey is the public part of a signing key as described in RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	// RSA public key parameters
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+	// Ed25519 public key parameters
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the document served from /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys that tokens may be verified with.
+// Symmetric keys are never published.
+func (m *KeyManager) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, kid := range m.order {
+		key := m.keys[kid]
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "RSA",
+				Use:       "sig",
+				KeyID:     key.ID,
+				Algorithm: key.Method.Alg(),
+				Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "OKP",
+				Use:       "sig",
+				KeyID:     key.ID,
+				Algorithm: key.Method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
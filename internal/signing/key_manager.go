@@ -0,0 +1,226 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/jalil32/go-auth-module/config"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing keys
+const minRSAKeyBits = 2048
+
+// legacyKeyID is the kid given to the shared HMAC secret when no asymmetric keys are configured
+const legacyKeyID = "hs256"
+
+// Key is a single signing or verification key identified by its kid
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private is nil for keys that are only kept around to verify tokens during a rotation
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeyManager holds every key that tokens may be verified with and the single active key used for signing
+type KeyManager struct {
+	keys   map[string]*Key
+	order  []string
+	active *Key
+}
+
+// LoadKeyManager builds a KeyManager from the JWT config.
+// JWT_SIGNING_KEYS is a comma separated list of kid=path pairs pointing at PEM encoded RSA or Ed25519 keys.
+// Private keys can sign and verify, public keys are verify only and let old tokens validate while a key is retired.
+// JWT_ACTIVE_KEY_ID picks the signing key and defaults to the first private key in the list.
+// When no keys are configured the legacy JWT_TOKEN HMAC secret is used instead.
+func LoadKeyManager(cfg config.JWTConfig) (*KeyManager, error) {
+	manager := &KeyManager{keys: map[string]*Key{}}
+
+	// 1) Fall back to the shared secret if no asymmetric keys have been configured
+	if strings.TrimSpace(cfg.SigningKeys) == "" {
+		if cfg.Token == "" {
+			return nil, errors.New("no JWT signing keys configured")
+		}
+
+		key := &Key{ID: legacyKeyID, Method: jwt.SigningMethodHS256, Private: []byte(cfg.Token), Public: []byte(cfg.Token)}
+		manager.add(key)
+		manager.active = key
+		return manager, nil
+	}
+
+	// 2) Load every configured key
+	for _, entry := range strings.Split(cfg.SigningKeys, ",") {
+		kid, path, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid signing key entry %q, expected kid=path", entry)
+		}
+
+		if _, exists := manager.keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", kid)
+		}
+
+		key, err := loadPEMKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+
+		manager.add(key)
+	}
+
+	// 3) Pick the active signing key
+	if cfg.ActiveKeyID != "" {
+		key, ok := manager.keys[cfg.ActiveKeyID]
+		if !ok {
+			return nil, fmt.Errorf("active signing key %q is not configured", cfg.ActiveKeyID)
+		}
+		manager.active = key
+	} else {
+		for _, kid := range manager.order {
+			if manager.keys[kid].Private != nil {
+				manager.active = manager.keys[kid]
+				break
+			}
+		}
+	}
+
+	if manager.active == nil || manager.active.Private == nil {
+		return nil, errors.New("active signing key must be a private key")
+	}
+
+	return manager, nil
+}
+
+func (m *KeyManager) add(key *Key) {
+	m.keys[key.ID] = key
+	m.order = append(m.order, key.ID)
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(m.active.Method, claims)
+	token.Header["kid"] = m.active.ID
+
+	signedToken, err := token.SignedString(m.active.Private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signedToken, nil
+}
+
+// SigningAlgorithm returns the algorithm of the active key, e.g. "RS256"
+func (m *KeyManager) SigningAlgorithm() string {
+	return m.active.Method.Alg()
+}
+
+// Asymmetric reports whether tokens are signed with a key that others can verify from the JWKS
+func (m *KeyManager) Asymmetric() bool {
+	_, symmetric := m.active.Method.(*jwt.SigningMethodHMAC)
+	return !symmetric
+}
+
+// Keyfunc selects the verification key by the token's kid header, for use with jwt.Parse
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token is missing the kid header")
+	}
+
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	// Never let the token choose a different algorithm than the key was loaded for
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v for key %q", token.Header["alg"], kid)
+	}
+
+	return key.Public, nil
+}
+
+// Algorithms returns the signing algorithms of every loaded key, for use with jwt.WithValidMethods
+func (m *KeyManager) Algorithms() []string {
+	seen := map[string]bool{}
+	var algorithms []string
+	for _, kid := range m.order {
+		alg := m.keys[kid].Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+// Parse verifies a token against the loaded keys and returns its claims
+func (m *KeyManager) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, m.Keyfunc, jwt.WithValidMethods(m.Algorithms()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// loadPEMKey reads a PEM file containing an RSA or Ed25519 private or public key
+func loadPEMKey(kid string, path string) (*Key, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from trusted configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %q: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q is not PEM encoded", kid)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %q has unsupported PEM type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %q: %w", kid, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("signing key %q must be at least %d bits", kid, minRSAKeyBits)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("signing key %q must be at least %d bits", kid, minRSAKeyBits)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return nil, fmt.Errorf("signing key %q must be an RSA or Ed25519 key", kid)
+	}
+}
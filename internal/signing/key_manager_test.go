@@ -0,0 +1,149 @@
+package signing_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/config"
+	"github.com/jalil32/go-auth-module/internal/signing"
+)
+
+// writePEM writes a PEM block to a file in dir and returns its path.
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func generateKeys(t *testing.T) (rsaPath string, rsaPublicPath string, edPath string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath = writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	rsaPublicPath = writePEM(t, dir, "rsa.pub.pem", "PUBLIC KEY", rsaPublic)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPath = writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", edDER)
+
+	return rsaPath, rsaPublicPath, edPath
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	rsaPath, rsaPublicPath, edPath := generateKeys(t)
+
+	// 1) Before the rotation tokens are signed with the RSA key
+	before, err := signing.LoadKeyManager(config.JWTConfig{SigningKeys: "old=" + rsaPath})
+	require.NoError(t, err)
+
+	oldToken, err := before.Sign(claims())
+	require.NoError(t, err)
+
+	// 2) During the rotation the new Ed25519 key signs and the old key is kept as verify only
+	during, err := signing.LoadKeyManager(config.JWTConfig{SigningKeys: "old=" + rsaPublicPath + ",new=" + edPath, ActiveKeyID: "new"})
+	require.NoError(t, err)
+
+	newToken, err := during.Sign(claims())
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", during.SigningAlgorithm())
+	assert.True(t, during.Asymmetric())
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	_, err = during.Parse(oldToken)
+	assert.NoError(t, err, "tokens signed with the retired key should still verify")
+
+	_, err = during.Parse(newToken)
+	assert.NoError(t, err)
+
+	// 3) Both keys are published and no private material leaks into the key set
+	jwks := during.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+
+	// 4) After the rotation the old key is gone and its tokens are rejected
+	after, err := signing.LoadKeyManager(config.JWTConfig{SigningKeys: "new=" + edPath})
+	require.NoError(t, err)
+
+	_, err = after.Parse(oldToken)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaPath, rsaPublicPath, _ := generateKeys(t)
+
+	manager, err := signing.LoadKeyManager(config.JWTConfig{SigningKeys: "rsa=" + rsaPath})
+	require.NoError(t, err)
+
+	// A token signed with HMAC using the public key bytes must not verify against the RSA key
+	publicPEM, err := os.ReadFile(rsaPublicPath)
+	require.NoError(t, err)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "rsa"
+	forgedToken, err := forged.SignedString(publicPEM)
+	require.NoError(t, err)
+
+	_, err = manager.Parse(forgedToken)
+	assert.Error(t, err)
+}
+
+func TestLoadKeyManager_Errors(t *testing.T) {
+	_, rsaPublicPath, _ := generateKeys(t)
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+	}{
+		{name: "No keys", cfg: config.JWTConfig{}},
+		{name: "Malformed entry", cfg: config.JWTConfig{SigningKeys: "missing-path"}},
+		{name: "Missing file", cfg: config.JWTConfig{SigningKeys: "a=/does/not/exist.pem"}},
+		{name: "Only public keys", cfg: config.JWTConfig{SigningKeys: "a=" + rsaPublicPath}},
+		{name: "Unknown active key", cfg: config.JWTConfig{SigningKeys: "a=" + rsaPublicPath, ActiveKeyID: "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signing.LoadKeyManager(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyManager_LegacySecretIsSymmetric(t *testing.T) {
+	manager, err := signing.LoadKeyManager(config.JWTConfig{Token: "secret"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "HS256", manager.SigningAlgorithm())
+	assert.False(t, manager.Asymmetric(), "HMAC keys cannot be published, so ID tokens must not be signed with them")
+}
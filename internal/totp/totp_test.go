@@ -0,0 +1,71 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalil32/go-auth-module/internal/totp"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes, authenticator apps use the last 6 of them
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "unix time %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := totp.Validate(rfcSecret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	// A code from the previous period is still accepted to allow for clock drift
+	step, ok = totp.Validate(rfcSecret, "081804", now.Add(totp.Period))
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	// But not one from two periods ago
+	_, ok = totp.Validate(rfcSecret, "081804", now.Add(2*totp.Period))
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "000000", now)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "81804", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := totp.URI("Go Auth", "user@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Go%20Auth:user@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Go+Auth")
+}
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT,                        -- AES-GCM encrypted TOTP secret, set during enrollment
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE; -- True once the user has confirmed enrollment
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    user_id INT NOT NULL,                           -- User the code belongs to
+    code_hash TEXT NOT NULL,                        -- SHA-256 hash of the recovery code
+    used_at TIMESTAMP,                              -- Set when the code has been consumed
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Auto-generated timestamp
+    CONSTRAINT fk_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- Remove recovery codes when the user is deleted
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mfa_recovery_codes;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_secret,
+    DROP COLUMN IF EXISTS totp_enabled;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    user_id INT NOT NULL,                           -- User the passkey belongs to
+    name VARCHAR(100) NOT NULL,                     -- User chosen label, e.g. "MacBook Touch ID"
+    credential_id BYTEA UNIQUE NOT NULL,            -- Credential ID returned by the authenticator
+    public_key BYTEA NOT NULL,                      -- COSE encoded credential public key
+    attestation_type TEXT NOT NULL,                 -- Attestation format used during registration
+    transports TEXT NOT NULL DEFAULT '',            -- Comma separated transports, e.g. "internal,hybrid"
+    aaguid BYTEA,                                   -- Authenticator model identifier
+    sign_count BIGINT NOT NULL DEFAULT 0,           -- Signature counter used to detect cloned authenticators
+    user_present BOOLEAN NOT NULL DEFAULT FALSE,
+    user_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE, -- True for synced passkeys, never changes
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,    -- True once the passkey has been backed up
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Auto-generated timestamp
+    last_used_at TIMESTAMP,                         -- Updated on every successful login
+    CONSTRAINT fk_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- Remove passkeys when the user is deleted
+);
+
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webauthn_credentials_user_id;
+DROP TABLE IF EXISTS webauthn_credentials;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    name VARCHAR(50) UNIQUE NOT NULL,               -- Role name used in tokens and RequireRole, e.g. "admin"
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- Auto-generated timestamp
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    name VARCHAR(100) UNIQUE NOT NULL,              -- "resource:action" name used in tokens and RequirePermission
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- Auto-generated timestamp
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_by INT REFERENCES users(id) ON DELETE SET NULL, -- Admin who granted the role, NULL if seeded
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,         -- Auto-generated timestamp
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+-- Seed the built in roles and permissions
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access to user and role management'),
+    ('member', 'Regular user of the product');
+
+INSERT INTO permissions (name, description) VALUES
+    ('roles:manage', 'Grant and revoke roles'),
+    ('users:read', 'View other users'),
+    ('users:write', 'Manage other users'),
+    ('bank:upload', 'Upload bank statements');
+
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions WHERE roles.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles JOIN permissions ON permissions.name = 'bank:upload' WHERE roles.name = 'member';
+
+-- Every existing user is a member
+INSERT INTO user_roles (user_id, role_id)
+    SELECT users.id, roles.id FROM users CROSS JOIN roles WHERE roles.name = 'member';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_roles_role_id;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
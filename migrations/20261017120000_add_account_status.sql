@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'pending_verification'
+        CHECK (status IN ('pending_verification', 'active', 'suspended', 'deleted')), -- Account lifecycle state
+    ADD COLUMN status_reason TEXT,                                                    -- Why the status last changed
+    ADD COLUMN status_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;                 -- When the status last changed
+
+-- Carry over the old flag, deactivated accounts become suspended
+UPDATE users SET status = CASE
+    WHEN is_active = FALSE THEN 'suspended'
+    WHEN verified THEN 'active'
+    ELSE 'pending_verification'
+END;
+
+ALTER TABLE users DROP COLUMN is_active;
+
+CREATE INDEX idx_users_status ON users(status);
+
+CREATE TABLE IF NOT EXISTS account_status_history (
+    id SERIAL PRIMARY KEY,                                      -- Auto-incremented unique ID
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_status TEXT,                                           -- NULL for the first recorded status
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,     -- Admin who made the change, NULL if the system did
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP              -- Auto-generated timestamp
+);
+
+CREATE INDEX idx_account_status_history_user_id ON account_status_history(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_status_history;
+
+ALTER TABLE users ADD COLUMN is_active BOOLEAN DEFAULT TRUE;
+UPDATE users SET is_active = status IN ('pending_verification', 'active');
+
+DROP INDEX IF EXISTS idx_users_status;
+ALTER TABLE users
+    DROP COLUMN status,
+    DROP COLUMN status_reason,
+    DROP COLUMN status_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS auth_events (
+    id BIGSERIAL PRIMARY KEY,                                        -- Auto-incremented unique event ID
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,             -- User who acted, NULL if unknown or deleted
+    target_user_id INT REFERENCES users(id) ON DELETE SET NULL,      -- User an admin acted on
+    email VARCHAR(255) NOT NULL DEFAULT '',                          -- Email given with the attempt, kept for unknown users
+    event_type TEXT NOT NULL,                                        -- e.g. login, register, password_reset_requested
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',                                 -- Failure reason or how the event happened
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP                   -- Auto-generated timestamp
+);
+
+CREATE INDEX idx_auth_events_user_id_created_at ON auth_events(user_id, created_at DESC);
+CREATE INDEX idx_auth_events_created_at ON auth_events(created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES ('audit:read', 'View the security audit log');
+
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles JOIN permissions ON permissions.name = 'audit:read' WHERE roles.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'audit:read';
+DROP INDEX IF EXISTS idx_auth_events_created_at;
+DROP INDEX IF EXISTS idx_auth_events_user_id_created_at;
+DROP TABLE IF EXISTS auth_events;
+-- +goose StatementEnd
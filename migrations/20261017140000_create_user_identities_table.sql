@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    user_id INT NOT NULL,                           -- User the identity signs in to
+    provider TEXT NOT NULL,                         -- "password" or an OAuth provider, e.g. "google"
+    provider_user_id TEXT,                          -- Subject ID from the provider, NULL for passwords and identities created before linking
+    email VARCHAR(255),                             -- Email the provider reported when the identity was linked
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Auto-generated timestamp
+    last_used_at TIMESTAMP,                         -- Updated on every sign in with the identity
+    CONSTRAINT fk_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE, -- Remove identities when the user is deleted
+    CONSTRAINT uq_user_identities_user_provider UNIQUE (user_id, provider),
+    CONSTRAINT uq_user_identities_subject UNIQUE (provider, provider_user_id)
+);
+
+-- Every existing password and provider sign in becomes an identity, provider subjects are filled in on the next sign in
+INSERT INTO user_identities (user_id, provider, email)
+    SELECT id, 'password', email FROM users WHERE password_hash IS NOT NULL;
+
+INSERT INTO user_identities (user_id, provider, email)
+    SELECT id, provider, email FROM users WHERE provider IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    client_id TEXT UNIQUE NOT NULL,                 -- Public identifier sent by the client application
+    client_secret_hash TEXT,                        -- SHA-256 of the client secret, NULL for public clients that only use PKCE
+    name VARCHAR(100) NOT NULL,                     -- Application name, e.g. "Budgeting"
+    redirect_uris TEXT[] NOT NULL,                  -- Exact redirect URIs the application may receive codes on
+    created_by INT REFERENCES users(id) ON DELETE SET NULL, -- Admin who registered the application
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- Auto-generated timestamp
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('clients:manage', 'Register and remove OpenID Connect client applications');
+
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles JOIN permissions ON permissions.name = 'clients:manage' WHERE roles.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'clients:manage';
+DROP TABLE IF EXISTS oauth_clients;
+-- +goose StatementEnd
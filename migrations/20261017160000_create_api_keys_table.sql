@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    name VARCHAR(100) NOT NULL,                     -- What the key is for, e.g. "Nightly statement import"
+    prefix VARCHAR(16) NOT NULL,                    -- Start of the key, shown so keys can be told apart
+    key_hash TEXT UNIQUE NOT NULL,                  -- SHA-256 of the key, the key itself is only shown once
+    scopes TEXT[] NOT NULL,                         -- Permissions the key grants, e.g. {bank:upload}
+    expires_at TIMESTAMP,                           -- NULL for keys that do not expire
+    last_used_at TIMESTAMP,                         -- Updated at most once a minute while the key is used
+    created_by INT REFERENCES users(id) ON DELETE SET NULL, -- Admin who created the key
+    revoked_at TIMESTAMP,                           -- Set when the key is revoked, revoked keys are kept for the record
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- Auto-generated timestamp
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('api_keys:manage', 'Create and revoke API keys for services');
+
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles JOIN permissions ON permissions.name = 'api_keys:manage' WHERE roles.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'api_keys:manage';
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    user_id INT NOT NULL,                           -- User the token acts as
+    name VARCHAR(100) NOT NULL,                     -- What the token is for, e.g. "Portfolio script"
+    prefix VARCHAR(16) NOT NULL,                    -- Start of the token, shown so tokens can be told apart
+    token_hash TEXT UNIQUE NOT NULL,                -- SHA-256 of the token, the token itself is only shown once
+    scopes TEXT[] NOT NULL,                         -- Permissions the token grants, limited to the user's own
+    expires_at TIMESTAMP,                           -- NULL for tokens that do not expire
+    last_used_at TIMESTAMP,                         -- Updated at most once a minute while the token is used
+    last_used_ip VARCHAR(45),                       -- Client IP of the last use
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Auto-generated timestamp
+    CONSTRAINT fk_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE -- Remove tokens when the user is deleted
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_access_tokens;
+-- +goose StatementEnd
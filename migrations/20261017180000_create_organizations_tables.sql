@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    name VARCHAR(100) NOT NULL,                     -- Shown to members, e.g. "Smith household"
+    created_by INT REFERENCES users(id) ON DELETE SET NULL, -- User who created the organization
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP  -- Auto-generated timestamp
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+    id SERIAL PRIMARY KEY,                          -- Auto-incremented unique ID
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member')), -- What the user may do in the organization
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Auto-generated timestamp
+    UNIQUE (organization_id, user_id)               -- A user is a member of an organization at most once
+);
+
+CREATE INDEX idx_memberships_user_id ON memberships (user_id);
+
+-- The organization the user works in, it is carried in the access token as the org_id claim
+ALTER TABLE users ADD COLUMN active_organization_id INT REFERENCES organizations(id) ON DELETE SET NULL;
+
+-- Services act in the organization their key is bound to
+ALTER TABLE api_keys ADD COLUMN organization_id INT REFERENCES organizations(id) ON DELETE SET NULL;
+
+-- Transactions belong to an organization, rows uploaded before organizations existed have none
+ALTER TABLE bank_transactions ADD COLUMN organization_id INT REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_bank_transactions_organization_id ON bank_transactions (organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS active_organization_id;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO permissions (name, description) VALUES
+    ('bank:read', 'View your own bank transactions');
+
+-- Admins hold every permission and every user is a member
+INSERT INTO role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles JOIN permissions ON permissions.name = 'bank:read' WHERE roles.name IN ('admin', 'member');
+
+-- Transactions are always looked up by their owner
+CREATE INDEX idx_bank_transactions_user_id_organization_id ON bank_transactions (user_id, organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bank_transactions_user_id_organization_id;
+DELETE FROM permissions WHERE name = 'bank:read';
+-- +goose StatementEnd
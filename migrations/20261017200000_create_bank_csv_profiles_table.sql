@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS bank_csv_profiles (
+    id SERIAL PRIMARY KEY,                                  -- Auto-incremented unique ID
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Profiles are private to the user who saved them
+    name VARCHAR(100) NOT NULL,                             -- Shown when picking a profile, e.g. "Westpac"
+    delimiter VARCHAR(1) NOT NULL DEFAULT '',               -- Empty detects the delimiter from the file
+    encoding VARCHAR(20) NOT NULL DEFAULT '',               -- Empty detects the encoding from the BOM and content
+    header_row INT NOT NULL DEFAULT 1,                      -- Line of the header, rows above it are skipped
+    date_column VARCHAR(100) NOT NULL,                      -- Header of the booking date column
+    date_formats TEXT[] NOT NULL DEFAULT '{}',              -- Tried in order, e.g. {DD/MM/YYYY,YYYY-MM-DD}
+    description_column VARCHAR(100) NOT NULL,
+    amount_column VARCHAR(100) NOT NULL DEFAULT '',         -- Signed amounts, empty when debit and credit are split
+    debit_column VARCHAR(100) NOT NULL DEFAULT '',          -- Money out
+    credit_column VARCHAR(100) NOT NULL DEFAULT '',         -- Money in
+    decimal_separator VARCHAR(1) NOT NULL DEFAULT '.',      -- The other of "." and "," separates thousands
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP          -- Auto-generated timestamp
+);
+
+CREATE INDEX idx_bank_csv_profiles_user_id ON bank_csv_profiles (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS bank_csv_profiles;
+-- +goose StatementEnd
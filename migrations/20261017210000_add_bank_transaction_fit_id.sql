@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- OFX and QFX statements carry the bank's own ID for every transaction, FITID, unique within the account
+ALTER TABLE bank_transactions ADD COLUMN account TEXT NOT NULL DEFAULT '';
+ALTER TABLE bank_transactions ADD COLUMN fit_id TEXT NOT NULL DEFAULT '';
+
+-- Importing the same statement twice skips the transactions that are already stored
+CREATE UNIQUE INDEX idx_bank_transactions_fit_id ON bank_transactions (user_id, organization_id, account, fit_id) WHERE fit_id <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bank_transactions_fit_id;
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS fit_id;
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS account;
+-- +goose StatementEnd
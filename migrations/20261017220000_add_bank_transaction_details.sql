@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Business statements (camt.053, MT940) say when the money moved, in which currency, with whom and why
+ALTER TABLE bank_transactions ADD COLUMN value_date TIMESTAMP;
+ALTER TABLE bank_transactions ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE bank_transactions ADD COLUMN counterparty TEXT NOT NULL DEFAULT '';
+ALTER TABLE bank_transactions ADD COLUMN remittance_info TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS remittance_info;
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS counterparty;
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS currency;
+ALTER TABLE bank_transactions DROP COLUMN IF EXISTS value_date;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Business accounts move more than the 21 million an INT holds in cents
+ALTER TABLE bank_transactions ALTER COLUMN amount_cents TYPE BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bank_transactions ALTER COLUMN amount_cents TYPE INT;
+-- +goose StatementEnd
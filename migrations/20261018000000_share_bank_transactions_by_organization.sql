@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every user owns a personal organization. Users without one, or with transactions uploaded before organizations
+-- existed, get it now and those transactions move into it.
+WITH owners AS (
+    SELECT id FROM users
+    WHERE NOT EXISTS (SELECT 1 FROM memberships WHERE memberships.user_id = users.id)
+       OR EXISTS (SELECT 1 FROM bank_transactions WHERE bank_transactions.user_id = users.id AND bank_transactions.organization_id IS NULL)
+), personal AS (
+    INSERT INTO organizations (name, created_by)
+    SELECT 'Personal', id FROM owners
+    RETURNING id, created_by
+), joined AS (
+    INSERT INTO memberships (organization_id, user_id, role)
+    SELECT id, created_by, 'owner' FROM personal
+), moved AS (
+    UPDATE bank_transactions SET organization_id = personal.id
+    FROM personal
+    WHERE bank_transactions.user_id = personal.created_by AND bank_transactions.organization_id IS NULL
+)
+UPDATE users SET active_organization_id = personal.id
+FROM personal
+WHERE users.id = personal.created_by AND users.active_organization_id IS NULL;
+
+-- Members share the organization's transactions, so a statement two members imported is only kept once
+DELETE FROM bank_transactions duplicate
+USING bank_transactions kept
+WHERE duplicate.fit_id <> ''
+  AND duplicate.organization_id = kept.organization_id
+  AND duplicate.account = kept.account
+  AND duplicate.fit_id = kept.fit_id
+  AND duplicate.transaction_id > kept.transaction_id;
+
+DROP INDEX IF EXISTS idx_bank_transactions_fit_id;
+CREATE UNIQUE INDEX idx_bank_transactions_fit_id ON bank_transactions (organization_id, account, fit_id) WHERE fit_id <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Personal organizations are kept, they are ordinary organizations owned by their user
+DROP INDEX IF EXISTS idx_bank_transactions_fit_id;
+CREATE UNIQUE INDEX idx_bank_transactions_fit_id ON bank_transactions (user_id, organization_id, account, fit_id) WHERE fit_id <> '';
+-- +goose StatementEnd